@@ -0,0 +1,137 @@
+package api
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const defaultAdminsTTL = time.Minute * 10
+
+// adminCache holds each group's administrators, as listed by getChatAdministrators.
+//
+// Entries expire after a TTL, and are dropped early whenever a chat_member or
+// my_chat_member update shows a membership change in the chat. Each chat is fetched by
+// one caller at a time, with the lock released meanwhile, and the rest wait for its list.
+type adminCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	chats    map[int64]*chatAdmins
+	fetching map[int64]*adminFetch
+}
+
+type chatAdmins struct {
+	ids     map[int64]bool
+	fetched time.Time
+}
+
+// adminFetch is a getChatAdministrators call in progress, with ids set before done closes.
+type adminFetch struct {
+	done chan struct{}
+	ids  map[int64]bool
+}
+
+func newAdminCache(ttl time.Duration) *adminCache {
+	return &adminCache{
+		ttl:      ttl,
+		chats:    map[int64]*chatAdmins{},
+		fetching: map[int64]*adminFetch{},
+	}
+}
+
+// IsChatAdmin reports whether the user is an administrator or the creator of the chat.
+func (s *Server) IsChatAdmin(chatID, userID int64) bool {
+	return s.ChatAdmins(chatID)[userID]
+}
+
+// ChatAdmins returns the set of administrator IDs for a chat, fetching it if not cached.
+// On error, the last known list is kept, if there is one.
+func (s *Server) ChatAdmins(chatID int64) map[int64]bool {
+	c := s.admins
+
+	c.mu.Lock()
+
+	cached, ok := c.chats[chatID]
+	if ok && time.Since(cached.fetched) < c.ttl {
+		c.mu.Unlock()
+		return cached.ids
+	}
+
+	if f, ok := c.fetching[chatID]; ok {
+		c.mu.Unlock()
+		<-f.done
+		return f.ids
+	}
+
+	f := &adminFetch{done: make(chan struct{})}
+	c.fetching[chatID] = f
+	c.mu.Unlock()
+
+	defer close(f.done)
+
+	ids := s.fetchAdmins(chatID)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Invalidated while fetching, the list may be stale, so it's given only to those waiting
+	fresh := c.fetching[chatID] == f
+	if fresh {
+		delete(c.fetching, chatID)
+	}
+
+	switch {
+	case ids == nil && ok:
+		ids = cached.ids
+	case ids != nil && fresh:
+		c.chats[chatID] = &chatAdmins{ids, time.Now()}
+	}
+
+	f.ids = ids
+	return ids
+}
+
+// fetchAdmins gets the administrators of a chat from Telegram, or nil on error.
+func (s *Server) fetchAdmins(chatID int64) map[int64]bool {
+	members, err := s.Bot.GetChatAdministrators(botapi.ChatAdministratorsConfig{
+		ChatConfig: botapi.ChatConfig{ChatID: chatID},
+	})
+
+	if err != nil {
+		log.Printf("Server: Error getting admins of chat %d: %q", chatID, err.Error())
+		return nil
+	}
+
+	ids := make(map[int64]bool, len(members))
+	for _, m := range members {
+		if m.User != nil && (m.IsAdministrator() || m.IsCreator()) {
+			ids[m.User.ID] = true
+		}
+	}
+
+	return ids
+}
+
+// InvalidateAdmins drops the cached administrators of a chat, and any list being fetched.
+func (s *Server) InvalidateAdmins(chatID int64) {
+	s.admins.mu.Lock()
+	defer s.admins.mu.Unlock()
+
+	delete(s.admins.chats, chatID)
+	delete(s.admins.fetching, chatID)
+}
+
+// HandleChatMember keeps the admin cache fresh as members are promoted, demoted, or leave.
+func (ctx *Context) HandleChatMember() {
+	for _, m := range []*botapi.ChatMemberUpdated{ctx.Update.ChatMember, ctx.Update.MyChatMember} {
+		if m == nil {
+			continue
+		}
+
+		if m.OldChatMember.Status != m.NewChatMember.Status {
+			ctx.Server.InvalidateAdmins(m.Chat.ID)
+		}
+	}
+}
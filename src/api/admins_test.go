@@ -0,0 +1,48 @@
+package api_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/willmroliver/plathbot/src/apitest"
+)
+
+func TestChatAdmins(t *testing.T) {
+	h := apitest.New(t)
+	chatID := int64(-600)
+
+	h.SetMember(chatID, 1, "creator")
+	h.SetMember(chatID, 2, "administrator")
+	h.SetMember(chatID, 3, "member")
+
+	wg := sync.WaitGroup{}
+
+	for range 10 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if admins := h.Server.ChatAdmins(chatID); !admins[1] || !admins[2] || admins[3] {
+				t.Errorf("ChatAdmins() - Expected users 1 and 2; Got %v", admins)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := len(h.Requests("getChatAdministrators")); n != 1 {
+		t.Errorf("ChatAdmins() - Expected one fetch for callers at once; Got %d", n)
+	}
+
+	h.SetMember(chatID, 3, "administrator")
+	h.Server.InvalidateAdmins(chatID)
+
+	if !h.Server.IsChatAdmin(chatID, 3) {
+		t.Errorf("IsChatAdmin() - Expected a promotion to show once invalidated; Got false")
+	}
+
+	if n := len(h.Requests("getChatAdministrators")); n != 2 {
+		t.Errorf("ChatAdmins() - Expected a fetch once invalidated; Got %d", n)
+	}
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/i18n"
+)
+
+// ParamType is the kind of value a command parameter accepts.
+type ParamType int
+
+const (
+	ParamWord ParamType = iota
+	ParamInt
+	ParamDuration
+	ParamUser
+	ParamEmoji
+	ParamRest
+)
+
+var paramTypeNames = map[ParamType]string{
+	ParamWord:     "a single word",
+	ParamInt:      "a whole number",
+	ParamDuration: "a duration like 1h30m",
+	ParamUser:     "an @username",
+	ParamEmoji:    "an emoji",
+	ParamRest:     "some text",
+}
+
+// Param declares one argument of a command. Optional and variadic params must come last,
+// as must a ParamRest, which takes everything remaining as one value.
+type Param struct {
+	Name     string
+	Type     ParamType
+	Optional bool
+	Variadic bool
+}
+
+func ArgWord(name string) Param {
+	return Param{Name: name, Type: ParamWord}
+}
+
+func ArgInt(name string) Param {
+	return Param{Name: name, Type: ParamInt}
+}
+
+func ArgDuration(name string) Param {
+	return Param{Name: name, Type: ParamDuration}
+}
+
+func ArgUser(name string) Param {
+	return Param{Name: name, Type: ParamUser}
+}
+
+func ArgEmoji(name string) Param {
+	return Param{Name: name, Type: ParamEmoji}
+}
+
+// ArgRest takes the rest of the line as one value, spaces included.
+func ArgRest(name string) Param {
+	return Param{Name: name, Type: ParamRest}
+}
+
+// Opt returns a copy of the param which may be left out.
+func (p Param) Opt() Param {
+	p.Optional = true
+	return p
+}
+
+// Many returns a copy of the param which takes every remaining argument.
+func (p Param) Many() Param {
+	p.Variadic = true
+	return p
+}
+
+func (p Param) String() string {
+	name := p.Name
+	if p.Variadic || p.Type == ParamRest {
+		name += "..."
+	}
+
+	if p.Optional {
+		return "[" + name + "]"
+	}
+
+	return "<" + name + ">"
+}
+
+// Usage formats a command and its params, e.g. "/pfp add [name...]".
+func Usage(cmd string, params []Param) string {
+	parts := make([]string, len(params)+1)
+	parts[0] = cmd
+
+	for i, p := range params {
+		parts[i+1] = p.String()
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// ArgError reports an argument which is missing or couldn't be parsed.
+type ArgError struct {
+	Param Param
+	Value string
+}
+
+func (e *ArgError) Error() string {
+	return e.Localize(i18n.Default)
+}
+
+func (e *ArgError) Localize(lang string) string {
+	expected := i18n.T(lang, paramTypeNames[e.Param.Type])
+
+	if e.Value == "" {
+		return i18n.T(lang, "Missing %s, expected %s", e.Param.Name, expected)
+	}
+
+	return i18n.T(lang, "Invalid %s %q, expected %s", e.Param.Name, e.Value, expected)
+}
+
+// Args holds the parsed arguments of a command, by param name.
+type Args struct {
+	values map[string][]any
+}
+
+// Has reports whether an optional param was given.
+func (a *Args) Has(name string) bool {
+	return a != nil && len(a.values[name]) != 0
+}
+
+func arg[T any](a *Args, name string) (v T) {
+	if a.Has(name) {
+		v, _ = a.values[name][0].(T)
+	}
+
+	return
+}
+
+func args[T any](a *Args, name string) (vs []T) {
+	if a == nil {
+		return
+	}
+
+	for _, v := range a.values[name] {
+		if t, ok := v.(T); ok {
+			vs = append(vs, t)
+		}
+	}
+
+	return
+}
+
+// String returns a word, emoji or rest-of-line param.
+func (a *Args) String(name string) string {
+	return arg[string](a, name)
+}
+
+func (a *Args) Strings(name string) []string {
+	return args[string](a, name)
+}
+
+func (a *Args) Int(name string) int64 {
+	return arg[int64](a, name)
+}
+
+func (a *Args) Ints(name string) []int64 {
+	return args[int64](a, name)
+}
+
+func (a *Args) Duration(name string) time.Duration {
+	return arg[time.Duration](a, name)
+}
+
+func (a *Args) User(name string) *botapi.User {
+	return arg[*botapi.User](a, name)
+}
+
+func (a *Args) Users(name string) []*botapi.User {
+	return args[*botapi.User](a, name)
+}
+
+// ParseArgs checks raw arguments against params. A missing user param is taken from the
+// message being replied to, if there is one.
+func (ctx *Context) ParseArgs(params []Param, raw []string) (*Args, error) {
+	a := &Args{values: map[string][]any{}}
+	i := 0
+
+	for _, p := range params {
+		if p.Type == ParamRest {
+			if rest := strings.Join(raw[i:], " "); rest != "" {
+				a.values[p.Name] = []any{rest}
+			} else if !p.Optional {
+				return nil, &ArgError{Param: p}
+			}
+
+			i = len(raw)
+			continue
+		}
+
+		n := 1
+		if p.Variadic {
+			n = len(raw) - i
+		}
+
+		if n == 0 || i >= len(raw) {
+			if u := ctx.replyUser(); p.Type == ParamUser && u != nil {
+				a.values[p.Name] = []any{u}
+				continue
+			}
+
+			if !p.Optional {
+				return nil, &ArgError{Param: p}
+			}
+
+			continue
+		}
+
+		for _, s := range raw[i : i+n] {
+			v, ok := ctx.parseArg(p.Type, s)
+			if !ok {
+				return nil, &ArgError{Param: p, Value: s}
+			}
+
+			a.values[p.Name] = append(a.values[p.Name], v)
+		}
+
+		i += n
+	}
+
+	if i < len(raw) {
+		return nil, i18n.Errorf("Unexpected %q", strings.Join(raw[i:], " "))
+	}
+
+	return a, nil
+}
+
+func (ctx *Context) parseArg(t ParamType, s string) (any, bool) {
+	switch t {
+	case ParamInt:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return n, err == nil
+	case ParamDuration:
+		dur, err := time.ParseDuration(s)
+		return dur, err == nil && dur > 0
+	case ParamUser:
+		u := ctx.lookupUser(s)
+		return u, u != nil
+	case ParamEmoji:
+		return s, IsEmoji(s)
+	default:
+		return s, s != ""
+	}
+}
+
+// lookupUser finds a user by @username among those the bot has seen, or by numeric ID.
+func (ctx *Context) lookupUser(s string) *botapi.User {
+	if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return &botapi.User{ID: id}
+	}
+
+	name, ok := strings.CutPrefix(s, "@")
+	if !ok || name == "" || ctx.UserRepo == nil {
+		return nil
+	}
+
+	users := ctx.UserRepo.AllWhere("username = ?", name)
+	if len(users) == 0 {
+		return nil
+	}
+
+	return &botapi.User{ID: users[0].ID, UserName: users[0].Username, FirstName: users[0].FirstName}
+}
+
+func (ctx *Context) replyUser() *botapi.User {
+	if ctx.Message == nil || ctx.Message.ReplyToMessage == nil {
+		return nil
+	}
+
+	return ctx.Message.ReplyToMessage.From
+}
+
+// IsEmoji reports whether s is a single emoji, including modifiers, flags and ZWJ sequences.
+func IsEmoji(s string) bool {
+	symbol := false
+
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.So, r), r >= 0x1F000 && r <= 0x1FAFF:
+			symbol = true
+		case r == 0x200D, r == 0xFE0F, r == 0x20E3, r >= 0xE0020 && r <= 0xE007F:
+			// Joiners, variation selectors, keycaps and tag sequences
+		default:
+			return false
+		}
+	}
+
+	return symbol
+}
@@ -0,0 +1,90 @@
+package api_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+)
+
+func TestParseArgs(t *testing.T) {
+	ctx := &api.Context{Message: &botapi.Message{}}
+
+	params := []api.Param{
+		api.ArgInt("rounds"),
+		api.ArgDuration("for").Opt(),
+		api.ArgEmoji("emojis").Opt().Many(),
+	}
+
+	a, err := ctx.ParseArgs(params, []string{"3", "1h30m", "🙂", "👍🏽", "🇬🇧"})
+	if err != nil {
+		t.Fatalf("ParseArgs() - Expected no error; Got %q", err.Error())
+	}
+
+	if n := a.Int("rounds"); n != 3 {
+		t.Errorf("Int() - Expected 3; Got %d", n)
+	}
+
+	if dur := a.Duration("for"); dur != time.Minute*90 {
+		t.Errorf("Duration() - Expected 1h30m; Got %s", dur)
+	}
+
+	if emojis := a.Strings("emojis"); len(emojis) != 3 {
+		t.Errorf("Strings() - Expected 3 emojis; Got %v", emojis)
+	}
+
+	a, err = ctx.ParseArgs(params, []string{"3"})
+	if err != nil || a.Has("for") || a.Has("emojis") {
+		t.Errorf("ParseArgs() - Expected optional params to be absent; Got %v, %v", err, a)
+	}
+
+	var argErr *api.ArgError
+
+	for _, raw := range [][]string{{}, {"three"}, {"3", "soon"}, {"3", "1h", "x"}} {
+		if _, err = ctx.ParseArgs(params, raw); !errors.As(err, &argErr) {
+			t.Errorf("ParseArgs() - Expected ArgError for %v; Got %v", raw, err)
+		}
+	}
+}
+
+func TestParseArgsRest(t *testing.T) {
+	reply := &botapi.User{ID: 42}
+	ctx := &api.Context{Message: &botapi.Message{ReplyToMessage: &botapi.Message{From: reply}}}
+
+	params := []api.Param{api.ArgUser("user"), api.ArgRest("reason")}
+
+	a, err := ctx.ParseArgs(params, []string{"12345", "too", "many", "puns"})
+	if err != nil {
+		t.Fatalf("ParseArgs() - Expected no error; Got %q", err.Error())
+	}
+
+	if u := a.User("user"); u == nil || u.ID != 12345 {
+		t.Errorf("User() - Expected ID 12345; Got %+v", u)
+	}
+
+	if s := a.String("reason"); s != "too many puns" {
+		t.Errorf("String() - Expected %q; Got %q", "too many puns", s)
+	}
+
+	if a, err = ctx.ParseArgs(params[:1], nil); err != nil || a.User("user") != reply {
+		t.Errorf("ParseArgs() - Expected user from reply; Got %v, %v", err, a)
+	}
+
+	if _, err = ctx.ParseArgs(params[:1], []string{"1", "2"}); err == nil {
+		t.Errorf("ParseArgs() - Expected error for unexpected args")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	usage := api.Usage("/pfp add", []api.Param{
+		api.ArgWord("name"),
+		api.ArgInt("n").Opt(),
+		api.ArgRest("text").Opt(),
+	})
+
+	if expected := "/pfp add <name> [n] [text...]"; usage != expected {
+		t.Errorf("Usage() - Expected %q; Got %q", expected, usage)
+	}
+}
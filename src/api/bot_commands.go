@@ -0,0 +1,158 @@
+package api
+
+import (
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/i18n"
+)
+
+// maxBotCommands is the most commands Telegram accepts per scope.
+const maxBotCommands = 100
+
+var (
+	// describedAPIs holds every CallbackAPI created with a Description, by path, so nested
+	// menus such as "emojis/admin" can be published alongside the top-level ones.
+	describedAPIs = map[string]*CallbackAPI{}
+
+	botCommandName = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+)
+
+// BotCommandName maps a callback path to the command which opens it, e.g. "emojis/admin"
+// becomes "emojis_admin". Context.HandleMessage maps it back.
+func BotCommandName(path string) string {
+	return strings.ReplaceAll(strings.ToLower(path), "/", "_")
+}
+
+// BotCommands lists the commands to show in Telegram's "/" menu for each scope.
+//
+// Every top-level menu is listed, along with nested menus and commands which were given
+// a description. Menus and commands requiring a role are only listed for group admins,
+// whose list also includes everything shown to groups. Inline actions are reached with
+// "@bot action" rather than a slash, so aren't listed.
+func (s *Server) BotCommands() (private, group, admin []botapi.BotCommand) {
+	seen := map[string]bool{}
+
+	add := func(name, description string, privateOnly, publicOnly bool, role Role) {
+		if !botCommandName.MatchString(name) {
+			log.Printf("Server: Not publishing invalid command name %q", name)
+			return
+		}
+
+		if seen[name] {
+			return
+		}
+
+		seen[name] = true
+		cmd := botapi.BotCommand{Command: name, Description: description}
+
+		if role != RoleMember {
+			admin = append(admin, cmd)
+			return
+		}
+
+		if !publicOnly {
+			private = append(private, cmd)
+		}
+
+		if !privateOnly {
+			group = append(group, cmd)
+			admin = append(admin, cmd)
+		}
+	}
+
+	addAPI := func(a *CallbackAPI) {
+		description := a.Description
+		if description == "" {
+			description = a.Title
+		}
+
+		add(BotCommandName(a.Path), description, a.PrivateOnly, a.PublicOnly, a.RequireRole)
+	}
+
+	for _, a := range s.callbackAPIs {
+		addAPI(a)
+	}
+
+	// Nested menus are created with their module, even if it isn't enabled
+	paths := make([]string, 0, len(describedAPIs))
+	for path := range describedAPIs {
+		if root, _, _ := strings.Cut(path, "/"); s.FindCallbackAPI(root) != nil {
+			paths = append(paths, path)
+		}
+	}
+
+	slices.Sort(paths)
+
+	for _, path := range paths {
+		addAPI(describedAPIs[path])
+	}
+
+	cmds := make([]string, 0, len(s.CommandAPI.Actions))
+	for cmd := range s.CommandAPI.Actions {
+		cmds = append(cmds, cmd)
+	}
+
+	slices.Sort(cmds)
+
+	for _, cmd := range cmds {
+		if meta := s.CommandAPI.Meta[cmd]; meta != nil && meta.Description != "" {
+			name := strings.ReplaceAll(strings.TrimPrefix(cmd, "/"), " ", "_")
+			add(name, meta.Description, false, false, meta.Role)
+		}
+	}
+
+	return
+}
+
+// PublishCommands sets the bot's "/" menus from BotCommands. As they're built from the
+// registry, they always match the modules enabled.
+//
+// The English menus are the default, and each other language with a catalogue gets its
+// own, shown to users whose Telegram app is set to it.
+func (s *Server) PublishCommands() {
+	private, group, admin := s.BotCommands()
+
+	scopes := []struct {
+		scope botapi.BotCommandScope
+		cmds  []botapi.BotCommand
+	}{
+		{botapi.NewBotCommandScopeAllPrivateChats(), private},
+		{botapi.NewBotCommandScopeAllGroupChats(), group},
+		{botapi.NewBotCommandScopeAllChatAdministrators(), admin},
+	}
+
+	for _, sc := range scopes {
+		cmds := sc.cmds
+		if len(cmds) > maxBotCommands {
+			log.Printf("Server: Only publishing the first %d of %d %s commands", maxBotCommands, len(cmds), sc.scope.Type)
+			cmds = cmds[:maxBotCommands]
+		}
+
+		for _, c := range i18n.Languages() {
+			lang := c.Lang
+			if lang == i18n.Default {
+				lang = ""
+			}
+
+			config := botapi.NewSetMyCommandsWithScopeAndLanguage(sc.scope, lang, translateCommands(c.Lang, cmds)...)
+
+			if _, err := s.Bot.Request(config); err != nil {
+				log.Printf("Server: Error publishing %s commands for %q: %q", sc.scope.Type, c.Lang, err.Error())
+			}
+		}
+	}
+}
+
+func translateCommands(lang string, cmds []botapi.BotCommand) []botapi.BotCommand {
+	res := make([]botapi.BotCommand, len(cmds))
+
+	for i, cmd := range cmds {
+		res[i] = botapi.BotCommand{Command: cmd.Command, Description: i18n.T(lang, cmd.Description)}
+	}
+
+	return res
+}
@@ -0,0 +1,67 @@
+package api_test
+
+import (
+	"testing"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+)
+
+func commandNames(cmds []botapi.BotCommand) (names []string) {
+	for _, cmd := range cmds {
+		names = append(names, cmd.Command)
+	}
+
+	return
+}
+
+func TestBotCommands(t *testing.T) {
+	s := &api.Server{
+		CallbackAPI: &api.CallbackAPI{Actions: map[string]api.CallbackAction{}},
+		CommandAPI:  &api.CommandAPI{Actions: map[string]api.CommandAction{}, Meta: map[string]*api.CommandMeta{}},
+	}
+
+	s.RegisterCallbackAPI(api.NewCallbackAPI("🎲 Play", "play", &api.CallbackConfig{PublicOnly: true}))
+	s.RegisterCallbackAPI(api.NewCallbackAPI("👤 Me", "me", &api.CallbackConfig{PrivateOnly: true}))
+
+	api.NewCallbackAPI("🔐 Manage", "play/admin", &api.CallbackConfig{
+		Description: "Manage play",
+		RequireRole: api.RoleAdmin,
+	})
+
+	noop := func(*api.Context, *botapi.Message, ...string) {}
+	s.RegisterCommandAction("/fact", noop, api.Describe("A fact"))
+	s.RegisterCommandAction("/hidden", noop)
+
+	private, group, admin := s.BotCommands()
+
+	expected := map[string][]string{
+		"private": {"me", "fact"},
+		"group":   {"play", "fact"},
+		"admin":   {"play", "play_admin", "fact"},
+	}
+
+	got := map[string][]string{
+		"private": commandNames(private),
+		"group":   commandNames(group),
+		"admin":   commandNames(admin),
+	}
+
+	for scope, names := range expected {
+		if len(got[scope]) != len(names) {
+			t.Errorf("BotCommands() - Expected %s commands %v; Got %v", scope, names, got[scope])
+			continue
+		}
+
+		for i := range names {
+			if got[scope][i] != names[i] {
+				t.Errorf("BotCommands() - Expected %s commands %v; Got %v", scope, names, got[scope])
+				break
+			}
+		}
+	}
+
+	if admin[1].Description != "Manage play" || group[0].Description != "🎲 Play" {
+		t.Errorf("BotCommands() - Expected descriptions from config or title; Got %+v, %+v", admin[1], group[0])
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/render"
 	"github.com/willmroliver/plathbot/src/util"
 )
 
@@ -16,6 +17,26 @@ const (
 	BuiltInDelete = "_DEL"
 )
 
+const (
+	helpRootText = `
+You can access most sub-menus using just commands.
+			<b>/stats games week</b>
+
+To see available sub-commands, use:
+			<b>/cmd help</b>, or 
+			<b>/cmd ?</b>
+		`
+
+	helpPartialText = `
+Partial matches are supported!
+
+So, if the command text is 
+				"🚀 Space stuff"
+You could use:
+				%[1]sspace				%[1]s🚀
+		`
+)
+
 var builtin = map[string]func(*Context, *botapi.CallbackQuery){
 	BuiltInDelete: func(ctx *Context, q *botapi.CallbackQuery) {
 		u := botapi.NewDeleteMessage(ctx.Chat.ID, q.Message.MessageID)
@@ -47,6 +68,8 @@ func (exts *CallbackExtensions) Options() []map[string]string {
 }
 
 type CallbackConfig struct {
+	// Description is shown in Telegram's command menu. Nested APIs are only published if set.
+	Description    string
 	Actions        map[string]CallbackAction
 	DynamicActions func(*Context, *botapi.CallbackQuery, *CallbackCmd) map[string]CallbackAction
 	DynamicOptions func(*Context, *botapi.CallbackQuery, *CallbackCmd) []map[string]string
@@ -56,11 +79,13 @@ type CallbackConfig struct {
 	PrivateOptions []map[string]string
 	PrivateOnly    bool
 	Extensions     CallbackExtensions
+	RequireRole    Role
 }
 
 type CallbackAPI struct {
 	Title          string
 	Path           string
+	Description    string
 	Actions        map[string]CallbackAction
 	DynamicActions func(*Context, *botapi.CallbackQuery, *CallbackCmd) map[string]CallbackAction
 	DynamicOptions func(*Context, *botapi.CallbackQuery, *CallbackCmd) []map[string]string
@@ -70,6 +95,7 @@ type CallbackAPI struct {
 	PrivateOptions []map[string]string
 	PrivateOnly    bool
 	Extensions     CallbackExtensions
+	RequireRole    Role
 }
 
 func NewCallbackAPI(title, path string, config *CallbackConfig) (api *CallbackAPI) {
@@ -80,6 +106,7 @@ func NewCallbackAPI(title, path string, config *CallbackConfig) (api *CallbackAP
 	api = &CallbackAPI{
 		Title:          title,
 		Path:           path,
+		Description:    config.Description,
 		Actions:        config.Actions,
 		DynamicActions: config.DynamicActions,
 		DynamicOptions: config.DynamicOptions,
@@ -89,17 +116,11 @@ func NewCallbackAPI(title, path string, config *CallbackConfig) (api *CallbackAP
 		PrivateOptions: config.PrivateOptions,
 		PrivateOnly:    config.PrivateOnly,
 		Extensions:     config.Extensions,
+		RequireRole:    config.RequireRole,
 	}
 
 	for _, ext := range api.Extensions {
-		api.Actions[ext.cmd] = ext.action
-
-		if !api.PublicOnly {
-			api.PrivateOptions = append(api.PrivateOptions, map[string]string{ext.title: ext.cmd})
-		}
-		if !api.PrivateOnly {
-			api.PublicOptions = append(api.PublicOptions, map[string]string{ext.title: ext.cmd})
-		}
+		api.addExtension(ext)
 	}
 
 	if config.DynamicOptions == nil {
@@ -107,10 +128,47 @@ func NewCallbackAPI(title, path string, config *CallbackConfig) (api *CallbackAP
 		api.PrivateOptions = api.resolveOpts(api.PrivateOptions)
 	}
 
+	if api.Description != "" {
+		describedAPIs[api.Path] = api
+	}
+
 	return
 }
 
+// Extend adds an option to the menu after it's created, as module integrations do.
+func (api *CallbackAPI) Extend(title, cmd string, action CallbackAction) {
+	ext := &CallbackExtension{title, cmd, action}
+	api.Extensions = append(api.Extensions, ext)
+
+	if api.DynamicOptions != nil {
+		api.addExtension(ext)
+		return
+	}
+
+	public, private := len(api.PublicOptions), len(api.PrivateOptions)
+	api.addExtension(ext)
+
+	api.PublicOptions = append(api.PublicOptions[:public], api.resolveOpts(api.PublicOptions[public:])...)
+	api.PrivateOptions = append(api.PrivateOptions[:private], api.resolveOpts(api.PrivateOptions[private:])...)
+}
+
+func (api *CallbackAPI) addExtension(ext *CallbackExtension) {
+	api.Actions[ext.cmd] = ext.action
+
+	if !api.PublicOnly {
+		api.PrivateOptions = append(api.PrivateOptions, map[string]string{ext.title: ext.cmd})
+	}
+	if !api.PrivateOnly {
+		api.PublicOptions = append(api.PublicOptions, map[string]string{ext.title: ext.cmd})
+	}
+}
+
 func (api *CallbackAPI) Select(c *Context, q *botapi.CallbackQuery, cc *CallbackCmd) {
+	if !c.Enabled(api.Path) {
+		log.Printf("%s is disabled in chat %d\n", api.Title, c.Chat.ID)
+		return
+	}
+
 	if api.PrivateOnly && c.Chat.Type != "private" {
 		api.privateRedirect(c, q)
 		return
@@ -123,6 +181,11 @@ func (api *CallbackAPI) Select(c *Context, q *botapi.CallbackQuery, cc *Callback
 		}
 	}
 
+	if !c.HasRole(api.RequireRole) {
+		log.Printf("User %d lacks role %q for %s\n", c.User.ID, api.RequireRole, api.Title)
+		return
+	}
+
 	if api.DynamicActions != nil {
 		maps.Copy(api.Actions, api.DynamicActions(c, q, cc))
 	}
@@ -136,6 +199,7 @@ func (api *CallbackAPI) Select(c *Context, q *botapi.CallbackQuery, cc *Callback
 
 	if action, ok := api.Actions[cmd]; ok {
 		log.Printf("Direct match in %s: %s\n", api.Title, cmd)
+		c.callbackPath = api.join(cmd)
 		action(c, q, cc.Next())
 		return
 	}
@@ -146,6 +210,7 @@ func (api *CallbackAPI) Select(c *Context, q *botapi.CallbackQuery, cc *Callback
 		for key, action := range api.Actions {
 			if strings.Contains(strings.ToLower(key), test) {
 				log.Printf("Partial match in %s: %s -> %s\n", api.Title, cmd, key)
+				c.callbackPath = api.join(key)
 				action(c, q, cc.Next())
 				return
 			}
@@ -166,9 +231,14 @@ func (api *CallbackAPI) Select(c *Context, q *botapi.CallbackQuery, cc *Callback
 }
 
 func (api *CallbackAPI) Expose(c *Context, q *botapi.CallbackQuery, cc *CallbackCmd) {
+	if !c.Enabled(api.Path) {
+		return
+	}
+
+	c.callbackPath = api.join("")
 	private := c.Chat.Type == "private"
 
-	if !private && !util.TryLockFor(fmt.Sprintf("%d %s", c.Chat.ID, api.Title), api.PublicCooldown) {
+	if !private && !util.TryLockFor(fmt.Sprintf("%d %s", c.Chat.ID, api.Title), c.Cooldown(api.PublicCooldown)) {
 		return
 	}
 
@@ -188,13 +258,13 @@ func (api *CallbackAPI) Expose(c *Context, q *botapi.CallbackQuery, cc *Callback
 	var err error
 
 	if q == nil {
-		msg := botapi.NewMessage(c.Chat.ID, api.Title)
-		msg.ReplyMarkup = *InlineKeyboard(*opts, fmt.Sprintf("user=%d", c.User.ID))
+		msg := botapi.NewMessage(c.Chat.ID, c.T(api.Title))
+		msg.ReplyMarkup = *c.InlineKeyboard(*opts, fmt.Sprintf("user=%d", c.User.ID))
 
-		_, err = c.Bot.Send(msg)
+		_, err = SendConfig(c.Bot, msg)
 	} else {
-		msg := botapi.NewEditMessageText(c.Chat.ID, q.Message.MessageID, api.Title)
-		msg.ReplyMarkup = InlineKeyboard(*opts, fmt.Sprintf("user=%d", c.User.ID))
+		msg := botapi.NewEditMessageText(c.Chat.ID, q.Message.MessageID, c.T(api.Title))
+		msg.ReplyMarkup = c.InlineKeyboard(*opts, fmt.Sprintf("user=%d", c.User.ID))
 
 		err = SendUpdate(c.Bot, &msg)
 	}
@@ -209,77 +279,81 @@ func (api *CallbackAPI) SendHelp(c *Context, q *botapi.CallbackQuery, cc *Callba
 	root := path == "/"
 
 	text := &strings.Builder{}
-	text.WriteString("*" + api.Title + "* - Commands:\n\n")
+	text.WriteString(string(render.HTML.Sprintf(c.T("<b>%s</b> - Commands:"), c.T(api.Title))) + "\n\n")
 
 	if !root {
 		path += " "
 	}
 
 	for key := range api.Actions {
-		if strings.HasPrefix(key, "_") {
+		if strings.HasPrefix(key, "_") || root && !c.Enabled(key) {
 			continue
 		}
 
-		text.WriteString(fmt.Sprintf("\t\t\t\t%s%s\n", path, key))
+		text.WriteString(string(render.HTML.Sprintf("\t\t\t\t%s%s\n", path, key)))
 	}
 
 	if root {
-		text.WriteString(`
-You can access most sub-menus using just commands.
-			*/stats games week*
+		if cmds := c.Server.CommandAPI.Help(c); cmds != "" {
+			text.WriteString("\n" + c.T("<b>Other commands:</b>") + "\n\n" + cmds)
+		}
 
-To see available sub-commands, use:
-			*/cmd help*, or 
-			*/cmd ?*
-		`)
+		text.WriteString(c.T(helpRootText))
 	} else if api.DynamicOptions != nil {
-		text.WriteString(fmt.Sprintf(`
-Partial matches are supported!
-
-So, if the command text is 
-				"🚀 Space stuff"
-You could use:
-				%sspace				%s🚀
-		`, path, path))
+		text.WriteString(string(render.HTML.Sprintf(c.T(helpPartialText), path)))
 	}
 
 	if q == nil {
-		msg := &botapi.MessageConfig{
-			BaseChat: botapi.BaseChat{ChatID: c.Chat.ID},
-		}
-		msg.ParseMode = botapi.ModeMarkdown
-		msg.Text = text.String()
+		msg := botapi.NewMessage(c.Chat.ID, text.String())
+		msg.ParseMode = botapi.ModeHTML
+		SendLong(c.Bot, msg)
+		return
+	}
+
+	// An edit can't be split, so whatever doesn't fit follows as new messages
+	parts := render.HTML.Split(text.String(), render.MaxLength)
+
+	msg := botapi.NewEditMessageText(c.Chat.ID, q.Message.MessageID, parts[0])
+	msg.ParseMode = botapi.ModeHTML
+	SendUpdate(c.Bot, &msg)
+
+	for _, part := range parts[1:] {
+		msg := botapi.NewMessage(c.Chat.ID, part)
+		msg.ParseMode = botapi.ModeHTML
 		SendConfig(c.Bot, msg)
-	} else {
-		msg := botapi.NewEditMessageText(c.Chat.ID, q.Message.MessageID, api.Title)
-		msg.ParseMode = botapi.ModeMarkdown
-		msg.Text = text.String()
-		SendUpdate(c.Bot, &msg)
 	}
 }
 
+// join returns the path to cmd within the menu, or the menu's own path if cmd is empty.
+// The hub's is "/".
+func (api *CallbackAPI) join(cmd string) string {
+	if path := strings.Trim(api.Path+"/"+cmd, "/"); path != "" {
+		return path
+	}
+
+	return "/"
+}
+
 func (api *CallbackAPI) privateRedirect(c *Context, q *botapi.CallbackQuery) {
 	dest := api.Path
 	if i := strings.Index(api.Path, "/"); i != -1 {
 		dest = dest[:i]
 	}
 
-	text := "🤫 Shhh.. You're in public"
+	text := c.T("🤫 Shhh.. You're in public")
 	mu := *InlineKeyboard([]map[string]string{{
-		api.Title: KeyboardLink(ToPrivateString(c.Bot, dest)),
+		c.T(api.Title): KeyboardLink(ToPrivateString(c.Bot, dest)),
 	}})
 
 	if q == nil {
 		msg := &botapi.MessageConfig{
 			BaseChat: botapi.BaseChat{ChatID: c.Chat.ID},
 		}
-		msg.ParseMode = "Markdown"
 		msg.Text = text
 		msg.ReplyMarkup = mu
 		SendConfig(c.Bot, msg)
 	} else {
-		msg := botapi.NewEditMessageTextAndMarkup(c.Chat.ID, q.Message.MessageID, api.Title, mu)
-		msg.ParseMode = "Markdown"
+		msg := botapi.NewEditMessageTextAndMarkup(c.Chat.ID, q.Message.MessageID, text, mu)
 		msg.Text = text
 		SendUpdate(c.Bot, &msg)
 	}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	callbackSigSep = "#"
+	callbackSigLen = 6
+)
+
+var (
+	callbackSecret     []byte
+	callbackSecretOnce sync.Once
+)
+
+// callbackKey returns the key callback data is signed with, from CALLBACK_SECRET. Without one,
+// a random key is used, so buttons sent before a restart stop working.
+func callbackKey() []byte {
+	callbackSecretOnce.Do(func() {
+		if secret := os.Getenv("CALLBACK_SECRET"); secret != "" {
+			callbackSecret = []byte(secret)
+			return
+		}
+
+		log.Println("Callback: CALLBACK_SECRET is not set, using a random key. Keyboards will expire on restart.")
+
+		callbackSecret = make([]byte, 32)
+		if _, err := rand.Read(callbackSecret); err != nil {
+			log.Panicf("Callback: error generating key: %q", err.Error())
+		}
+	})
+
+	return callbackSecret
+}
+
+func callbackSig(data string) string {
+	mac := hmac.New(sha256.New, callbackKey())
+	mac.Write([]byte(data))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:callbackSigLen])
+}
+
+// SignCallbackData appends a truncated HMAC of data, so that presses of buttons we didn't
+// create can be told apart by VerifyCallbackData.
+func SignCallbackData(data string) string {
+	return data + callbackSigSep + callbackSig(data)
+}
+
+// VerifyCallbackData checks and strips the signature added by SignCallbackData, returning
+// false if it is missing or doesn't match.
+func VerifyCallbackData(signed string) (string, bool) {
+	i := strings.LastIndex(signed, callbackSigSep)
+	if i == -1 {
+		return "", false
+	}
+
+	data, sig := signed[:i], signed[i+1:]
+
+	if !hmac.Equal([]byte(sig), []byte(callbackSig(data))) {
+		return "", false
+	}
+
+	return data, true
+}
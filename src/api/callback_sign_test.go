@@ -0,0 +1,36 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/willmroliver/plathbot/src/api"
+)
+
+func TestVerifyCallbackData(t *testing.T) {
+	data := "user=123456789|reddit/admin/remove/abc123"
+	signed := api.SignCallbackData(data)
+
+	if res, ok := api.VerifyCallbackData(signed); !ok || res != data {
+		t.Errorf("VerifyCallbackData() - Expected ok, %q; Got %v, %q", data, ok, res)
+	}
+
+	tampered := []string{
+		data,
+		"user=987654321|reddit/admin/remove/abc123" + signed[len(data):],
+		signed[:len(signed)-1],
+		signed + "x",
+		"",
+	}
+
+	for _, s := range tampered {
+		if res, ok := api.VerifyCallbackData(s); ok {
+			t.Errorf("VerifyCallbackData() - Expected !ok for %q; Got %q", s, res)
+		}
+	}
+
+	b := api.KeyboardButton("Remove", "reddit/admin/remove/abc123", "user=123456789")
+
+	if res, ok := api.VerifyCallbackData(*b.CallbackData); !ok || res != data {
+		t.Errorf("KeyboardButton() - Expected signed %q; Got %v, %q", data, ok, res)
+	}
+}
@@ -0,0 +1,139 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willmroliver/plathbot/src/ds"
+	"github.com/willmroliver/plathbot/src/util"
+)
+
+const (
+	// CallbackDataLimit is the most bytes Telegram accepts as a button's callback_data.
+	CallbackDataLimit = 64
+
+	callbackTokenPrefix     = "~"
+	callbackStoreSize       = 10000
+	defaultCallbackStoreTTL = time.Hour * 24
+)
+
+var (
+	callbackStore     *tokenStore
+	callbackStoreOnce sync.Once
+)
+
+// tokenStore maps short tokens to callback payloads too long to fit in a button.
+//
+// Tokens are a hash of the payload, so the same button rendered twice shares a token,
+// and each save pushes back the expiry.
+type tokenStore struct {
+	cache *ds.LRUCache[string, *storedPayload]
+	ttl   time.Duration
+}
+
+type storedPayload struct {
+	data    string
+	expires time.Time
+}
+
+// callbackTokens returns the store if CALLBACK_STORE is enabled, otherwise nil.
+func callbackTokens() *tokenStore {
+	callbackStoreOnce.Do(func() {
+		callbackStore = loadCallbackStore(callbackStoreSize)
+	})
+
+	return callbackStore
+}
+
+// loadCallbackStore makes a store holding up to size payloads, configured from the
+// environment, or returns nil if CALLBACK_STORE isn't enabled.
+func loadCallbackStore(size int) *tokenStore {
+	switch strings.ToLower(os.Getenv("CALLBACK_STORE")) {
+	case "", "0", "false", "off":
+		return nil
+	}
+
+	ttl := defaultCallbackStoreTTL
+
+	if v := os.Getenv("CALLBACK_STORE_TTL"); v != "" {
+		if dur, err := time.ParseDuration(v); err == nil {
+			ttl = dur
+		} else {
+			log.Printf("Callback store: Invalid CALLBACK_STORE_TTL %q, using %s", v, ttl)
+		}
+	}
+
+	return newTokenStore(size, ttl)
+}
+
+func newTokenStore(size int, ttl time.Duration) *tokenStore {
+	return &tokenStore{
+		cache: ds.NewLRUCache[string, *storedPayload](size),
+		ttl:   ttl,
+	}
+}
+
+func (s *tokenStore) Save(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	token := callbackTokenPrefix + base64.RawURLEncoding.EncodeToString(sum[:9])
+
+	s.cache.Lock()
+	defer s.cache.Unlock()
+
+	s.cache.Save(token, &storedPayload{data, time.Now().Add(s.ttl)})
+	return token
+}
+
+func (s *tokenStore) Load(token string) (data string, ok bool) {
+	s.cache.Lock()
+	defer s.cache.Unlock()
+
+	p, ok := s.cache.Load(token)
+	if !ok {
+		return
+	}
+
+	if time.Now().After(p.expires) {
+		s.cache.Delete(token)
+		return "", false
+	}
+
+	return p.data, true
+}
+
+// CompactCallbackData returns data as is if it fits in a button, otherwise a token for it
+// when the callback store is enabled.
+func CompactCallbackData(data string) string {
+	if len(data) <= CallbackDataLimit {
+		return data
+	}
+
+	if s := callbackTokens(); s != nil {
+		return s.Save(data)
+	}
+
+	if util.TryLockFor("callback data limit", time.Minute) {
+		log.Printf("Callback data exceeds %d bytes and will be rejected, set CALLBACK_STORE to enable tokens: %q", CallbackDataLimit, data)
+	}
+
+	return data
+}
+
+// ExpandCallbackData reverses CompactCallbackData. It returns false only for a token
+// which has expired, or which was issued before a restart.
+func ExpandCallbackData(data string) (string, bool) {
+	if !strings.HasPrefix(data, callbackTokenPrefix) {
+		return data, true
+	}
+
+	if s := callbackTokens(); s != nil {
+		return s.Load(data)
+	}
+
+	return "", false
+}
@@ -0,0 +1,56 @@
+package api_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/willmroliver/plathbot/src/api"
+)
+
+func TestCallbackStore(t *testing.T) {
+	t.Setenv("CALLBACK_STORE", "1")
+	api.UseCallbackStore(t, 2)
+
+	short := "stats/xp"
+	long := "stats/💕 Engage XP/week/" + strings.Repeat("x", api.CallbackDataLimit)
+
+	if data := api.CompactCallbackData(short); data != short {
+		t.Errorf("CompactCallbackData() - Expected %q unchanged; Got %q", short, data)
+	}
+
+	b := api.KeyboardButton("XP", long, "user=123456789")
+	token := *b.CallbackData
+
+	if len(token) > api.CallbackDataLimit {
+		t.Errorf("KeyboardButton() - Expected data within %d bytes; Got %d", api.CallbackDataLimit, len(token))
+	}
+
+	data, ok := api.ExpandCallbackData(token)
+	if ok {
+		data, ok = api.VerifyCallbackData(data)
+	}
+
+	if !ok || data != "user=123456789|"+long {
+		t.Errorf("ExpandCallbackData() - Expected ok, original payload; Got %v, %q", ok, data)
+	}
+
+	if again := *api.KeyboardButton("XP", long, "user=123456789").CallbackData; again != token {
+		t.Errorf("KeyboardButton() - Expected same token for same payload; Got %q, %q", token, again)
+	}
+
+	if data, ok := api.ExpandCallbackData("~unknown"); ok {
+		t.Errorf("ExpandCallbackData() - Expected !ok for unknown token; Got %q", data)
+	}
+
+	if data, ok := api.ExpandCallbackData(short); !ok || data != short {
+		t.Errorf("ExpandCallbackData() - Expected %q unchanged; Got %v, %q", short, ok, data)
+	}
+
+	// Holding two payloads, the least recently used is dropped for a third
+	api.KeyboardButton("XP", long+"2", "")
+	api.KeyboardButton("XP", long+"3", "")
+
+	if data, ok := api.ExpandCallbackData(token); ok {
+		t.Errorf("ExpandCallbackData() - Expected !ok once evicted; Got %q", data)
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"strings"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func init() {
+	RegisterCommandAction("/cancel", cancel,
+		Describe("Cancel what you're in the middle of"),
+		Params(ArgWord("name").Opt()),
+	)
+}
+
+// pendingItem is something /cancel can abort: a conversation or a message hook.
+type pendingItem struct {
+	name   string
+	cancel func() bool
+}
+
+// pending lists what the user is waiting on in the chat. Chat hooks in groups may belong to
+// anyone, so are only listed for moderators.
+func (ctx *Context) pending() (items []pendingItem) {
+	s := ctx.Server
+
+	if cs := s.Conversation(ctx.Chat.ID, ctx.User.ID); cs != nil {
+		name := cs.conv.Name
+		if name == "" {
+			name = "conversation"
+		}
+
+		items = append(items, pendingItem{name, func() bool {
+			return s.CancelConversation(ctx.Chat.ID, ctx.User.ID)
+		}})
+	}
+
+	chatHooks := ctx.Chat.Type == "private" || ctx.HasRole(RoleModerator)
+
+	for _, hook := range s.PendingHooks(ctx.Chat.ID, ctx.User.ID) {
+		if scope, _ := hook.Scope(); scope == HookChat && !chatHooks {
+			continue
+		}
+
+		items = append(items, pendingItem{hook.Namespace, func() bool {
+			return s.CancelHook(hook)
+		}})
+	}
+
+	return
+}
+
+// cancel aborts the named pending item, or everything with "all". Without a name, a lone
+// item is aborted, otherwise the user picks from a list.
+func cancel(c *Context, m *botapi.Message, args ...string) {
+	items, name := c.pending(), c.Args.String("name")
+
+	if len(items) == 0 {
+		SendBasic(c.Bot, c.Chat.ID, c.T("Nothing to cancel."))
+		return
+	}
+
+	if name == "" && len(items) > 1 {
+		rows := make([][]botapi.InlineKeyboardButton, 0, len(items)+1)
+
+		for _, item := range items {
+			rows = append(rows, []botapi.InlineKeyboardButton{
+				KeyboardButton("✖️ "+item.name, "cmd|/cancel "+item.name),
+			})
+		}
+
+		rows = append(rows, []botapi.InlineKeyboardButton{KeyboardButton(c.T("✖️ All"), "cmd|/cancel all")})
+
+		msg := botapi.NewMessage(c.Chat.ID, c.T("You have a few things pending. Which should I cancel?"))
+		msg.ReplyMarkup = botapi.NewInlineKeyboardMarkup(rows...)
+		SendConfig(c.Bot, msg)
+		return
+	}
+
+	cancelled := []string{}
+
+	for _, item := range items {
+		if (name == "" || name == "all" || name == item.name) && item.cancel() {
+			cancelled = append(cancelled, item.name)
+		}
+	}
+
+	if len(cancelled) == 0 {
+		SendBasic(c.Bot, c.Chat.ID, c.T("Nothing called %q to cancel.", name))
+		return
+	}
+
+	SendBasic(c.Bot, c.Chat.ID, c.T("👍 Cancelled %s.", strings.Join(cancelled, ", ")))
+}
@@ -1,26 +1,150 @@
 package api
 
 import (
+	"fmt"
 	"log"
+	"slices"
+	"strings"
+	"time"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/i18n"
+	"github.com/willmroliver/plathbot/src/render"
 )
 
 type CommandAction func(*Context, *botapi.Message, ...string)
 
+// CommandMeta holds the options a command was registered with.
+type CommandMeta struct {
+	Role        Role
+	Description string
+	Params      []Param
+}
+
+// CommandOption configures a command on registration.
+type CommandOption func(*CommandMeta)
+
+// Describe sets the description shown for the command in Telegram's command menu
+// and in /help. Commands without one aren't published.
+func Describe(description string) CommandOption {
+	return func(m *CommandMeta) {
+		m.Description = description
+	}
+}
+
+// RequireRole restricts a command to users holding role in the chat it's used in.
+func RequireRole(role Role) CommandOption {
+	return func(m *CommandMeta) {
+		m.Role = role
+	}
+}
+
+// Params declares the arguments a command takes. They are parsed before the action is
+// called, which reads them from ctx.Args; anything invalid is answered with the usage.
+func Params(params ...Param) CommandOption {
+	return func(m *CommandMeta) {
+		m.Params = params
+	}
+}
+
+// CommandAPI maps commands to actions. A command may be a subcommand, registered with
+// its parent as "/pfp add", and the longest match is used.
 type CommandAPI struct {
 	Actions map[string]CommandAction
+	Meta    map[string]*CommandMeta
 }
 
 func (api *CommandAPI) Select(c *Context, msg *botapi.Message, args ...string) {
-	action, ok := api.Actions[args[0]]
-	if !ok && len(args[0]) > 2 && args[0][1] == 'p' {
-		action, ok = api.Actions["/"+args[0][2:]]
+	args = strings.Fields(strings.Join(args, " "))
+	if len(args) == 0 {
+		return
+	}
+
+	cmd, action, n := api.match(args)
+	if action == nil {
+		log.Printf("Command: Select: Not found: %q\n", args[0])
+
+		if c.Chat.Type == "private" {
+			SendBasic(c.Bot, c.Chat.ID, c.T("🤔 Unknown command, try /help"))
+		}
+
+		return
 	}
 
-	if ok {
-		action(c, msg, args[1:]...)
+	meta := api.Meta[cmd]
+	if meta == nil {
+		meta = &CommandMeta{}
+	}
+
+	if !c.Enabled(cmd) {
+		log.Printf("Command: %s is disabled in chat %d\n", cmd, c.Chat.ID)
+		return
+	}
+
+	if !c.HasRole(meta.Role) {
+		log.Printf("Command: User %d lacks role %q for %s\n", c.User.ID, meta.Role, cmd)
+		return
+	}
+
+	args = args[n:]
+
+	if meta.Params != nil {
+		parsed, err := c.ParseArgs(meta.Params, args)
+		if err != nil {
+			SendBasic(c.Bot, c.Chat.ID, c.T("⚠️ %s\nUsage: %s", i18n.ErrorText(c.Lang(), err), Usage(cmd, meta.Params)))
+			return
+		}
+
+		c.Args = parsed
+	}
+
+	start := time.Now()
+	defer commandDuration.Since(start, cmd)
+
+	commandsTotal.Inc(cmd)
+	action(c, msg, args...)
+}
+
+// match finds the longest command prefixing args, accepting "/pcmd" for "/cmd".
+// It returns the command, its action and the number of args it spans.
+func (api *CommandAPI) match(args []string) (cmd string, action CommandAction, n int) {
+	for n = min(len(args), 2); n > 0; n-- {
+		words := slices.Clone(args[:n])
+
+		if cmd = strings.Join(words, " "); api.Actions[cmd] != nil {
+			return cmd, api.Actions[cmd], n
+		}
+
+		if len(words[0]) > 2 && words[0][1] == 'p' {
+			words[0] = "/" + words[0][2:]
+
+			if cmd = strings.Join(words, " "); api.Actions[cmd] != nil {
+				return cmd, api.Actions[cmd], n
+			}
+		}
+	}
+
+	return "", nil, 0
+}
+
+// Help lists the described commands available to the user, with their usage.
+func (api *CommandAPI) Help(c *Context) string {
+	cmds := make([]string, 0, len(api.Meta))
+
+	for cmd, meta := range api.Meta {
+		if meta.Description != "" && c.HasRole(meta.Role) && c.Enabled(cmd) {
+			cmds = append(cmds, cmd)
+		}
+	}
+
+	slices.Sort(cmds)
+
+	text := &strings.Builder{}
+
+	for _, cmd := range cmds {
+		meta := api.Meta[cmd]
+		text.WriteString(fmt.Sprintf("\t\t\t\t%s - %s\n", render.HTML.Code(Usage(cmd, meta.Params)), render.HTML.Escape(c.T(meta.Description))))
 	}
 
-	log.Printf("Command: Select: Not found: %q\n", args[0])
+	return text.String()
 }
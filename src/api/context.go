@@ -11,14 +11,25 @@ import (
 	"github.com/willmroliver/plathbot/src/service"
 )
 
+// callbackExpiredText answers presses we can't act on. Bad signatures are most often
+// buttons sent before a restart or a change of secret, so they get the same reply.
+const callbackExpiredText = "⌛ This menu has expired, please open it again"
+
 type Context struct {
-	Server   *Server
-	Bot      *botapi.BotAPI
-	Update   *botapi.Update
-	UserRepo *repo.UserRepo
-	User     *botapi.User
-	Chat     *botapi.Chat
-	Message  *botapi.Message
+	Server    *Server
+	Bot       *botapi.BotAPI
+	Update    *botapi.Update
+	UserRepo  *repo.UserRepo
+	User      *botapi.User
+	Chat      *botapi.Chat
+	Message   *botapi.Message
+	Args      *Args
+	RequestID string
+
+	lang string
+	// callbackPath is the menu path the update resolved to, for metrics. Nested menus
+	// resolve in turn, so the deepest is kept.
+	callbackPath string
 }
 
 func NewContext(server *Server, update *botapi.Update) *Context {
@@ -35,6 +46,7 @@ func (ctx *Context) HandleUpdate() {
 	ctx.HandleMessageReaction()
 	ctx.HandleCallbackQuery()
 	ctx.HandleInlineQuery()
+	ctx.HandleChatMember()
 }
 
 func (ctx *Context) HandleMessage() {
@@ -52,17 +64,25 @@ func (ctx *Context) HandleMessage() {
 	if m.Chat.Type != "private" {
 		service.
 			NewUserXPService(ctx.Server.DB).
-			UpdateXPs(ctx.User, service.XPTitleEngage, 10)
+			UpdateXPs(ctx.User, ctx.Chat.ID, service.XPTitleEngage, ctx.XP(model.XPMessage))
 	}
 
 	if !strings.HasPrefix(text, "/") {
 		return
 	}
 
-	log.Printf("Message: %q, from %d: %q\n", text, m.From.UserName, m.From.ID)
+	log.Printf("Message: %q, from %q: %d\n", text, m.From.UserName, m.From.ID)
 
 	text = strings.Replace(text, "@"+ctx.Bot.Self.UserName, "", 1)
 
+	// Published commands for nested menus use underscores, e.g. /emojis_admin
+	if word, rest, found := strings.Cut(text, " "); strings.Contains(word, "_") && !strings.HasPrefix(word, "/_") {
+		text = strings.ReplaceAll(word, "_", " ")
+		if found {
+			text += " " + rest
+		}
+	}
+
 	var cc *CallbackCmd
 
 	if strings.HasPrefix(text, "/start ") {
@@ -119,11 +139,11 @@ func (ctx *Context) HandleMessageReaction() {
 	case len(m.OldReaction) < len(m.NewReaction):
 		service.
 			NewUserXPService(ctx.Server.DB).
-			UpdateXPs(ctx.User, service.XPTitleEngage, 10)
+			UpdateXPs(ctx.User, ctx.Chat.ID, service.XPTitleEngage, ctx.XP(model.XPReaction))
 	case len(m.OldReaction) > len(m.NewReaction):
 		service.
 			NewUserXPService(ctx.Server.DB).
-			UpdateXPs(ctx.User, service.XPTitleEngage, -10)
+			UpdateXPs(ctx.User, ctx.Chat.ID, service.XPTitleEngage, -ctx.XP(model.XPReaction))
 	default:
 		break
 	}
@@ -135,6 +155,21 @@ func (ctx *Context) HandleCallbackQuery() {
 		return
 	}
 
+	data, ok := ExpandCallbackData(m.Data)
+	if !ok {
+		ctx.Logf("Context: expired callback token %q from %d\n", m.Data, m.From.ID)
+		SendLater(ctx.Bot, botapi.NewCallback(m.ID, ctx.T(callbackExpiredText)))
+		return
+	}
+
+	if data, ok = VerifyCallbackData(data); !ok {
+		ctx.Logf("Context: rejected unsigned or tampered callback data %q from %d\n", m.Data, m.From.ID)
+		SendLater(ctx.Bot, botapi.NewCallback(m.ID, ctx.T(callbackExpiredText)))
+		return
+	}
+
+	m.Data = data
+
 	if strings.HasPrefix(m.Data, "cmd|") {
 		ctx.Update.Message = &botapi.Message{
 			From: m.From,
@@ -155,7 +190,7 @@ func (ctx *Context) HandleCallbackQuery() {
 
 func (ctx *Context) HandleInlineQuery() {
 	if m := ctx.Update.InlineQuery; m != nil {
-		ctx.Server.InlineAPI.Select(ctx, m, m.Query)
+		ctx.Server.InlineAPI.Select(ctx, m)
 	}
 }
 
@@ -163,11 +198,31 @@ func (ctx *Context) GetUser() *model.User {
 	return ctx.UserRepo.Get(ctx.User)
 }
 
-func (ctx *Context) IsAdmin() bool {
-	if ctx.Chat == nil {
-		return false
+// ScoresChatID is the chat whose scores to show: the current group, or every chat combined,
+// model.GlobalChatID, in private or when global is asked for.
+func (ctx *Context) ScoresChatID(global bool) int64 {
+	if global || ctx.Chat == nil || ctx.Chat.Type == "private" {
+		return model.GlobalChatID
+	}
+
+	return ctx.Chat.ID
+}
+
+// ScopeToggle returns a keyboard row switching a leaderboard between the group's scores,
+// at chatPath, and every chat's, at globalPath. It's nil in private, where only the latter shows.
+func (ctx *Context) ScopeToggle(global bool, chatPath, globalPath string) map[string]string {
+	if ctx.Chat == nil || ctx.Chat.Type == "private" {
+		return nil
 	}
 
-	return ctx.Chat.Type == "private" ||
-		ctx.UserRepo.Get(ctx.User).IsAdmin(ctx.Bot, ctx.Chat.ID)
+	if global {
+		return map[string]string{"💬 This chat": chatPath}
+	}
+
+	return map[string]string{"🌍 All chats": globalPath}
+}
+
+// IsAdmin reports whether the current user holds RoleAdmin in the current chat.
+func (ctx *Context) IsAdmin() bool {
+	return ctx.HasRole(RoleAdmin)
 }
@@ -0,0 +1,447 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/i18n"
+	"github.com/willmroliver/plathbot/src/render"
+)
+
+const (
+	defaultStepTimeout = time.Minute * 5
+
+	// ConversationEnd may be returned from a Step's Next to finish the conversation early.
+	ConversationEnd = "$end"
+)
+
+var (
+	ErrConversationCancelled = errors.New("conversation cancelled")
+	ErrConversationTimeout   = errors.New("conversation timed out")
+)
+
+// StepParser turns a reply into the value stored for a step. Any error is sent back to the
+// user and the step waits for another reply. Parsers may also Set values for later steps.
+type StepParser func(c *Context, cs *ConversationState, m *botapi.Message) (any, error)
+
+// Step is a single question in a Conversation.
+type Step struct {
+	// Name is the key the step's answer is stored under.
+	Name string
+
+	// Prompt is sent when the step is reached, unless Ask is set. It's plain text.
+	Prompt string
+	Ask    func(c *Context, cs *ConversationState)
+
+	// Parse reads the reply, defaulting to its text. Validate may reject the parsed value.
+	Parse    StepParser
+	Validate func(cs *ConversationState, value any) error
+
+	// Timeout is how long to wait for an answer, defaulting to the Conversation's.
+	Timeout time.Duration
+
+	// Next picks the step to go to by name. Returning "" moves on to the following step
+	// and ConversationEnd finishes.
+	Next func(cs *ConversationState) string
+}
+
+// Conversation declares a multi-step exchange with a user as an ordered list of steps.
+// Each step's answer is parsed, validated and stored before moving on, and once the last
+// is answered OnDone is called with everything collected.
+//
+// A user has at most one conversation per chat, and may leave it with /cancel.
+type Conversation struct {
+	// Name is how /cancel refers to the conversation, defaulting to "conversation".
+	Name    string
+	Steps   []*Step
+	Timeout time.Duration
+
+	OnDone func(c *Context, cs *ConversationState)
+
+	// OnCancel is called with ErrConversationCancelled or ErrConversationTimeout. If nil,
+	// the user is told when the conversation times out; /cancel answers for itself.
+	OnCancel func(s *Server, cs *ConversationState, err error)
+}
+
+// ConversationState is a user's progress through a Conversation. The embedded Interaction
+// holds the current step's name and the last prompt sent.
+type ConversationState struct {
+	*Interaction[string]
+	ChatID, UserID int64
+	Data           any
+
+	// Lang is the language the conversation is held in, fixed when it starts.
+	Lang string
+
+	conv   *Conversation
+	server *Server
+	values map[string]any
+	step   int
+	timer  *time.Timer
+	mu     sync.Mutex
+}
+
+type conversationKey struct {
+	chatID, userID int64
+}
+
+// Start begins the conversation with the context's user, in the context's chat, replacing
+// any they were already having there. Data is carried along for the steps and callbacks.
+func (conv *Conversation) Start(c *Context, data any) *ConversationState {
+	return conv.StartWith(c, data, nil)
+}
+
+// StartWith is Start with some answers already given, e.g. from command arguments. Steps
+// which already have a value are skipped.
+func (conv *Conversation) StartWith(c *Context, data any, values map[string]any) *ConversationState {
+	msg := c.Message
+	if msg == nil {
+		msg = &botapi.Message{Chat: c.Chat}
+	}
+
+	cs := &ConversationState{
+		Interaction: NewInteraction(msg, ""),
+		ChatID:      c.Chat.ID,
+		UserID:      c.User.ID,
+		Data:        data,
+		Lang:        c.Lang(),
+		conv:        conv,
+		server:      c.Server,
+		values:      map[string]any{},
+		step:        -1,
+	}
+
+	for k, v := range values {
+		cs.values[k] = v
+	}
+
+	key := conversationKey{cs.ChatID, cs.UserID}
+	if prev, ok := c.Server.conversations.Swap(key, cs); ok {
+		prev.(*ConversationState).stop()
+	}
+
+	cs.advance(c, 0)
+	return cs
+}
+
+// Conversation returns the user's conversation in the chat, or nil if there isn't one.
+func (s *Server) Conversation(chatID, userID int64) *ConversationState {
+	if data, ok := s.conversations.Load(conversationKey{chatID, userID}); ok {
+		return data.(*ConversationState)
+	}
+
+	return nil
+}
+
+// CancelConversation ends the user's conversation in the chat, reporting whether there was one.
+func (s *Server) CancelConversation(chatID, userID int64) bool {
+	cs := s.Conversation(chatID, userID)
+	if cs == nil {
+		return false
+	}
+
+	cs.end(ErrConversationCancelled)
+	return true
+}
+
+// DoConversation passes a message to its sender's conversation in the chat, if any,
+// reporting whether it was consumed. Commands are left alone, so /cancel still works.
+func (s *Server) DoConversation(ctx *Context) bool {
+	m := ctx.Update.Message
+	if m == nil || m.From == nil || strings.HasPrefix(m.Text, "/") {
+		return false
+	}
+
+	cs := s.Conversation(m.Chat.ID, m.From.ID)
+	if cs == nil {
+		return false
+	}
+
+	step := cs.waiting()
+	if step == nil {
+		return false
+	}
+
+	parse := step.Parse
+	if parse == nil {
+		parse = ParseText
+	}
+
+	value, err := parse(ctx, cs, m)
+	if err != nil {
+		cs.reject(err)
+		return true
+	}
+
+	cs.Answer(ctx, value)
+	return true
+}
+
+// Answer submits a value for the current step, as if parsed from a reply. Buttons use this
+// to answer steps which don't expect a message.
+func (cs *ConversationState) Answer(c *Context, value any) error {
+	step := cs.waiting()
+	if step == nil {
+		return ErrConversationCancelled
+	}
+
+	if step.Validate != nil {
+		if err := step.Validate(cs, value); err != nil {
+			cs.reject(err)
+			return err
+		}
+	}
+
+	cs.mu.Lock()
+	if cs.current() != step {
+		cs.mu.Unlock()
+		return ErrConversationCancelled
+	}
+
+	cs.values[step.Name] = value
+	next := cs.step + 1
+	cs.mu.Unlock()
+
+	if step.Next != nil {
+		switch name := step.Next(cs); name {
+		case "":
+		case ConversationEnd:
+			next = len(cs.conv.Steps)
+		default:
+			if next = cs.indexOf(name); next == -1 {
+				log.Printf("Conversation: step %q has no next step %q, finishing\n", step.Name, name)
+				next = len(cs.conv.Steps)
+				break
+			}
+
+			// A step branched to is asked again, even if answered before
+			cs.mu.Lock()
+			delete(cs.values, name)
+			cs.mu.Unlock()
+		}
+	}
+
+	cs.advance(c, next)
+	return nil
+}
+
+// Get returns the answer stored under name, or nil.
+func (cs *ConversationState) Get(name string) any {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	return cs.values[name]
+}
+
+// String returns the answer stored under name if it's a string, else "".
+func (cs *ConversationState) String(name string) string {
+	s, _ := cs.Get(name).(string)
+	return s
+}
+
+// Set stores a value under name, e.g. for later steps to read.
+func (cs *ConversationState) Set(name string, value any) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.values[name] = value
+}
+
+// Has reports whether a value is stored under name.
+func (cs *ConversationState) Has(name string) bool {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	_, ok := cs.values[name]
+	return ok
+}
+
+// current returns the step being waited on, or nil once the conversation has ended.
+func (cs *ConversationState) current() *Step {
+	if cs.step < 0 || cs.step >= len(cs.conv.Steps) {
+		return nil
+	}
+
+	return cs.conv.Steps[cs.step]
+}
+
+func (cs *ConversationState) waiting() *Step {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	return cs.current()
+}
+
+func (cs *ConversationState) indexOf(name string) int {
+	for i, step := range cs.conv.Steps {
+		if step.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// advance moves to the i'th step, skipping any already answered, and prompts for it.
+// Past the last step the conversation is finished and OnDone is called.
+func (cs *ConversationState) advance(c *Context, i int) {
+	cs.mu.Lock()
+
+	for i < len(cs.conv.Steps) {
+		if _, ok := cs.values[cs.conv.Steps[i].Name]; !ok {
+			break
+		}
+		i++
+	}
+
+	cs.step = i
+	if cs.timer != nil {
+		cs.timer.Stop()
+	}
+
+	step := cs.current()
+	if step == nil {
+		cs.mu.Unlock()
+		cs.server.conversations.CompareAndDelete(conversationKey{cs.ChatID, cs.UserID}, cs)
+
+		if cs.conv.OnDone != nil {
+			cs.conv.OnDone(c, cs)
+		}
+
+		return
+	}
+
+	timeout := step.Timeout
+	if timeout == 0 {
+		timeout = cs.conv.Timeout
+	}
+	if timeout == 0 {
+		timeout = defaultStepTimeout
+	}
+	if timeout > time.Hour {
+		timeout = time.Hour
+	}
+
+	cs.timer = time.AfterFunc(timeout, func() {
+		if cs.waiting() == step {
+			cs.end(ErrConversationTimeout)
+		}
+	})
+	cs.mu.Unlock()
+
+	cs.Mutate(step.Name, cs.Msg)
+
+	if step.Ask != nil {
+		step.Ask(c, cs)
+	} else if step.Prompt != "" {
+		if msg, err := SendConfig(cs.server.Bot, cs.NewMessage(render.HTML.Escape(cs.T(step.Prompt)), nil)); err == nil {
+			cs.Msg = msg
+		}
+	}
+}
+
+// T translates text into the conversation's language, as in i18n.T.
+func (cs *ConversationState) T(text string, args ...any) string {
+	return i18n.T(cs.Lang, text, args...)
+}
+
+// reject tells the user why their answer wasn't accepted. Errors made with i18n.Errorf
+// are translated.
+func (cs *ConversationState) reject(err error) {
+	SendBasic(cs.server.Bot, cs.ChatID, "⚠️ "+i18n.ErrorText(cs.Lang, err))
+}
+
+// stop ends the conversation without notifying anyone.
+func (cs *ConversationState) stop() {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	cs.step = len(cs.conv.Steps)
+	if cs.timer != nil {
+		cs.timer.Stop()
+	}
+}
+
+// end stops the conversation and reports why, via OnCancel or a notice on timeout.
+func (cs *ConversationState) end(err error) {
+	cs.stop()
+
+	if !cs.server.conversations.CompareAndDelete(conversationKey{cs.ChatID, cs.UserID}, cs) {
+		return
+	}
+
+	if cs.conv.OnCancel != nil {
+		cs.conv.OnCancel(cs.server, cs, err)
+		return
+	}
+
+	if errors.Is(err, ErrConversationTimeout) {
+		SendBasic(cs.server.Bot, cs.ChatID, cs.T("⌛ Cancelled, no reply was received in time."))
+	}
+}
+
+// ParseText accepts any non-empty text reply.
+func ParseText(c *Context, cs *ConversationState, m *botapi.Message) (any, error) {
+	if text := strings.TrimSpace(m.Text); text != "" {
+		return text, nil
+	}
+
+	return nil, i18n.Errorf("Please reply with some text.")
+}
+
+// ParseDuration accepts durations like '1h30m' or '3h 15m 30 s'.
+func ParseDuration(c *Context, cs *ConversationState, m *botapi.Message) (any, error) {
+	return parseDuration(m.Text)
+}
+
+// ParseTime accepts dates like '20 Jul 99 07:00 BST'. See parseTime.
+func ParseTime(c *Context, cs *ConversationState, m *botapi.Message) (any, error) {
+	return parseTime(m.Text)
+}
+
+// ParsePhoto accepts a photo, keeping its largest size.
+func ParsePhoto(c *Context, cs *ConversationState, m *botapi.Message) (any, error) {
+	if len(m.Photo) == 0 {
+		return nil, i18n.Errorf("Please send an image.")
+	}
+
+	return &m.Photo[len(m.Photo)-1], nil
+}
+
+func parseDuration(text string) (dur time.Duration, err error) {
+	if dur, err = time.ParseDuration(strings.ReplaceAll(text, " ", "")); err != nil || dur <= 0 {
+		err = i18n.Errorf("Invalid duration. Accepted units are 'h', 'm' and 's'.")
+	}
+
+	return
+}
+
+// parseTime reads an RFC822 time, filling in a missing time of day as 00:00 and a missing
+// zone as UTC.
+func parseTime(text string) (t time.Time, err error) {
+	text = strings.TrimSpace(text)
+
+	switch n := strings.Count(text, " "); {
+	case n < 2:
+		err = i18n.Errorf("Could not get a valid time from %q. Try e.g. '20 Jul 99 07:00 BST'.", text)
+		return
+	case n == 2:
+		text += " 00:00 +0000"
+	case n == 3:
+		text += " +0000"
+	}
+
+	if t, err = time.Parse(time.RFC822Z, text); err != nil {
+		t, err = time.Parse(time.RFC822, text)
+	}
+
+	if err != nil {
+		err = i18n.Errorf("Could not get a valid time from %q. Try e.g. '20 Jul 99 07:00 BST'.", text)
+	}
+
+	return
+}
@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+)
+
+func reply(s *api.Server, chat *botapi.Chat, user *botapi.User, text string) (*api.Context, bool) {
+	m := &botapi.Message{From: user, Chat: chat, Text: text}
+	ctx := &api.Context{Server: s, Update: &botapi.Update{Message: m}, User: user, Chat: chat, Message: m}
+
+	return ctx, s.DoConversation(ctx)
+}
+
+func TestConversation(t *testing.T) {
+	s := &api.Server{}
+	chat, user, other := &botapi.Chat{ID: -1}, &botapi.User{ID: 1}, &botapi.User{ID: 2}
+	quiet := func(*api.Context, *api.ConversationState) {}
+
+	var done *api.ConversationState
+
+	conv := &api.Conversation{
+		Steps: []*api.Step{
+			{Name: "kind", Ask: quiet, Next: func(cs *api.ConversationState) string {
+				if cs.String("kind") == "forever" {
+					return "note"
+				}
+				return ""
+			}},
+			{Name: "for", Ask: quiet, Parse: api.ParseDuration},
+			{Name: "note", Ask: quiet},
+		},
+		OnDone: func(c *api.Context, cs *api.ConversationState) {
+			done = cs
+		},
+	}
+
+	ctx, _ := reply(s, chat, user, "")
+	conv.Start(ctx, "data")
+
+	if _, ok := reply(s, chat, other, "timed"); ok {
+		t.Errorf("DoConversation() - Expected another user's message to be ignored")
+	}
+
+	if _, ok := reply(s, chat, user, "/help"); ok {
+		t.Errorf("DoConversation() - Expected commands to be ignored")
+	}
+
+	for _, text := range []string{"timed", "90m", "hello"} {
+		if _, ok := reply(s, chat, user, text); !ok {
+			t.Fatalf("DoConversation() - Expected %q to be consumed", text)
+		}
+	}
+
+	if done == nil {
+		t.Fatalf("OnDone() - Expected to be called")
+	}
+
+	if dur, _ := done.Get("for").(time.Duration); dur != time.Minute*90 || done.String("note") != "hello" || done.Data != "data" {
+		t.Errorf("OnDone() - Expected 1h30m0s, \"hello\", \"data\"; Got %v, %q, %v", done.Get("for"), done.String("note"), done.Data)
+	}
+
+	if s.Conversation(chat.ID, user.ID) != nil {
+		t.Errorf("Conversation() - Expected nil once done")
+	}
+
+	// Branching past the duration step
+	done = nil
+	conv.Start(ctx, nil)
+	reply(s, chat, user, "forever")
+	reply(s, chat, user, "bye")
+
+	if done == nil || done.Has("for") || done.String("note") != "bye" {
+		t.Errorf("Next() - Expected the duration step to be skipped; Got %v", done)
+	}
+
+	// Preset values are skipped, and cancelling ends the conversation
+	done = nil
+	cs := conv.StartWith(ctx, nil, map[string]any{"kind": "timed"})
+
+	if !cs.Is("for") {
+		t.Errorf("StartWith() - Expected to start at \"for\"")
+	}
+
+	conv.OnCancel = func(*api.Server, *api.ConversationState, error) {}
+
+	if !s.CancelConversation(chat.ID, user.ID) || s.CancelConversation(chat.ID, user.ID) {
+		t.Errorf("CancelConversation() - Expected true, then false")
+	}
+
+	if _, ok := reply(s, chat, user, "90m"); ok || done != nil {
+		t.Errorf("DoConversation() - Expected no conversation after cancelling")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	expected := map[string]time.Time{
+		"20 Jul 99":             time.Date(1999, 7, 20, 0, 0, 0, 0, time.UTC),
+		"20 Jul 99 07:00":       time.Date(1999, 7, 20, 7, 0, 0, 0, time.UTC),
+		"20 Jul 99 07:00 +0100": time.Date(1999, 7, 20, 6, 0, 0, 0, time.UTC),
+	}
+
+	for text, want := range expected {
+		got, err := api.ParseTime(nil, nil, &botapi.Message{Text: text})
+		if err != nil || !got.(time.Time).Equal(want) {
+			t.Errorf("ParseTime() - Expected %s for %q; Got %v, %v", want, text, got, err)
+		}
+	}
+
+	if _, err := api.ParseTime(nil, nil, &botapi.Message{Text: "tomorrow"}); err == nil {
+		t.Errorf("ParseTime() - Expected error for %q", "tomorrow")
+	}
+}
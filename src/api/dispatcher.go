@@ -0,0 +1,151 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/util"
+)
+
+const (
+	defaultWorkers    = 8
+	defaultQueueDepth = 64
+)
+
+// Dispatcher runs updates on a fixed pool of workers, each with a bounded queue.
+//
+// Updates from the same chat are always routed to the same worker, so they are handled
+// in the order received, while different chats are handled in parallel.
+type Dispatcher struct {
+	queues []chan botapi.Update
+	handle func(botapi.Update)
+	wg     sync.WaitGroup
+}
+
+func NewDispatcher(workers, depth int, handle func(botapi.Update)) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+
+	d := &Dispatcher{
+		queues: make([]chan botapi.Update, workers),
+		handle: handle,
+	}
+
+	d.wg.Add(workers)
+
+	for i := range d.queues {
+		d.queues[i] = make(chan botapi.Update, depth)
+		go d.work(d.queues[i])
+	}
+
+	return d
+}
+
+// Dispatch queues the update on its chat's worker. If that queue is full, Dispatch blocks
+// until there is room, applying back-pressure to whatever is receiving updates.
+func (d *Dispatcher) Dispatch(update botapi.Update) {
+	i := int(uint64(UpdateChatID(&update)) % uint64(len(d.queues)))
+	queue := d.queues[i]
+
+	select {
+	case queue <- update:
+		return
+	default:
+	}
+
+	if util.TryLockFor(fmt.Sprintf("dispatcher %d", i), time.Second*10) {
+		log.Printf("Dispatcher: worker %d queue full (%d), blocking until it drains\n", i, cap(queue))
+	}
+
+	start := time.Now()
+	queue <- update
+
+	if waited := time.Since(start); waited > time.Second {
+		log.Printf("Dispatcher: update %d waited %s for worker %d\n", update.UpdateID, waited, i)
+	}
+}
+
+// Stop closes the queues and blocks until every queued update has been handled.
+// Dispatch must not be called after Stop.
+func (d *Dispatcher) Stop() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+
+	d.wg.Wait()
+}
+
+// Pending returns the number of updates waiting across all queues.
+func (d *Dispatcher) Pending() (n int) {
+	for _, queue := range d.queues {
+		n += len(queue)
+	}
+
+	return
+}
+
+func (d *Dispatcher) work(queue chan botapi.Update) {
+	defer d.wg.Done()
+
+	for update := range queue {
+		d.handle(update)
+	}
+}
+
+// UpdateChatID returns the ID of the chat an update belongs to. Updates without a chat,
+// such as inline queries, are keyed by the user who sent them.
+func UpdateChatID(u *botapi.Update) int64 {
+	// FromChat assumes callback queries have a message, which those from inline messages don't
+	if u.CallbackQuery == nil || u.CallbackQuery.Message != nil {
+		if chat := u.FromChat(); chat != nil {
+			return chat.ID
+		}
+	}
+
+	switch {
+	case u.ChatMember != nil:
+		return u.ChatMember.Chat.ID
+	case u.MyChatMember != nil:
+		return u.MyChatMember.Chat.ID
+	case u.MessageReaction != nil && u.MessageReaction.Chat != nil:
+		return u.MessageReaction.Chat.ID
+	case u.MessageReactionCount != nil && u.MessageReactionCount.Chat != nil:
+		return u.MessageReactionCount.Chat.ID
+	}
+
+	if user := u.SentFrom(); user != nil {
+		return user.ID
+	}
+
+	return 0
+}
+
+func envInt(key string, def int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+
+		log.Printf("Server: Invalid %s %q, using %d", key, v, def)
+	}
+
+	return def
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if dur, err := time.ParseDuration(v); err == nil && dur > 0 {
+			return dur
+		}
+
+		log.Printf("Server: Invalid %s %q, using %s", key, v, def)
+	}
+
+	return def
+}
@@ -0,0 +1,102 @@
+package api_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+)
+
+func chatUpdate(id int, chatID int64) botapi.Update {
+	return botapi.Update{
+		UpdateID: id,
+		Message: &botapi.Message{
+			Chat: &botapi.Chat{ID: chatID},
+			From: &botapi.User{ID: chatID},
+		},
+	}
+}
+
+func TestDispatcherOrder(t *testing.T) {
+	mu := sync.Mutex{}
+	seen := map[int64][]int{}
+
+	d := api.NewDispatcher(3, 4, func(u botapi.Update) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		chatID := u.Message.Chat.ID
+		seen[chatID] = append(seen[chatID], u.UpdateID)
+	})
+
+	chats := []int64{-100, 7, 12, -3}
+
+	for i := range 200 {
+		d.Dispatch(chatUpdate(i, chats[i%len(chats)]))
+	}
+
+	d.Stop()
+
+	total := 0
+
+	for chatID, ids := range seen {
+		total += len(ids)
+
+		for i := 1; i < len(ids); i++ {
+			if ids[i] < ids[i-1] {
+				t.Errorf("Dispatch() - Chat %d handled %d after %d", chatID, ids[i], ids[i-1])
+			}
+		}
+	}
+
+	if total != 200 {
+		t.Errorf("Stop() - Expected %d updates handled; Got %d", 200, total)
+	}
+}
+
+func TestDispatcherParallel(t *testing.T) {
+	release := make(chan struct{})
+
+	d := api.NewDispatcher(2, 1, func(u botapi.Update) {
+		if u.Message.Chat.ID == 1 {
+			<-release
+			return
+		}
+
+		close(release)
+	})
+
+	d.Dispatch(chatUpdate(1, 1))
+	d.Dispatch(chatUpdate(2, 2))
+
+	done := make(chan struct{})
+
+	go func() {
+		d.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Dispatch() - Expected chats on different workers to run in parallel")
+	}
+}
+
+func TestUpdateChatID(t *testing.T) {
+	user := &botapi.User{ID: 7}
+
+	updates := map[int64]botapi.Update{
+		-100: chatUpdate(1, -100),
+		-200: {ChatMember: &botapi.ChatMemberUpdated{Chat: botapi.Chat{ID: -200}}},
+		7:    {CallbackQuery: &botapi.CallbackQuery{From: user, InlineMessageID: "inline"}},
+	}
+
+	for expected, u := range updates {
+		if id := api.UpdateChatID(&u); id != expected {
+			t.Errorf("UpdateChatID() - Expected %d; Got %d", expected, id)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SuspendHooks saves what's pending as at shutdown, without closing the database.
+func (s *Server) SuspendHooks() {
+	s.suspendHooks()
+}
+
+// Serve is Listen, stopping when ctx is done rather than on a signal.
+func (s *Server) Serve(ctx context.Context) {
+	s.serve(ctx)
+}
+
+// UnregisterModules removes modules registered by a test, so that servers made by later
+// tests don't set them up.
+func UnregisterModules(names ...string) {
+	for _, name := range names {
+		delete(modules, name)
+	}
+}
+
+// UseCallbackStore replaces the callback store with one holding up to size payloads,
+// configured from the environment, until the test ends.
+func UseCallbackStore(t testing.TB, size int) {
+	callbackStoreOnce.Do(func() {})
+
+	old := callbackStore
+	callbackStore = loadCallbackStore(size)
+
+	t.Cleanup(func() { callbackStore = old })
+}
+
+// IsNewMessage reports whether c counts against a group's budget for new messages.
+func IsNewMessage(c botapi.Chattable) bool {
+	return isNewMessage(c)
+}
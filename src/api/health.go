@@ -0,0 +1,241 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/willmroliver/plathbot/src/util"
+)
+
+const healthTimeout = 5 * time.Second
+
+// healthCheck is the outcome of one of the checks behind /healthz and /readyz.
+type healthCheck struct {
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+type healthReport struct {
+	OK     bool                   `json:"ok"`
+	Checks map[string]healthCheck `json:"checks"`
+	Jobs   []util.JobStatus       `json:"jobs"`
+}
+
+// hookStatus describes a pending message hook for /status.
+type hookStatus struct {
+	Scope     string    `json:"scope"`
+	OwnerID   int64     `json:"owner_id"`
+	Namespace string    `json:"namespace"`
+	Handler   string    `json:"handler,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type statusReport struct {
+	Uptime   string           `json:"uptime"`
+	Modules  []string         `json:"modules"`
+	Menus    []string         `json:"menus"`
+	Commands []string         `json:"commands"`
+	Hooks    []hookStatus     `json:"hooks"`
+	Jobs     []util.JobStatus `json:"jobs"`
+	Schedule []JobInfo        `json:"schedule"`
+	Reports  map[string]any   `json:"reports"`
+}
+
+// AddStatus adds a section to /status, such as a module's running games. report is
+// called on each request, and its result encoded as JSON.
+func (s *Server) AddStatus(name string, report func() any) {
+	s.statusMux.Lock()
+	defer s.statusMux.Unlock()
+
+	if s.statuses == nil {
+		s.statuses = map[string]func() any{}
+	}
+
+	s.statuses[name] = report
+}
+
+// healthz reports whether the bot is alive: its database answers, it's handled an update
+// within STALL_TIMEOUT, if set, and none of its background jobs are stale. Failing it
+// means the process should be restarted.
+func (s *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	report := s.health(r.Context(), false)
+	report.OK = report.Checks["database"].OK && report.Checks["last_update"].OK && report.Checks["jobs"].OK
+
+	writeHealth(w, report)
+}
+
+// readyz reports whether the bot can serve users: it's receiving updates, and both its
+// database and Telegram answer.
+func (s *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	report := s.health(r.Context(), true)
+	report.OK = report.Checks["listening"].OK && report.Checks["database"].OK && report.Checks["telegram"].OK
+
+	writeHealth(w, report)
+}
+
+// health runs the checks, only calling out to Telegram if asked.
+func (s *Server) health(ctx context.Context, telegram bool) *healthReport {
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+
+	report := &healthReport{Checks: map[string]healthCheck{}, Jobs: util.Jobs()}
+
+	report.Checks["listening"] = healthCheck{OK: s.ready.Load()}
+	report.Checks["database"] = checkErr(s.pingDB(ctx))
+
+	if telegram {
+		report.Checks["telegram"] = checkErr(s.pingTelegram(ctx))
+	}
+
+	last := healthCheck{OK: true, Detail: "none yet"}
+	if at := s.LastUpdate(); !at.IsZero() {
+		last.Detail = fmt.Sprintf("%s ago", time.Since(at).Round(time.Second))
+	}
+
+	if stall := envDuration("STALL_TIMEOUT", 0); stall > 0 && s.ready.Load() {
+		since := s.LastUpdate()
+		if since.Before(s.listening) {
+			since = s.listening
+		}
+
+		last.OK = time.Since(since) <= stall
+	}
+
+	report.Checks["last_update"] = last
+
+	var stale []string
+	for _, job := range report.Jobs {
+		if job.Stale {
+			stale = append(stale, job.Name)
+		}
+	}
+
+	jobs := healthCheck{OK: len(stale) == 0}
+	if !jobs.OK {
+		jobs.Detail = "stale: " + strings.Join(stale, ", ")
+	}
+
+	report.Checks["jobs"] = jobs
+
+	return report
+}
+
+// status lists what the bot is running: its modules, menus and commands, pending hooks,
+// background jobs, scheduled jobs, and whatever modules report with AddStatus.
+func (s *Server) status(w http.ResponseWriter, r *http.Request) {
+	report := &statusReport{
+		Uptime:   time.Since(s.created).Round(time.Second).String(),
+		Modules:  s.EnabledModules(),
+		Menus:    []string{},
+		Commands: slices.Sorted(maps.Keys(s.CommandAPI.Actions)),
+		Hooks:    []hookStatus{},
+		Jobs:     util.Jobs(),
+		Schedule: []JobInfo{},
+		Reports:  map[string]any{},
+	}
+
+	if s.Scheduler != nil {
+		report.Schedule = s.Scheduler.Jobs()
+	}
+
+	for _, a := range s.callbackAPIs {
+		report.Menus = append(report.Menus, a.Path)
+	}
+
+	for _, hook := range s.hooks.all() {
+		scope := "chat"
+		if hook.scope == HookUser {
+			scope = "user"
+		}
+
+		report.Hooks = append(report.Hooks, hookStatus{scope, hook.id, hook.Namespace, hook.Handler, hook.ExpiresAt})
+	}
+
+	s.statusMux.Lock()
+	for name, fn := range s.statuses {
+		report.Reports[name] = fn()
+	}
+	s.statusMux.Unlock()
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// requireToken guards next with MONITOR_TOKEN, sent as a bearer token.
+func requireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			log.Printf("Monitor: rejected %s request from %s with bad token", r.URL.Path, r.RemoteAddr)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func (s *Server) pingDB(ctx context.Context) error {
+	if s.DB == nil {
+		return errors.New("no database")
+	}
+
+	sqlDB, err := s.DB.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
+
+// pingTelegram calls getMe, giving up when ctx is done.
+func (s *Server) pingTelegram(ctx context.Context) error {
+	res := make(chan error, 1)
+
+	go func() {
+		_, err := s.Bot.GetMe()
+		res <- err
+	}()
+
+	select {
+	case err := <-res:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func checkErr(err error) healthCheck {
+	if err != nil {
+		return healthCheck{Detail: err.Error()}
+	}
+
+	return healthCheck{OK: true}
+}
+
+func writeHealth(w http.ResponseWriter, report *healthReport) {
+	code := http.StatusOK
+	if !report.OK {
+		code = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, code, report)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Monitor: Error writing response: %q", err.Error())
+	}
+}
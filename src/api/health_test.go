@@ -0,0 +1,116 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/apitest"
+	"github.com/willmroliver/plathbot/src/util"
+)
+
+type healthResponse struct {
+	OK     bool `json:"ok"`
+	Checks map[string]struct {
+		OK     bool   `json:"ok"`
+		Detail string `json:"detail"`
+	} `json:"checks"`
+}
+
+func get(t *testing.T, h http.Handler, path, token string, v any) int {
+	t.Helper()
+
+	r := httptest.NewRequest("GET", path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if v != nil && w.Code != http.StatusUnauthorized {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("GET %s - Unexpected error decoding %q: %q", path, w.Body.String(), err.Error())
+		}
+	}
+
+	return w.Code
+}
+
+func TestHealth(t *testing.T) {
+	t.Setenv("MONITOR_TOKEN", "secret")
+
+	h := apitest.New(t)
+	monitor := h.Server.MonitorHandler()
+
+	res := &healthResponse{}
+	if code := get(t, monitor, "/healthz", "", res); code != http.StatusOK || !res.Checks["database"].OK || res.Checks["last_update"].Detail != "none yet" {
+		t.Errorf("/healthz - Expected a healthy bot yet to handle an update; Got %d, %+v", code, res)
+	}
+
+	// Listen hasn't been called, so the bot isn't receiving updates
+	res = &healthResponse{}
+	if code := get(t, monitor, "/readyz", "", res); code != http.StatusServiceUnavailable || res.Checks["listening"].OK || !res.Checks["telegram"].OK {
+		t.Errorf("/readyz - Expected only listening to fail; Got %d, %+v", code, res)
+	}
+
+	h.SendText(apitest.Group(-400), apitest.User(40, "dave"), "hello")
+
+	res = &healthResponse{}
+	if get(t, monitor, "/healthz", "", res); res.Checks["last_update"].Detail == "none yet" {
+		t.Errorf("/healthz - Expected the last update to be reported; Got %+v", res)
+	}
+
+	job := util.NewJob("health test", time.Millisecond)
+	job.Start()
+	time.Sleep(time.Millisecond * 5)
+
+	res = &healthResponse{}
+	if code := get(t, monitor, "/healthz", "", res); code != http.StatusServiceUnavailable || res.Checks["jobs"].Detail != "stale: health test" {
+		t.Errorf("/healthz - Expected the stale job to fail it; Got %d, %+v", code, res)
+	}
+
+	job.Stop()
+
+	if code := get(t, monitor, "/healthz", "", nil); code != http.StatusOK {
+		t.Errorf("/healthz - Expected stopped jobs to be ignored; Got %d", code)
+	}
+
+	if code := get(t, monitor, "/status", "wrong", nil); code != http.StatusUnauthorized {
+		t.Errorf("/status - Expected a bad token to be rejected; Got %d", code)
+	}
+
+	h.Server.RegisterChatHook(-400, api.NewMessageHook(func(*api.Server, *botapi.Message, any) bool { return true }, nil, time.Minute))
+	h.Server.AddStatus("test", func() any { return 42 })
+	h.Server.RegisterCallbackAPI(api.NewCallbackAPI("🩺 Health", "healthmenu", &api.CallbackConfig{}))
+
+	status := &struct {
+		Menus []string `json:"menus"`
+		Hooks []struct {
+			Scope   string `json:"scope"`
+			OwnerID int64  `json:"owner_id"`
+		} `json:"hooks"`
+		Reports map[string]int `json:"reports"`
+	}{}
+
+	if code := get(t, monitor, "/status", "secret", status); code != http.StatusOK {
+		t.Fatalf("/status - Expected %d; Got %d", http.StatusOK, code)
+	}
+
+	if !slices.Contains(status.Menus, "healthmenu") {
+		t.Errorf("/status - Expected the menus to be listed; Got %v", status.Menus)
+	}
+
+	if len(status.Hooks) != 1 || status.Hooks[0].Scope != "chat" || status.Hooks[0].OwnerID != -400 {
+		t.Errorf("/status - Expected the pending hook; Got %+v", status.Hooks)
+	}
+
+	if status.Reports["test"] != 42 {
+		t.Errorf("/status - Expected the added report; Got %v", status.Reports)
+	}
+}
@@ -1,17 +1,122 @@
 package api
 
 import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-type InlineAction func(*Context, *botapi.InlineQuery)
+const (
+	defaultInlineCache    = time.Minute * 5
+	defaultInlinePageSize = 20
+	// maxInlinePageSize is the most results Telegram accepts in one answer
+	maxInlinePageSize = 50
+)
+
+// InlineAction answers an inline query routed to it by its first word, e.g. "stats" in
+// "@bot stats games week", with the words after as args. It returns every result, and
+// the InlineAPI answers with a page at a time.
+type InlineAction func(c *Context, q *botapi.InlineQuery, args ...string) []any
+
+// InlineMeta is how an inline action's answers are cached and paged.
+type InlineMeta struct {
+	CacheTime time.Duration
+	// Shared answers are cached for every user, rather than per user. Only set it for
+	// results which aren't translated or otherwise personal.
+	Shared   bool
+	PageSize int
+}
+
+type InlineOption func(*InlineMeta)
+
+// InlineCache sets how long Telegram may cache the action's answers.
+func InlineCache(d time.Duration) InlineOption {
+	return func(m *InlineMeta) {
+		m.CacheTime = d
+	}
+}
+
+// InlineShared lets Telegram share cached answers between users.
+func InlineShared() InlineOption {
+	return func(m *InlineMeta) {
+		m.Shared = true
+	}
+}
+
+// InlinePageSize sets how many results are sent at a time, at least 1 and up to Telegram's
+// limit of 50.
+func InlinePageSize(n int) InlineOption {
+	return func(m *InlineMeta) {
+		m.PageSize = max(1, min(n, maxInlinePageSize))
+	}
+}
+
+func newInlineMeta(opts ...InlineOption) *InlineMeta {
+	meta := &InlineMeta{CacheTime: defaultInlineCache, PageSize: defaultInlinePageSize}
+	for _, opt := range opts {
+		opt(meta)
+	}
+
+	return meta
+}
 
 type InlineAPI struct {
 	Actions map[string]InlineAction
+	Meta    map[string]*InlineMeta
 }
 
-func (api *InlineAPI) Select(c *Context, msg *botapi.InlineQuery, cmd string) {
-	if action, exists := api.Actions[cmd]; exists {
-		action(c, msg)
+// Select routes the query by its first word, ignoring case, and answers with the page of
+// results at its offset. Queries matching no action go to the one registered as "", if any,
+// with every word as args.
+func (api *InlineAPI) Select(c *Context, q *botapi.InlineQuery) {
+	args := strings.Fields(q.Query)
+
+	cmd := ""
+	if len(args) > 0 {
+		cmd = strings.ToLower(args[0])
+	}
+
+	action, ok := api.Actions[cmd]
+	if ok && cmd != "" {
+		args = args[1:]
+	} else if action, ok = api.Actions[""]; ok {
+		cmd = ""
+	} else {
+		return
+	}
+
+	meta := api.Meta[cmd]
+	if meta == nil {
+		meta = newInlineMeta()
+	}
+
+	inlineQueriesTotal.Inc(cmd)
+
+	results := action(c, q, args...)
+	if results == nil {
+		results = []any{}
+	}
+
+	offset, _ := strconv.Atoi(q.Offset)
+	offset = min(max(offset, 0), len(results))
+	end := min(offset+meta.PageSize, len(results))
+
+	answer := botapi.InlineConfig{
+		InlineQueryID: q.ID,
+		Results:       results[offset:end],
+		CacheTime:     int(meta.CacheTime.Seconds()),
+		IsPersonal:    !meta.Shared,
+	}
+
+	if end < len(results) {
+		answer.NextOffset = strconv.Itoa(end)
+	}
+
+	if _, err := c.Bot.Request(answer); err != nil {
+		countTelegramError(err)
+		log.Printf("Inline: Error answering %q: %q", q.Query, err.Error())
 	}
 }
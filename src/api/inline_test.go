@@ -0,0 +1,83 @@
+package api_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/apitest"
+)
+
+func TestInlineQuery(t *testing.T) {
+	h := apitest.New(t)
+
+	var got []string
+	h.Server.RegisterInlineAction("count", func(c *api.Context, q *botapi.InlineQuery, args ...string) []any {
+		got = args
+
+		results := make([]any, 25)
+		for i := range results {
+			results[i] = botapi.NewInlineQueryResultArticle(fmt.Sprintf("%d", i), fmt.Sprintf("#%d", i), "text")
+		}
+
+		return results
+	}, api.InlineShared())
+
+	h.Server.RegisterInlineAction("few", func(c *api.Context, q *botapi.InlineQuery, args ...string) []any {
+		return []any{
+			botapi.NewInlineQueryResultArticle("a", "A", "text"),
+			botapi.NewInlineQueryResultArticle("b", "B", "text"),
+		}
+	}, api.InlinePageSize(0))
+
+	user := apitest.User(50, "erin")
+
+	answer := func(query, offset string) (ids []string, next string, personal string) {
+		t.Helper()
+
+		h.Send(botapi.Update{InlineQuery: &botapi.InlineQuery{ID: "q", From: user, Query: query, Offset: offset}})
+
+		req := h.Last("answerInlineQuery")
+		if req == nil {
+			t.Fatalf("Select() - Expected the query to be answered; Got no answer")
+		}
+
+		var results []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal([]byte(req.Params.Get("results")), &results); err != nil {
+			t.Fatalf("Select() - Unexpected error decoding results: %q", err.Error())
+		}
+
+		for _, r := range results {
+			ids = append(ids, r.ID)
+		}
+
+		return ids, req.Params.Get("next_offset"), req.Params.Get("is_personal")
+	}
+
+	ids, next, personal := answer("Count up to", "")
+	if len(ids) != 20 || ids[0] != "0" || next != "20" {
+		t.Errorf("Select() - Expected the first 20 results, with 20 as the next offset; Got %v, %q", ids, next)
+	}
+
+	if len(got) != 2 || got[0] != "up" || got[1] != "to" {
+		t.Errorf("Select() - Expected the words after the command as args; Got %v", got)
+	}
+
+	if personal == "true" {
+		t.Errorf("Select() - Expected shared results not to be personal")
+	}
+
+	ids, next, _ = answer("Count up to", next)
+	if len(ids) != 5 || ids[0] != "20" || next != "" {
+		t.Errorf("Select() - Expected the last 5 results, with no next offset; Got %v, %q", ids, next)
+	}
+
+	// Page sizes below 1 are raised to it, rather than paging forever
+	if ids, next, _ = answer("few", ""); len(ids) != 1 || ids[0] != "a" || next != "1" {
+		t.Errorf("Select() - Expected a page of 1 result, with 1 as the next offset; Got %v, %q", ids, next)
+	}
+}
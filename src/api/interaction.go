@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"time"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -33,9 +34,10 @@ func (i *Interaction[T]) Age() time.Duration {
 	return time.Since(i.time)
 }
 
+// NewMessage replies in the interaction's chat with text, formatted as HTML.
 func (i *Interaction[T]) NewMessage(text string, markup *tgbotapi.InlineKeyboardMarkup) *botapi.MessageConfig {
 	msg := botapi.NewMessage(i.Msg.Chat.ID, text)
-	msg.ParseMode = botapi.ModeMarkdown
+	msg.ParseMode = botapi.ModeHTML
 
 	if markup != nil {
 		msg.ReplyMarkup = *markup
@@ -44,6 +46,7 @@ func (i *Interaction[T]) NewMessage(text string, markup *tgbotapi.InlineKeyboard
 	return &msg
 }
 
+// NewMessageUpdate edits the interaction's message to text, formatted as HTML.
 func (i *Interaction[T]) NewMessageUpdate(text string, markup *tgbotapi.InlineKeyboardMarkup) *botapi.EditMessageTextConfig {
 	var msg botapi.EditMessageTextConfig
 	if markup != nil {
@@ -57,6 +60,38 @@ func (i *Interaction[T]) NewMessageUpdate(text string, markup *tgbotapi.InlineKe
 		msg = botapi.NewEditMessageText(i.Msg.Chat.ID, i.Msg.MessageID, text)
 	}
 
-	msg.ParseMode = botapi.ModeMarkdown
+	msg.ParseMode = botapi.ModeHTML
 	return &msg
 }
+
+// interactionJSON is how an Interaction is saved, e.g. as part of a durable hook's data.
+// Only the message's chat and ID are kept, which is all that's needed to reply to or edit it.
+type interactionJSON[T comparable] struct {
+	ChatID    int64     `json:"chat_id"`
+	MessageID int       `json:"message_id"`
+	State     T         `json:"state"`
+	Time      time.Time `json:"time"`
+}
+
+func (i *Interaction[T]) MarshalJSON() ([]byte, error) {
+	v := interactionJSON[T]{State: i.state, Time: i.time}
+
+	if i.Msg != nil && i.Msg.Chat != nil {
+		v.ChatID, v.MessageID = i.Msg.Chat.ID, i.Msg.MessageID
+	}
+
+	return json.Marshal(v)
+}
+
+func (i *Interaction[T]) UnmarshalJSON(data []byte) error {
+	var v interactionJSON[T]
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	i.Msg = &botapi.Message{MessageID: v.MessageID, Chat: &botapi.Chat{ID: v.ChatID}}
+	i.state, i.time = v.State, v.Time
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/i18n"
+	"github.com/willmroliver/plathbot/src/repo"
+)
+
+// Lang picks the language to use with a user in a chat.
+//
+// Groups use the chat's language if one is set, since everyone there sees the replies.
+// Otherwise it's the user's choice from the account menu, then the language their
+// Telegram app is set to, and lastly i18n.Default.
+func (s *Server) Lang(chat *botapi.Chat, user *botapi.User) string {
+	if chat != nil && chat.Type != "private" {
+		if lang := s.ChatSettings(chat.ID).Language; lang != "" {
+			return lang
+		}
+	}
+
+	if user == nil {
+		return i18n.Default
+	}
+
+	if s.DB != nil {
+		if lang := repo.NewUserRepo(s.DB).Language(user.ID); lang != "" {
+			return lang
+		}
+	}
+
+	if lang := i18n.Match(user.LanguageCode); lang != "" {
+		return lang
+	}
+
+	return i18n.Default
+}
+
+// langFor is Lang for a chat and user known only by ID, as with saved hooks. Negative
+// IDs are groups, and a user's Telegram language isn't known.
+func (s *Server) langFor(chatID, userID int64) string {
+	chat := &botapi.Chat{ID: chatID, Type: "private"}
+	if chatID < 0 {
+		chat.Type = "supergroup"
+	}
+
+	return s.Lang(chat, &botapi.User{ID: userID})
+}
+
+// Lang returns the language to reply in, resolved once per update by Server.Lang.
+func (ctx *Context) Lang() string {
+	if ctx.lang == "" {
+		ctx.lang = ctx.Server.Lang(ctx.Chat, ctx.User)
+	}
+
+	return ctx.lang
+}
+
+// T translates text into the user's language, formatting it with args as in i18n.T.
+func (ctx *Context) T(text string, args ...any) string {
+	return i18n.T(ctx.Lang(), text, args...)
+}
+
+// N translates a plural message into the user's language, as in i18n.N.
+func (ctx *Context) N(n int, one, other string, args ...any) string {
+	return i18n.N(ctx.Lang(), n, one, other, args...)
+}
+
+// InlineKeyboard is InlineKeyboard with the button labels translated.
+func (ctx *Context) InlineKeyboard(data []map[string]string, tags ...string) *botapi.InlineKeyboardMarkup {
+	return InlineKeyboard(TranslateOptions(ctx.Lang(), data), tags...)
+}
+
+// TranslateOptions copies a set of Text:Data keyboard options with the text translated.
+func TranslateOptions(lang string, opts []map[string]string) []map[string]string {
+	res := make([]map[string]string, len(opts))
+
+	for i, row := range opts {
+		res[i] = make(map[string]string, len(row))
+
+		for text, data := range row {
+			res[i][i18n.T(lang, text)] = data
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"context"
+	"log"
+	"time"
+
+	d "github.com/willmroliver/plathbot/src/db"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+// Stopper halts a background job, blocking until the job has exited.
+type Stopper func()
+
+// Shutdown waits for queued and running handlers, flushes outgoing messages, stops
+// the scheduler and every background job started by BeforeListen hooks, saves what's pending on message
+// hooks and closes the database.
+// Anything still running when ctx expires is abandoned.
+func (s *Server) Shutdown(ctx context.Context) {
+	running.CompareAndSwap(s, nil)
+
+	if s.dispatcher != nil && !wait(ctx, s.dispatcher.Stop) {
+		log.Printf("Server: Timed out with %d updates still queued", s.dispatcher.Pending())
+	}
+
+	if !wait(ctx, OutboxFor(s.Bot).Flush) {
+		log.Println("Server: Timed out flushing outgoing messages")
+	}
+
+	// Stopped one at a time, in the reverse of the order they were started, so the
+	// scheduler halts before the hook jobs its tasks may rely on
+	stopJobs := func() {
+		for i := len(s.stoppers) - 1; i >= 0; i-- {
+			s.stoppers[i]()
+		}
+	}
+
+	if !wait(ctx, stopJobs) {
+		log.Println("Server: Timed out waiting for background jobs to stop")
+	}
+
+	s.suspendHooks()
+
+	if err := d.Close(s.DB); err != nil {
+		log.Printf("Server: Error closing database: %q", err.Error())
+	}
+
+	log.Println("Server: Shutdown complete")
+}
+
+// wait calls the blocking func, returning false if ctx expires first.
+func wait(ctx context.Context, block func()) bool {
+	done := make(chan struct{})
+
+	go func() {
+		block()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func shutdownTimeout() time.Duration {
+	return envDuration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout)
+}
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"cmp"
+	"slices"
+	"sync"
 	"time"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -11,10 +14,37 @@ import (
 // Returning false indicates that the hook was not successful and should persist.
 type MessageHookable func(*Server, *botapi.Message, any) bool
 
+const defaultHookNamespace = "reply"
+
+// HookScope is what a hook is registered against: every message in a chat, or every
+// message from a user.
+type HookScope int
+
+const (
+	HookChat HookScope = iota
+	HookUser
+)
+
 type MessageHook struct {
 	ExpiresAt time.Time
 	Hook      MessageHookable
 	Data      any
+
+	// Namespace tells hooks with the same owner apart, defaulting to "reply". Registering
+	// a hook replaces any pending in the same namespace, and /cancel lists hooks by it.
+	Namespace string
+
+	// ReplyTo, if set, restricts the hook to replies to that message, e.g. its prompt.
+	ReplyTo int
+
+	// Handler names the registered handler of a durable hook. See NewDurableHook.
+	Handler string
+	// ChatID is where to apologise if the hook can't be resumed after a restart.
+	ChatID int64
+
+	scope HookScope
+	id    int64
+	seq   uint64
 }
 
 // NewMessageHook packs a hookable callback with a payload to pass on execution, and a lifespan.
@@ -30,7 +60,147 @@ func NewMessageHook(cb MessageHookable, data any, lifespan time.Duration) *Messa
 	}
 }
 
+// Named sets the hook's namespace.
+func (h *MessageHook) Named(namespace string) *MessageHook {
+	h.Namespace = namespace
+	return h
+}
+
+// ReplyingTo restricts the hook to replies to the given message.
+func (h *MessageHook) ReplyingTo(m *botapi.Message) *MessageHook {
+	if m != nil {
+		h.ReplyTo = m.MessageID
+	}
+
+	return h
+}
+
 // Execute executes the hookable, passing the given *Server, *Message and Data payload.
 func (h *MessageHook) Execute(s *Server, m *botapi.Message) bool {
 	return h.Hook(s, m, h.Data)
 }
+
+// Scope returns what the hook was registered against, and its ID.
+func (h *MessageHook) Scope() (HookScope, int64) {
+	return h.scope, h.id
+}
+
+type hookKey struct {
+	scope     HookScope
+	id        int64
+	namespace string
+}
+
+// hookRegistry holds the pending message hooks, keyed by (scope, id, namespace).
+type hookRegistry struct {
+	mu    sync.Mutex
+	hooks map[hookKey]*MessageHook
+	seq   uint64
+}
+
+func (r *hookRegistry) add(scope HookScope, id int64, hook *MessageHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.hooks == nil {
+		r.hooks = map[hookKey]*MessageHook{}
+	}
+
+	if hook.Namespace == "" {
+		hook.Namespace = defaultHookNamespace
+	}
+
+	r.seq++
+	hook.scope, hook.id, hook.seq = scope, id, r.seq
+
+	r.hooks[hookKey{scope, id, hook.Namespace}] = hook
+}
+
+// remove deletes the hook, unless it has since been replaced.
+func (r *hookRegistry) remove(hook *MessageHook) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := hookKey{hook.scope, hook.id, hook.Namespace}
+	if r.hooks[key] != hook {
+		return false
+	}
+
+	delete(r.hooks, key)
+	return true
+}
+
+// pending returns the live hooks owned by the chat or the user, newest first, pruning
+// any which have expired.
+func (r *hookRegistry) pending(chatID, userID int64) (hooks []*MessageHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	for key, hook := range r.hooks {
+		if hook.ExpiresAt.Before(now) {
+			delete(r.hooks, key)
+			continue
+		}
+
+		if (key.scope == HookChat && key.id == chatID) || (key.scope == HookUser && key.id == userID) {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	slices.SortFunc(hooks, func(a, b *MessageHook) int {
+		return cmp.Compare(b.seq, a.seq)
+	})
+
+	return
+}
+
+// all returns every live hook.
+func (r *hookRegistry) all() (hooks []*MessageHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	for _, hook := range r.hooks {
+		if hook.ExpiresAt.After(now) {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	return
+}
+
+// match returns the hooks to try for a message, in priority order: hooks waiting on a reply
+// to the message it replies to, then the sender's hooks, then the chat's. Within each,
+// newer hooks come first.
+func (r *hookRegistry) match(m *botapi.Message) []*MessageHook {
+	replyTo := 0
+	if m.ReplyToMessage != nil {
+		replyTo = m.ReplyToMessage.MessageID
+	}
+
+	hooks := r.pending(m.Chat.ID, m.From.ID)
+
+	rank := func(h *MessageHook) int {
+		switch {
+		case h.ReplyTo != 0:
+			return 0
+		case h.scope == HookUser:
+			return 1
+		default:
+			return 2
+		}
+	}
+
+	hooks = slices.DeleteFunc(hooks, func(h *MessageHook) bool {
+		return h.ReplyTo != 0 && h.ReplyTo != replyTo
+	})
+
+	slices.SortStableFunc(hooks, func(a, b *MessageHook) int {
+		return rank(a) - rank(b)
+	})
+
+	return hooks
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/i18n"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/repo"
+)
+
+// sessionExpiredText is sent for hooks pending at a restart which can't be resumed.
+const sessionExpiredText = "⌛ Sorry, I restarted and lost track of what we were doing. Please start again."
+
+var hookHandlers = map[string]hookHandler{}
+
+// hookHandler is a named hook callback, with a way to restore its data once saved.
+type hookHandler struct {
+	hook   MessageHookable
+	decode func([]byte) (any, error)
+}
+
+// RegisterHookHandler names a hook callback so that hooks using it can be saved and resumed
+// after a restart. Its data is saved as JSON, so T should be a serialisable type, or a
+// pointer to one. Call this from init, so handlers exist before saved hooks are resumed.
+func RegisterHookHandler[T any](name string, handler func(*Server, *botapi.Message, T) bool) {
+	hookHandlers[name] = hookHandler{
+		hook: func(s *Server, m *botapi.Message, data any) bool {
+			return handler(s, m, data.(T))
+		},
+		decode: func(b []byte) (any, error) {
+			var data T
+			err := json.Unmarshal(b, &data)
+			return data, err
+		},
+	}
+}
+
+// NewDurableHook is NewMessageHook for a handler registered with RegisterHookHandler. Pending
+// durable hooks are saved, and resumed if the server restarts before they're done. ChatID
+// is where to apologise if a hook can't be resumed.
+func NewDurableHook(handler string, chatID int64, data any, lifespan time.Duration) *MessageHook {
+	h, ok := hookHandlers[handler]
+	if !ok {
+		log.Panicf("NewDurableHook: no hook handler registered as %q", handler)
+	}
+
+	hook := NewMessageHook(h.hook, data, lifespan)
+	hook.Handler, hook.ChatID = handler, chatID
+
+	return hook
+}
+
+// saveHook records a newly registered hook. Anything saved under the same key is replaced,
+// or removed if the new hook can't be resumed.
+func (s *Server) saveHook(hook *MessageHook) {
+	if s.DB == nil {
+		return
+	}
+
+	r := repo.NewMessageHookRepo(s.DB)
+
+	if hook.Handler == "" {
+		r.Remove(int(hook.scope), hook.id, hook.Namespace)
+		return
+	}
+
+	if saved := s.savedHook(hook); saved != nil {
+		r.Put(saved)
+	}
+}
+
+// forgetHook removes a finished or cancelled hook from the store.
+func (s *Server) forgetHook(hook *MessageHook) {
+	if s.DB != nil && hook.Handler != "" {
+		repo.NewMessageHookRepo(s.DB).Remove(int(hook.scope), hook.id, hook.Namespace)
+	}
+}
+
+func (s *Server) savedHook(hook *MessageHook) *model.MessageHook {
+	saved := &model.MessageHook{
+		Scope:     int(hook.scope),
+		OwnerID:   hook.id,
+		Namespace: hook.Namespace,
+		Handler:   hook.Handler,
+		ChatID:    hook.ChatID,
+		ReplyTo:   hook.ReplyTo,
+		ExpiresAt: hook.ExpiresAt,
+	}
+
+	if saved.ChatID == 0 && hook.scope == HookChat {
+		// Chat hooks are in their chat. A user's could be in any, so they go without an apology
+		saved.ChatID = hook.id
+	}
+
+	if hook.Handler == "" {
+		return saved
+	}
+
+	data, err := json.Marshal(hook.Data)
+	if err != nil {
+		log.Printf("Server: error saving %q hook: %q", hook.Handler, err.Error())
+		return nil
+	}
+
+	saved.Data = data
+	return saved
+}
+
+// suspendHooks saves what's pending at shutdown which can't be resumed, so the users
+// waiting on it can be told when the server is back.
+//
+// Conversations aren't resumed: their steps and callbacks are code, and their Data may be
+// anything. Each waiting on an answer is saved against its user and chat, so that an
+// apology is sent to the chat it was held in.
+func (s *Server) suspendHooks() {
+	if s.DB == nil {
+		return
+	}
+
+	r := repo.NewMessageHookRepo(s.DB)
+
+	for _, hook := range s.hooks.all() {
+		if hook.Handler == "" {
+			r.Put(s.savedHook(hook))
+		}
+	}
+
+	s.conversations.Range(func(key, value any) bool {
+		cs := value.(*ConversationState)
+		if step := cs.waiting(); step == nil {
+			return true
+		}
+
+		name := cs.conv.Name
+		if name == "" {
+			name = "conversation"
+		}
+
+		r.Put(&model.MessageHook{
+			Scope:     int(HookUser),
+			OwnerID:   cs.UserID,
+			Namespace: fmt.Sprintf("%s/%d", name, cs.ChatID),
+			ChatID:    cs.ChatID,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+
+		return true
+	})
+}
+
+// ResumeHooks registers the saved hooks which haven't expired, and is called by Listen.
+// Those which can't be resumed, because they weren't durable or their handler is gone,
+// are dropped with an apology.
+func (s *Server) ResumeHooks() {
+	r := repo.NewMessageHookRepo(s.DB)
+	resumed, now := 0, time.Now()
+
+	for _, saved := range r.All() {
+		if saved.ExpiresAt.Before(now) {
+			r.Remove(saved.Scope, saved.OwnerID, saved.Namespace)
+			continue
+		}
+
+		hook, err := restoreHook(saved)
+		if err != nil {
+			log.Printf("Server: can't resume %q hook for %d: %q", saved.Namespace, saved.OwnerID, err.Error())
+
+			r.Remove(saved.Scope, saved.OwnerID, saved.Namespace)
+
+			if saved.ChatID == 0 {
+				continue
+			}
+
+			SendLater(s.Bot, botapi.NewMessage(saved.ChatID, i18n.T(s.langFor(saved.ChatID, saved.OwnerID), sessionExpiredText)))
+			continue
+		}
+
+		s.hooks.add(HookScope(saved.Scope), saved.OwnerID, hook)
+		resumed++
+	}
+
+	if resumed != 0 {
+		log.Printf("Server: Resumed %d message hooks\n", resumed)
+	}
+}
+
+func restoreHook(saved *model.MessageHook) (*MessageHook, error) {
+	if saved.Handler == "" {
+		return nil, errors.New("not durable")
+	}
+
+	h, ok := hookHandlers[saved.Handler]
+	if !ok {
+		return nil, fmt.Errorf("no handler %q", saved.Handler)
+	}
+
+	data, err := h.decode(saved.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MessageHook{
+		ExpiresAt: saved.ExpiresAt,
+		Hook:      h.hook,
+		Data:      data,
+		Namespace: saved.Namespace,
+		ReplyTo:   saved.ReplyTo,
+		Handler:   saved.Handler,
+		ChatID:    saved.ChatID,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package api_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/apitest"
+	"github.com/willmroliver/plathbot/src/db"
+)
+
+type testReply struct {
+	Interaction *api.Interaction[string] `json:"interaction"`
+	Note        string                   `json:"note"`
+}
+
+func TestResumeHooks(t *testing.T) {
+	conn, err := db.Open(filepath.Join(t.TempDir(), "hooks.db"))
+	if err != nil {
+		t.Fatalf("Open() - Unexpected error: %q", err.Error())
+	}
+	defer db.Close(conn)
+
+	db.Migrate(conn)
+
+	var got *testReply
+
+	api.RegisterHookHandler("test_reply", func(s *api.Server, m *botapi.Message, r *testReply) bool {
+		got = r
+		return true
+	})
+
+	chat := &botapi.Chat{ID: 5}
+	data := &testReply{api.NewInteraction(&botapi.Message{MessageID: 9, Chat: chat}, "asking"), "hi"}
+
+	before := &api.Server{DB: conn}
+	before.RegisterChatHook(chat.ID, api.NewDurableHook("test_reply", chat.ID, data, time.Minute).Named("test"))
+
+	after := &api.Server{DB: conn}
+	after.ResumeHooks()
+
+	if !after.DoMessageHook(&botapi.Message{From: &botapi.User{ID: 1}, Chat: chat, Text: "hello"}) {
+		t.Fatalf("DoMessageHook() - Expected the resumed hook to succeed")
+	}
+
+	if got == nil || got.Note != "hi" || !got.Interaction.Is("asking") || got.Interaction.Msg.MessageID != 9 || got.Interaction.Msg.Chat.ID != 5 {
+		t.Errorf("ResumeHooks() - Expected the hook's data to be restored; Got %+v", got)
+	}
+
+	// Done hooks are removed from the store
+	again := &api.Server{DB: conn}
+	again.ResumeHooks()
+
+	if n := len(again.PendingHooks(chat.ID, 1)); n != 0 {
+		t.Errorf("ResumeHooks() - Expected no hooks once done; Got %d", n)
+	}
+}
+
+func TestSuspendHooks(t *testing.T) {
+	h := apitest.New(t)
+	user := &botapi.User{ID: 1}
+	quiet := func(*api.Context, *api.ConversationState) {}
+
+	conv := &api.Conversation{Name: "test_talk", Steps: []*api.Step{{Name: "note", Ask: quiet}}}
+
+	// The same conversation in two chats, and a user hook which could be in any
+	for _, chatID := range []int64{-1, -2} {
+		ctx, _ := reply(h.Server, &botapi.Chat{ID: chatID}, user, "")
+		conv.Start(ctx, nil)
+	}
+
+	h.Server.RegisterUserHook(user.ID, api.NewMessageHook(func(*api.Server, *botapi.Message, any) bool {
+		return true
+	}, nil, time.Minute))
+
+	h.Server.SuspendHooks()
+
+	after := &api.Server{DB: h.DB, Bot: h.Server.Bot}
+	after.ResumeHooks()
+	api.OutboxFor(after.Bot).Flush()
+
+	chats := map[int64]int{}
+	for _, req := range h.Requests("sendMessage") {
+		chats[req.ChatID()]++
+	}
+
+	if len(chats) != 2 || chats[-1] != 1 || chats[-2] != 1 {
+		t.Errorf("ResumeHooks() - Expected an apology in each conversation's chat; Got %v", chats)
+	}
+}
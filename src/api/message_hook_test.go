@@ -0,0 +1,59 @@
+package api_test
+
+import (
+	"testing"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+)
+
+func TestDoMessageHook(t *testing.T) {
+	s := &api.Server{}
+	chat, user := &botapi.Chat{ID: -1}, &botapi.User{ID: 1}
+	ran := []string{}
+
+	hook := func(name string, ok bool) *api.MessageHook {
+		return api.NewMessageHook(func(*api.Server, *botapi.Message, any) bool {
+			ran = append(ran, name)
+			return ok
+		}, nil, time.Minute).Named(name)
+	}
+
+	s.RegisterChatHook(chat.ID, hook("chat", true))
+	s.RegisterUserHook(user.ID, hook("wallet", false))
+	s.RegisterUserHook(user.ID, hook("wallet", false))
+	s.RegisterUserHook(user.ID, hook("prompt", true).ReplyingTo(&botapi.Message{MessageID: 7}))
+
+	if n := len(s.PendingHooks(chat.ID, user.ID)); n != 3 {
+		t.Errorf("RegisterUserHook() - Expected a namespace to be replaced, leaving 3 hooks; Got %d", n)
+	}
+
+	if !s.DoMessageHook(&botapi.Message{From: user, Chat: chat, Text: "hi"}) {
+		t.Errorf("DoMessageHook() - Expected success")
+	}
+
+	if len(ran) != 2 || ran[0] != "wallet" || ran[1] != "chat" {
+		t.Errorf("DoMessageHook() - Expected wallet, chat; Got %v", ran)
+	}
+
+	ran = ran[:0]
+	reply := &botapi.Message{From: user, Chat: chat, Text: "hi", ReplyToMessage: &botapi.Message{MessageID: 7}}
+
+	if !s.DoMessageHook(reply) || len(ran) != 1 || ran[0] != "prompt" {
+		t.Errorf("DoMessageHook() - Expected the reply to go to prompt first; Got %v", ran)
+	}
+
+	pending := s.PendingHooks(chat.ID, user.ID)
+	if len(pending) != 1 || pending[0].Namespace != "wallet" {
+		t.Fatalf("PendingHooks() - Expected only wallet to remain; Got %d hooks", len(pending))
+	}
+
+	if !s.CancelHook(pending[0]) || s.CancelHook(pending[0]) {
+		t.Errorf("CancelHook() - Expected true, then false")
+	}
+
+	if s.DoMessageHook(&botapi.Message{From: user, Chat: chat, Text: "hi"}) {
+		t.Errorf("DoMessageHook() - Expected no hooks left to succeed")
+	}
+}
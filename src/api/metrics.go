@@ -0,0 +1,95 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/metrics"
+)
+
+var (
+	updatesTotal   = metrics.NewCounter("plathbot_updates_total", "Updates received, by type.", "type")
+	updateDuration = metrics.NewHistogram("plathbot_update_duration_seconds", "Time taken handling an update, by type.", metrics.DefBuckets, "type")
+
+	callbacksTotal   = metrics.NewCounter("plathbot_callbacks_total", "Menu actions run, by the path they resolved to.", "path")
+	callbackDuration = metrics.NewHistogram("plathbot_callback_duration_seconds", "Time taken handling a menu action, by the path it resolved to.", metrics.DefBuckets, "path")
+
+	inlineQueriesTotal = metrics.NewCounter("plathbot_inline_queries_total", "Inline queries answered, by action.", "action")
+
+	commandsTotal   = metrics.NewCounter("plathbot_commands_total", "Commands run, by command.", "command")
+	commandDuration = metrics.NewHistogram("plathbot_command_duration_seconds", "Time taken running a command, by command.", metrics.DefBuckets, "command")
+
+	telegramErrors = metrics.NewCounter("plathbot_telegram_errors_total", "Requests to the Bot API which failed for good, by error code.", "code")
+	pendingHooks   = metrics.NewGauge("plathbot_message_hooks_pending", "Message hooks waiting on a message.")
+
+	// running is the server listening for updates, whose hooks are counted as pending
+	running atomic.Pointer[Server]
+)
+
+func init() {
+	pendingHooks.Func(func() float64 {
+		if s := running.Load(); s != nil {
+			return float64(len(s.hooks.all()))
+		}
+
+		return 0
+	})
+}
+
+// Metrics counts each update and times its handling, by type, and by the menu path it
+// resolved to if it opened one.
+func Metrics(next Handler) Handler {
+	return func(ctx *Context) {
+		start, kind := time.Now(), updateType(ctx.Update)
+
+		defer func() {
+			updatesTotal.Inc(kind)
+			updateDuration.Since(start, kind)
+
+			if ctx.callbackPath != "" {
+				callbacksTotal.Inc(ctx.callbackPath)
+				callbackDuration.Since(start, ctx.callbackPath)
+			}
+		}()
+
+		next(ctx)
+	}
+}
+
+func updateType(u *botapi.Update) string {
+	switch {
+	case u.Message != nil:
+		return "message"
+	case u.EditedMessage != nil:
+		return "edited_message"
+	case u.CallbackQuery != nil:
+		return "callback_query"
+	case u.InlineQuery != nil:
+		return "inline_query"
+	case u.MessageReaction != nil:
+		return "message_reaction"
+	case u.MessageReactionCount != nil:
+		return "message_reaction_count"
+	case u.ChatMember != nil:
+		return "chat_member"
+	case u.MyChatMember != nil:
+		return "my_chat_member"
+	}
+
+	return "other"
+}
+
+// countTelegramError counts a failed request by its Bot API error code, or as "network"
+// if it never got a response.
+func countTelegramError(err error) {
+	var tgErr *botapi.Error
+
+	if errors.As(err, &tgErr) {
+		telegramErrors.Inc(strconv.Itoa(tgErr.Code))
+	} else {
+		telegramErrors.Inc("network")
+	}
+}
@@ -0,0 +1,53 @@
+package api_test
+
+import (
+	"strings"
+	"testing"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/apitest"
+	"github.com/willmroliver/plathbot/src/metrics"
+)
+
+func TestMetrics(t *testing.T) {
+	h := apitest.New(t)
+	group, user := apitest.Group(-300), apitest.User(30, "carol")
+
+	h.Server.RegisterCommandAction("/metricsping", func(c *api.Context, m *botapi.Message, args ...string) {})
+	h.Server.RegisterCallbackAPI(api.NewCallbackAPI("📏 Metrics", "metricsmenu", &api.CallbackConfig{
+		Actions: map[string]api.CallbackAction{
+			"go": func(*api.Context, *botapi.CallbackQuery, *api.CallbackCmd) {},
+		},
+		PublicOptions: []map[string]string{{"🏁 Go": "go"}},
+	}))
+
+	h.SendText(group, user, "/metricsping")
+	h.SendText(group, user, "/metricsmenu")
+
+	if h.Message(group.ID, 1) == nil {
+		t.Fatalf("/metricsmenu - Expected the menu to be sent")
+	}
+
+	h.PressButton(user, group.ID, 1, "🏁 Go")
+
+	out := &strings.Builder{}
+	if err := metrics.Write(out); err != nil {
+		t.Fatalf("Write() - Unexpected error: %q", err.Error())
+	}
+
+	for _, line := range []string{
+		`plathbot_commands_total{command="/metricsping"} 1`,
+		`plathbot_command_duration_seconds_count{command="/metricsping"} 1`,
+		`plathbot_callbacks_total{path="metricsmenu"} 1`,
+		`plathbot_callbacks_total{path="metricsmenu/go"} 1`,
+		`plathbot_callback_duration_seconds_count{path="metricsmenu/go"} 1`,
+		`plathbot_updates_total{type="callback_query"}`,
+		`plathbot_db_query_duration_seconds_count{op="query"}`,
+		`plathbot_message_hooks_pending 0`,
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Write() - Expected %q; Got:\n%s", line, out.String())
+		}
+	}
+}
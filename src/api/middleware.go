@@ -0,0 +1,125 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// slowUpdate is how long an update may take before Timing logs it.
+const slowUpdate = time.Second * 2
+
+var middlewares = []Middleware{}
+
+// Handler handles a single update, with ctx already built from it.
+type Handler func(ctx *Context)
+
+// Middleware wraps a Handler, running code before and/or after calling next.
+// A middleware may also return without calling next to drop the update.
+type Middleware func(next Handler) Handler
+
+// Use registers middlewares to be applied to every Server, after the built-ins.
+func Use(mw ...Middleware) {
+	middlewares = append(middlewares, mw...)
+}
+
+// Use wraps update handling with the given middlewares. Earlier middlewares run first,
+// so each wraps everything registered after it. Middlewares must be added before Listen.
+func (s *Server) Use(mw ...Middleware) {
+	s.middlewares = append(s.middlewares, mw...)
+}
+
+// chain composes the middlewares around the conversations, message hooks and Context handlers.
+func (s *Server) chain() Handler {
+	var h Handler = func(ctx *Context) {
+		if s.DoConversation(ctx) || s.DoMessageHook(ctx.Update.Message) {
+			return
+		}
+
+		ctx.HandleUpdate()
+	}
+
+	for i := len(s.middlewares) - 1; i >= 0; i-- {
+		h = s.middlewares[i](h)
+	}
+
+	return h
+}
+
+// Recover stops a panicking handler from taking down the process, logging the stack.
+func Recover(next Handler) Handler {
+	return func(ctx *Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				ctx.Logf("Recovered panic handling update %d: %v\n%s", ctx.Update.UpdateID, r, debug.Stack())
+			}
+		}()
+
+		next(ctx)
+	}
+}
+
+// RequestID tags each update with a short random ID, which ctx.Logf includes.
+func RequestID(next Handler) Handler {
+	return func(ctx *Context) {
+		bytes := make([]byte, 4)
+		if _, err := rand.Read(bytes); err == nil {
+			ctx.RequestID = hex.EncodeToString(bytes)
+		}
+
+		next(ctx)
+	}
+}
+
+// Timing logs any update which takes longer than slowUpdate to handle.
+func Timing(next Handler) Handler {
+	return func(ctx *Context) {
+		start := time.Now()
+		next(ctx)
+
+		if dur := time.Since(start); dur > slowUpdate {
+			ctx.Logf("Slow update %d took %s", ctx.Update.UpdateID, dur)
+		}
+	}
+}
+
+// Enrich sets ctx.User, ctx.Chat and ctx.Message from the update before it is handled,
+// so that later middlewares can rely on them.
+func Enrich(next Handler) Handler {
+	return func(ctx *Context) {
+		u := ctx.Update
+
+		switch {
+		case u.Message != nil:
+			ctx.User, ctx.Chat, ctx.Message = u.Message.From, u.Message.Chat, u.Message
+		case u.CallbackQuery != nil:
+			ctx.User = u.CallbackQuery.From
+
+			if m := u.CallbackQuery.Message; m != nil {
+				ctx.Chat, ctx.Message = m.Chat, m
+			}
+		case u.MessageReaction != nil:
+			ctx.User, ctx.Chat, ctx.Message = u.MessageReaction.User, u.MessageReaction.Chat, u.MessageReaction
+		case u.InlineQuery != nil:
+			ctx.User = u.InlineQuery.From
+		case u.ChatMember != nil:
+			ctx.User, ctx.Chat = &u.ChatMember.From, &u.ChatMember.Chat
+		case u.MyChatMember != nil:
+			ctx.User, ctx.Chat = &u.MyChatMember.From, &u.MyChatMember.Chat
+		}
+
+		next(ctx)
+	}
+}
+
+// Logf logs with the update's request ID prefixed, if it has one.
+func (ctx *Context) Logf(format string, args ...any) {
+	if ctx.RequestID != "" {
+		format = fmt.Sprintf("[%s] %s", ctx.RequestID, format)
+	}
+
+	log.Printf(format, args...)
+}
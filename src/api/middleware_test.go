@@ -0,0 +1,57 @@
+package api_test
+
+import (
+	"testing"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+)
+
+func TestRecover(t *testing.T) {
+	ctx := &api.Context{Update: &botapi.Update{UpdateID: 1}}
+	after := false
+
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Errorf("Recover() - Expected panic to be recovered; Got %v", r)
+			}
+		}()
+
+		api.Recover(func(ctx *api.Context) {
+			panic("handler panic")
+		})(ctx)
+
+		after = true
+	}()
+
+	if !after {
+		t.Errorf("Recover() - Expected to return normally after a panic")
+	}
+}
+
+func TestEnrich(t *testing.T) {
+	user := &botapi.User{ID: 1}
+	chat := &botapi.Chat{ID: -100}
+	msg := &botapi.Message{Chat: chat}
+
+	ctx := &api.Context{Update: &botapi.Update{
+		CallbackQuery: &botapi.CallbackQuery{From: user, Message: msg},
+	}}
+
+	api.Enrich(func(ctx *api.Context) {
+		if ctx.User != user || ctx.Chat != chat || ctx.Message != msg {
+			t.Errorf("Enrich() - Expected user, chat & message from callback query; Got %+v, %+v, %+v", ctx.User, ctx.Chat, ctx.Message)
+		}
+	})(ctx)
+
+	ctx = &api.Context{Update: &botapi.Update{
+		CallbackQuery: &botapi.CallbackQuery{From: user},
+	}}
+
+	api.Enrich(func(ctx *api.Context) {
+		if ctx.User != user || ctx.Chat != nil {
+			t.Errorf("Enrich() - Expected user and no chat for inline callback query; Got %+v, %+v", ctx.User, ctx.Chat)
+		}
+	})(ctx)
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+var modules = map[string]*Module{}
+
+// Module is an optional feature, such as games or emoji rankings. Every module is compiled
+// in, and registers itself on init, but only those enabled by the MODULES setting are set up.
+type Module struct {
+	Name string
+	// Requires names the modules this one can't run without. It's only enabled with them.
+	Requires []string
+	// Setup registers the module's menus, commands and jobs with the server.
+	Setup func(*Server)
+	// Integrations are set up, by module name, only when that module is enabled too. They
+	// run once every enabled module is set up, so each can extend the other's menus.
+	Integrations map[string]func(*Server)
+}
+
+// RegisterModule adds a module which can be enabled by name.
+func RegisterModule(m *Module) {
+	if m.Integrations == nil {
+		m.Integrations = map[string]func(*Server){}
+	}
+
+	modules[m.Name] = m
+}
+
+// RegisterIntegration adds an integration between two modules, set up only when both are
+// enabled. It's for integrations which can't live in either module's package, as they
+// import both.
+func RegisterIntegration(module, with string, setup func(*Server)) {
+	m, ok := modules[module]
+	if !ok {
+		log.Panicf("Modules: integration with unknown module %q", module)
+	}
+
+	m.Integrations[with] = setup
+}
+
+// Modules returns the names of every registered module, in order.
+func Modules() []string {
+	names := make([]string, 0, len(modules))
+	for name := range modules {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// EnabledModules returns the names of the modules enabled by config, in order.
+//
+// MODULES lists them, comma or space separated, or is "all". Without it the API_<NAME>=1
+// flags used by earlier builds are read, and if none of those are set either, every module
+// is enabled. Modules missing something they require are left out.
+func EnabledModules() []string {
+	wanted := map[string]bool{}
+
+	if list := os.Getenv("MODULES"); list != "" {
+		for name := range strings.FieldsFuncSeq(list, func(r rune) bool { return r == ',' || r == ' ' }) {
+			wanted[strings.ToLower(name)] = true
+		}
+	} else {
+		for _, name := range Modules() {
+			if v := os.Getenv("API_" + strings.ToUpper(name)); v == "1" || v == "true" {
+				wanted[name] = true
+			}
+		}
+
+		if len(wanted) == 0 {
+			wanted["all"] = true
+		}
+	}
+
+	if wanted["all"] {
+		for name := range modules {
+			wanted[name] = true
+		}
+	}
+
+	for name := range wanted {
+		if _, ok := modules[name]; !ok && name != "all" {
+			log.Printf("Modules: Unknown module %q", name)
+		}
+	}
+
+	return resolveModules(wanted)
+}
+
+// resolveModules drops the wanted modules missing a requirement, then orders the rest so
+// that each comes after those it requires, and otherwise by name.
+func resolveModules(wanted map[string]bool) (enabled []string) {
+	ok := map[string]bool{}
+
+	var visit func(name string, path []string) bool
+	visit = func(name string, path []string) bool {
+		if done, seen := ok[name]; seen {
+			return done
+		}
+
+		m := modules[name]
+		if m == nil || !wanted[name] || slices.Contains(path, name) {
+			return false
+		}
+
+		for _, req := range m.Requires {
+			if !visit(req, append(path, name)) {
+				log.Printf("Modules: Not enabling %q, which requires %q", name, req)
+				ok[name] = false
+				return false
+			}
+		}
+
+		ok[name] = true
+		enabled = append(enabled, name)
+		return true
+	}
+
+	for _, name := range Modules() {
+		visit(name, nil)
+	}
+
+	return
+}
+
+// setupModules sets up the enabled modules, then the integrations between them.
+func (s *Server) setupModules(enabled []string) {
+	for _, name := range enabled {
+		log.Printf("Modules: Enabling %s\n", name)
+		modules[name].Setup(s)
+	}
+
+	for _, name := range enabled {
+		m := modules[name]
+
+		for _, with := range slices.Sorted(maps.Keys(m.Integrations)) {
+			if slices.Contains(enabled, with) {
+				m.Integrations[with](s)
+			}
+		}
+	}
+
+	s.modules = enabled
+}
+
+// EnabledModules returns the names of the modules set up on the server.
+func (s *Server) EnabledModules() []string {
+	return s.modules
+}
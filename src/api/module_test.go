@@ -0,0 +1,73 @@
+package api_test
+
+import (
+	"slices"
+	"testing"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/apitest"
+)
+
+func TestModules(t *testing.T) {
+	var setup []string
+
+	menu := func(name string) func(*api.Server) {
+		return func(s *api.Server) {
+			setup = append(setup, name)
+			s.RegisterCallbackAPI(api.NewCallbackAPI(name, name, &api.CallbackConfig{}))
+		}
+	}
+
+	noop := func(*api.Context, *botapi.CallbackQuery, *api.CallbackCmd) {}
+
+	t.Cleanup(func() { api.UnregisterModules("modbase", "modoff", "modorphan", "modextra") })
+
+	api.RegisterModule(&api.Module{Name: "modbase", Setup: menu("modbase")})
+	api.RegisterModule(&api.Module{Name: "modoff", Setup: menu("modoff")})
+	api.RegisterModule(&api.Module{Name: "modorphan", Setup: menu("modorphan"), Requires: []string{"modmissing"}})
+	api.RegisterModule(&api.Module{
+		Name:     "modextra",
+		Setup:    menu("modextra"),
+		Requires: []string{"modbase"},
+		Integrations: map[string]func(*api.Server){
+			"modoff": func(s *api.Server) { t.Errorf("Integrations - Expected modoff's to be skipped, as it's disabled") },
+		},
+	})
+	api.RegisterIntegration("modextra", "modbase", func(s *api.Server) {
+		s.FindCallbackAPI("modbase").Extend("➕ Extra", "extra", noop)
+	})
+
+	t.Setenv("MODULES", "modextra, modbase modorphan")
+
+	h := apitest.New(t)
+
+	if expected, got := []string{"modbase", "modextra"}, h.Server.EnabledModules(); !slices.Equal(got, expected) {
+		t.Errorf("EnabledModules() - Expected %v; Got %v", expected, got)
+	}
+
+	if expected := []string{"modbase", "modextra"}; !slices.Equal(setup, expected) {
+		t.Errorf("Setup - Expected %v, in order; Got %v", expected, setup)
+	}
+
+	if h.Server.FindCallbackAPI("modoff") != nil || h.Server.FindCallbackAPI("modorphan") != nil {
+		t.Errorf("FindCallbackAPI() - Expected disabled modules to have no menu")
+	}
+
+	if base := h.Server.FindCallbackAPI("modbase"); base == nil || base.Actions["extra"] == nil {
+		t.Errorf("Integrations - Expected modextra to extend the modbase menu")
+	}
+
+	t.Setenv("MODULES", "")
+	t.Setenv("API_MODOFF", "1")
+
+	if expected, got := []string{"modoff"}, api.EnabledModules(); !slices.Equal(got, expected) {
+		t.Errorf("EnabledModules() - Expected the API_ flags to be read; Got %v", got)
+	}
+
+	t.Setenv("API_MODOFF", "")
+
+	if got := api.EnabledModules(); !slices.Equal(got, []string{"modbase", "modextra", "modoff"}) {
+		t.Errorf("EnabledModules() - Expected every module with its requirements; Got %v", got)
+	}
+}
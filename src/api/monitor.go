@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/willmroliver/plathbot/src/metrics"
+)
+
+// serveMonitor serves MonitorHandler on MONITOR_ADDR, e.g. ":9090", returning a Stopper
+// for the server, or nil if it isn't set. It's kept apart from the webhook, which has to
+// be public.
+func (s *Server) serveMonitor() Stopper {
+	addr := os.Getenv("MONITOR_ADDR")
+	if addr == "" {
+		return nil
+	}
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.MonitorHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Monitor: server error: %q", err.Error())
+		}
+	}()
+
+	log.Printf("Server: Serving monitoring endpoints on %s\n", addr)
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Monitor: error shutting down server: %q", err.Error())
+		}
+	}
+}
+
+// MonitorHandler serves the monitoring endpoints:
+//
+//	/metrics	Metrics in the Prometheus text format
+//	/healthz	Whether the process is healthy, failing if it should be restarted
+//	/readyz	Whether the bot is up and can reach its database and Telegram
+//	/status	What the bot is running, as JSON. Only served if MONITOR_TOKEN is set,
+//		which must be sent as a bearer token
+func (s *Server) MonitorHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("GET /readyz", s.readyz)
+
+	if token := os.Getenv("MONITOR_TOKEN"); token != "" {
+		mux.HandleFunc("GET /status", requireToken(token, s.status))
+	}
+
+	return mux
+}
@@ -0,0 +1,325 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"reflect"
+	"sync"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram's documented budgets for outgoing messages. Short bursts over the
+// per-chat rate are tolerated, so chats get a little headroom.
+const (
+	globalInterval = time.Second / 30
+	globalBurst    = 30
+	chatInterval   = time.Second
+	chatBurst      = 3
+	groupInterval  = time.Minute / 20
+	groupBurst     = 20
+
+	maxSendAttempts = 5
+)
+
+var (
+	outboxes    = map[*botapi.BotAPI]*Outbox{}
+	outboxesMux = &sync.Mutex{}
+)
+
+// Delivery is the outcome of a message sent through an Outbox.
+type Delivery struct {
+	Message *botapi.Message
+	Err     error
+}
+
+// Outbox schedules everything a bot sends so that Telegram's global, per-chat and
+// per-group rate limits are respected, retrying automatically on a 429 retry_after.
+//
+// Messages to the same chat are delivered one at a time, in the order they were queued.
+type Outbox struct {
+	bot     *botapi.BotAPI
+	mu      sync.Mutex
+	global  *limiter
+	chats   map[int64]*limiter
+	groups  map[int64]*limiter
+	queues  map[int64][]*outgoing
+	pending sync.WaitGroup
+
+	unlimited bool
+}
+
+type outgoing struct {
+	c    botapi.Chattable
+	done chan Delivery
+}
+
+// OutboxFor returns the Outbox shared by all senders using bot.
+func OutboxFor(bot *botapi.BotAPI) *Outbox {
+	outboxesMux.Lock()
+	defer outboxesMux.Unlock()
+
+	if o, ok := outboxes[bot]; ok {
+		return o
+	}
+
+	o := &Outbox{
+		bot:    bot,
+		global: newLimiter(globalInterval, globalBurst),
+		chats:  map[int64]*limiter{},
+		groups: map[int64]*limiter{},
+		queues: map[int64][]*outgoing{},
+	}
+
+	outboxes[bot] = o
+	return o
+}
+
+// Send queues c for delivery. The returned channel receives the result once c has been
+// sent or has failed for good; callers not interested in the result can ignore it.
+func (o *Outbox) Send(c botapi.Chattable) <-chan Delivery {
+	out := &outgoing{c, make(chan Delivery, 1)}
+	chatID := chatIDOf(c)
+
+	o.pending.Add(1)
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	queue, running := o.queues[chatID]
+	o.queues[chatID] = append(queue, out)
+
+	if !running {
+		go o.work(chatID)
+	}
+
+	return out.done
+}
+
+// SetRateLimited turns the rate limits on or off. They're on by default, and only worth
+// turning off when talking to a fake Bot API in tests.
+func (o *Outbox) SetRateLimited(on bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.unlimited = !on
+}
+
+// Flush blocks until everything queued so far has been delivered.
+func (o *Outbox) Flush() {
+	o.pending.Wait()
+}
+
+// work delivers the queue for chatID until it is empty.
+func (o *Outbox) work(chatID int64) {
+	for {
+		o.mu.Lock()
+		queue := o.queues[chatID]
+
+		if len(queue) == 0 {
+			delete(o.queues, chatID)
+			o.mu.Unlock()
+			return
+		}
+
+		out := queue[0]
+		queue[0] = nil
+		o.queues[chatID] = queue[1:]
+		o.mu.Unlock()
+
+		m, err := o.deliver(chatID, out.c)
+		out.done <- Delivery{m, err}
+		o.pending.Done()
+	}
+}
+
+func (o *Outbox) deliver(chatID int64, c botapi.Chattable) (*botapi.Message, error) {
+	for attempt := 1; ; attempt++ {
+		if wait := time.Until(o.reserve(chatID, isNewMessage(c))); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		m, err := o.send(c)
+
+		retry, limited := retryAfter(err)
+		if !limited || attempt == maxSendAttempts {
+			if err != nil {
+				countTelegramError(err)
+			}
+
+			return &m, err
+		}
+
+		log.Printf("Outbox: rate limited in chat %d, retrying in %s\n", chatID, retry)
+		time.Sleep(retry)
+	}
+}
+
+func (o *Outbox) send(c botapi.Chattable) (m botapi.Message, err error) {
+	switch c.(type) {
+	case botapi.DeleteMessageConfig, *botapi.DeleteMessageConfig, botapi.CallbackConfig, *botapi.CallbackConfig:
+		// These return true rather than a Message
+		_, err = o.bot.Request(c)
+	default:
+		m, err = o.bot.Send(c)
+	}
+
+	return
+}
+
+// reserve books the earliest slot at which a request to chatID fits every budget.
+// Only new messages count against a group's budget; edits, deletes and the like
+// are held to the global and per-chat rates alone.
+func (o *Outbox) reserve(chatID int64, newMessage bool) time.Time {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := time.Now()
+	at := now
+
+	if o.unlimited {
+		return now
+	}
+
+	limiters := []*limiter{o.global}
+
+	if chatID != 0 {
+		limiters = append(limiters, o.limiter(o.chats, chatID, chatInterval, chatBurst, now))
+	}
+
+	if chatID < 0 && newMessage {
+		limiters = append(limiters, o.limiter(o.groups, chatID, groupInterval, groupBurst, now))
+	}
+
+	for _, l := range limiters {
+		at = l.earliest(at)
+	}
+
+	for _, l := range limiters {
+		l.take(at)
+	}
+
+	return at
+}
+
+// limiter returns the limiter for id, first dropping any idle limiters once the map grows.
+func (o *Outbox) limiter(m map[int64]*limiter, id int64, interval time.Duration, burst int, now time.Time) *limiter {
+	if l, ok := m[id]; ok {
+		return l
+	}
+
+	if len(m) > 1024 {
+		for key, l := range m {
+			if l.tat.Before(now) {
+				delete(m, key)
+			}
+		}
+	}
+
+	l := newLimiter(interval, burst)
+	m[id] = l
+	return l
+}
+
+// limiter is a GCRA rate limiter: one send per interval on average, allowing bursts.
+type limiter struct {
+	interval  time.Duration
+	tolerance time.Duration
+	tat       time.Time
+}
+
+func newLimiter(interval time.Duration, burst int) *limiter {
+	return &limiter{
+		interval:  interval,
+		tolerance: interval * time.Duration(burst-1),
+	}
+}
+
+// earliest returns the earliest time at or after t that a send is within budget.
+func (l *limiter) earliest(t time.Time) time.Time {
+	if at := l.tat.Add(-l.tolerance); at.After(t) {
+		return at
+	}
+
+	return t
+}
+
+// take spends budget for a send at t.
+func (l *limiter) take(t time.Time) {
+	if t.After(l.tat) {
+		l.tat = t
+	}
+
+	l.tat = l.tat.Add(l.interval)
+}
+
+func retryAfter(err error) (time.Duration, bool) {
+	var tgErr *botapi.Error
+
+	if !errors.As(err, &tgErr) || tgErr.Code != 429 {
+		return 0, false
+	}
+
+	if tgErr.RetryAfter < 1 {
+		return time.Second, true
+	}
+
+	return time.Duration(tgErr.RetryAfter) * time.Second, true
+}
+
+// newMessages are the configs that post a new message to a chat.
+var newMessages = map[reflect.Type]bool{
+	reflect.TypeOf(botapi.MessageConfig{}):     true,
+	reflect.TypeOf(botapi.ForwardConfig{}):     true,
+	reflect.TypeOf(botapi.CopyMessageConfig{}): true,
+	reflect.TypeOf(botapi.PhotoConfig{}):       true,
+	reflect.TypeOf(botapi.AudioConfig{}):       true,
+	reflect.TypeOf(botapi.DocumentConfig{}):    true,
+	reflect.TypeOf(botapi.StickerConfig{}):     true,
+	reflect.TypeOf(botapi.VideoConfig{}):       true,
+	reflect.TypeOf(botapi.AnimationConfig{}):   true,
+	reflect.TypeOf(botapi.VideoNoteConfig{}):   true,
+	reflect.TypeOf(botapi.VoiceConfig{}):       true,
+	reflect.TypeOf(botapi.LocationConfig{}):    true,
+	reflect.TypeOf(botapi.VenueConfig{}):       true,
+	reflect.TypeOf(botapi.ContactConfig{}):     true,
+	reflect.TypeOf(botapi.SendPollConfig{}):    true,
+	reflect.TypeOf(botapi.GameConfig{}):        true,
+	reflect.TypeOf(botapi.InvoiceConfig{}):     true,
+	reflect.TypeOf(botapi.MediaGroupConfig{}):  true,
+	reflect.TypeOf(botapi.DiceConfig{}):        true,
+}
+
+// isNewMessage reports whether c posts a new message, rather than acting on one
+// already sent.
+func isNewMessage(c botapi.Chattable) bool {
+	t := reflect.TypeOf(c)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return newMessages[t]
+}
+
+// chatIDOf finds the ChatID a config is addressed to, or 0 for configs without one,
+// such as edits of inline messages.
+func chatIDOf(c botapi.Chattable) int64 {
+	v := reflect.ValueOf(c)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+
+	return findChatID(v)
+}
+
+func findChatID(v reflect.Value) int64 {
+	if v.Kind() != reflect.Struct {
+		return 0
+	}
+
+	if f := v.FieldByName("ChatID"); f.IsValid() && f.Kind() == reflect.Int64 {
+		return f.Int()
+	}
+
+	return 0
+}
@@ -0,0 +1,37 @@
+package api_test
+
+import (
+	"testing"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+)
+
+func TestIsNewMessage(t *testing.T) {
+	text := botapi.NewMessage(-100, "hi")
+	sends := []botapi.Chattable{
+		text,
+		&text,
+		botapi.NewPhoto(-100, botapi.FileID("photo")),
+		botapi.NewDice(-100),
+	}
+
+	for _, c := range sends {
+		if !api.IsNewMessage(c) {
+			t.Errorf("IsNewMessage() - Expected true for %T; Got false", c)
+		}
+	}
+
+	others := []botapi.Chattable{
+		botapi.NewEditMessageText(-100, 1, "hi"),
+		botapi.NewEditMessageReplyMarkup(-100, 1, botapi.NewInlineKeyboardMarkup()),
+		botapi.NewDeleteMessage(-100, 1),
+		botapi.NewCallback("id", ""),
+	}
+
+	for _, c := range others {
+		if api.IsNewMessage(c) {
+			t.Errorf("IsNewMessage() - Expected false for %T; Got true", c)
+		}
+	}
+}
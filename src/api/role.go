@@ -0,0 +1,129 @@
+package api
+
+import (
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/willmroliver/plathbot/src/repo"
+)
+
+// Role is a level of access. Owners, admins and moderators are ranked, each holding every
+// role below it, while custom roles are granted individually and held implicitly by admins.
+//
+//   - Owners are set bot-wide by OWNER_IDS.
+//   - Admins are a chat's Telegram administrators, or users granted the role by an owner.
+//   - Moderators, and custom roles, are granted per chat by admins, or bot-wide from a
+//     private chat by owners.
+type Role string
+
+const (
+	RoleMember    Role = ""
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+	RoleOwner     Role = "owner"
+)
+
+var (
+	roleRanks = map[Role]int{
+		RoleModerator: 1,
+		RoleAdmin:     2,
+		RoleOwner:     3,
+	}
+
+	roleName = regexp.MustCompile(`^[a-z][a-z0-9_]{1,15}$`)
+
+	owners     map[int64]bool
+	ownersOnce sync.Once
+)
+
+// ParseRole reads a role name, as typed by a user.
+func ParseRole(s string) (Role, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !roleName.MatchString(s) {
+		return RoleMember, false
+	}
+
+	return Role(s), true
+}
+
+// Rank orders the built-in roles. Members and custom roles rank 0.
+func (r Role) Rank() int {
+	return roleRanks[r]
+}
+
+// Custom reports whether r is a role other than the built-in ones.
+func (r Role) Custom() bool {
+	_, ok := roleRanks[r]
+	return r != RoleMember && !ok
+}
+
+// IsOwner reports whether the user is listed in OWNER_IDS.
+func IsOwner(userID int64) bool {
+	ownersOnce.Do(func() {
+		owners = map[int64]bool{}
+
+		for v := range strings.SplitSeq(os.Getenv("OWNER_IDS"), ",") {
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+
+			if id, err := strconv.ParseInt(v, 10, 64); err == nil {
+				owners[id] = true
+			} else {
+				log.Printf("Roles: Invalid owner ID %q in OWNER_IDS", v)
+			}
+		}
+	})
+
+	return owners[userID]
+}
+
+// HasRole reports whether the current user holds role in the current chat.
+func (ctx *Context) HasRole(role Role) bool {
+	if role == RoleMember {
+		return true
+	}
+
+	if ctx.User == nil {
+		return false
+	}
+
+	if IsOwner(ctx.User.ID) {
+		return true
+	}
+
+	if role == RoleOwner {
+		return false
+	}
+
+	need := role.Rank()
+	if role.Custom() {
+		need = RoleAdmin.Rank()
+	}
+
+	var chatID int64
+	if ctx.Chat != nil && ctx.Chat.Type != "private" {
+		chatID = ctx.Chat.ID
+	}
+
+	for _, r := range repo.NewUserRoleRepo(ctx.Server.DB).Roles(ctx.User.ID, chatID) {
+		if held := Role(r.Role); held == role || held.Rank() >= need {
+			return true
+		}
+	}
+
+	return ctx.isChatAdmin()
+}
+
+// isChatAdmin reports whether the current user is an administrator of the current group.
+func (ctx *Context) isChatAdmin() bool {
+	if ctx.Chat == nil || ctx.Chat.Type == "private" {
+		return false
+	}
+
+	return ctx.Server.IsChatAdmin(ctx.Chat.ID, ctx.User.ID)
+}
@@ -0,0 +1,45 @@
+package api_test
+
+import (
+	"testing"
+
+	"github.com/willmroliver/plathbot/src/api"
+)
+
+func TestParseRole(t *testing.T) {
+	valid := map[string]api.Role{
+		"moderator":  api.RoleModerator,
+		" Admin ":    api.RoleAdmin,
+		"raid_lead2": api.Role("raid_lead2"),
+	}
+
+	for s, expected := range valid {
+		if role, ok := api.ParseRole(s); !ok || role != expected {
+			t.Errorf("ParseRole() - Expected ok, %q; Got %v, %q", expected, ok, role)
+		}
+	}
+
+	for _, s := range []string{"", "a", "2fast", "raid lead", "an_unreasonably_long_role"} {
+		if role, ok := api.ParseRole(s); ok {
+			t.Errorf("ParseRole() - Expected !ok for %q; Got %q", s, role)
+		}
+	}
+}
+
+func TestRoleRank(t *testing.T) {
+	ranked := []api.Role{api.RoleMember, api.RoleModerator, api.RoleAdmin, api.RoleOwner}
+
+	for i := 1; i < len(ranked); i++ {
+		if ranked[i].Rank() <= ranked[i-1].Rank() {
+			t.Errorf("Rank() - Expected %q to outrank %q", ranked[i], ranked[i-1])
+		}
+
+		if ranked[i].Custom() {
+			t.Errorf("Custom() - Expected %q to be built-in", ranked[i])
+		}
+	}
+
+	if custom := api.Role("raider"); !custom.Custom() || custom.Rank() != 0 {
+		t.Errorf("Custom() - Expected %q to be custom with rank 0; Got %v, %d", custom, custom.Custom(), custom.Rank())
+	}
+}
@@ -0,0 +1,623 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/willmroliver/plathbot/src/ds"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/repo"
+	"github.com/willmroliver/plathbot/src/util"
+)
+
+// schedulerIdle is how long the scheduler sleeps with nothing queued, unless woken.
+const schedulerIdle = time.Hour
+
+// jobHandler is a named job, with a way to restore its data once saved.
+type jobHandler struct {
+	run    func(context.Context, *Server, any) error
+	decode func([]byte) (any, error)
+}
+
+// RegisterJobHandler names a job so that it can be scheduled, and resumed after a restart.
+// Its data is saved as JSON, so T should be a serialisable type, or a pointer to one.
+// Modules register theirs from Setup, so that the jobs of those turned off aren't run,
+// and before the scheduler starts, so that handlers exist as saved jobs are loaded. ctx
+// is cancelled when the server shuts down.
+func RegisterJobHandler[T any](sc *Scheduler, name string, handler func(ctx context.Context, s *Server, data T) error) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	sc.handlers[name] = jobHandler{
+		run: func(ctx context.Context, s *Server, data any) error {
+			return handler(ctx, s, data.(T))
+		},
+		decode: func(b []byte) (any, error) {
+			var data T
+			err := json.Unmarshal(b, &data)
+			return data, err
+		},
+	}
+}
+
+// Schedule decides when a job runs.
+type Schedule interface {
+	// Next returns when to run after t. A job is done once that's no later than t.
+	Next(t time.Time) time.Time
+	// String is how the schedule is saved, as read by ParseSchedule.
+	String() string
+}
+
+type onceSchedule time.Time
+
+// Once runs a job at a time, or as soon as it's scheduled if that's passed.
+func Once(at time.Time) Schedule {
+	return onceSchedule(at)
+}
+
+func (o onceSchedule) Next(time.Time) time.Time {
+	return time.Time(o)
+}
+
+func (o onceSchedule) String() string {
+	return "@once " + time.Time(o).Format(time.RFC3339)
+}
+
+type everySchedule time.Duration
+
+// Every runs a job repeatedly, an interval after each run starts.
+func Every(d time.Duration) Schedule {
+	return everySchedule(d)
+}
+
+func (e everySchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+func (e everySchedule) String() string {
+	return "@every " + time.Duration(e).String()
+}
+
+type cronSchedule struct {
+	*util.Cron
+}
+
+// Cron runs a job at the times a cron expression matches, in local time. See util.Cron.
+func Cron(spec string) (Schedule, error) {
+	c, err := util.ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return cronSchedule{c}, nil
+}
+
+// ParseSchedule reads a schedule: "@once" and an RFC 3339 time, "@every" and a duration,
+// or a cron expression.
+func ParseSchedule(spec string) (Schedule, error) {
+	if at, ok := strings.CutPrefix(spec, "@once "); ok {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return nil, err
+		}
+
+		return Once(t), nil
+	}
+
+	if every, ok := strings.CutPrefix(spec, "@every "); ok {
+		d, err := time.ParseDuration(every)
+		if err != nil {
+			return nil, err
+		}
+
+		if d <= 0 {
+			return nil, fmt.Errorf("schedule %q: interval must be positive", spec)
+		}
+
+		return Every(d), nil
+	}
+
+	return Cron(spec)
+}
+
+// JobOption configures a scheduled job.
+type JobOption func(*model.ScheduledJob)
+
+// JobJitter delays each run by up to d, so that jobs on the same schedule don't all
+// start at once.
+func JobJitter(d time.Duration) JobOption {
+	return func(j *model.ScheduledJob) {
+		j.Jitter = d
+	}
+}
+
+// JobOverlap lets a run start while the last is still going. Otherwise it's skipped.
+func JobOverlap() JobOption {
+	return func(j *model.ScheduledJob) {
+		j.Overlap = true
+	}
+}
+
+// JobInfo is a job's state, as listed by Jobs.
+type JobInfo struct {
+	model.ScheduledJob
+	Running bool `json:"running"`
+}
+
+// Scheduler runs jobs at the times their schedules give, in order of when they're next
+// due. Jobs are saved, so that their schedules, whether they're paused and how they last
+// went outlive a restart.
+type Scheduler struct {
+	server *Server
+
+	mu       sync.Mutex
+	handlers map[string]jobHandler
+	jobs     map[string]*scheduledJob
+	queue    *ds.PriorityQueue[*queuedJob]
+	started  bool
+	ctx      context.Context
+	wake     chan struct{}
+	runs     sync.WaitGroup
+}
+
+type scheduledJob struct {
+	saved    *model.ScheduledJob
+	schedule Schedule
+	handler  jobHandler
+	data     any
+	health   *util.Job
+
+	// seq is bumped whenever the job's queued run is replaced or withdrawn, so that stale
+	// entries can be dropped as they reach the front of the queue
+	seq     uint64
+	running int
+}
+
+type queuedJob struct {
+	at  time.Time
+	job *scheduledJob
+	seq uint64
+}
+
+func NewScheduler(s *Server) *Scheduler {
+	return &Scheduler{
+		server:   s,
+		handlers: map[string]jobHandler{},
+		jobs:     map[string]*scheduledJob{},
+		queue: ds.NewPriorityQueue(func(a, b *queuedJob) int {
+			// Soonest first
+			return b.at.Compare(a.at)
+		}),
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// Schedule runs the handler registered as handler on schedule, with data, replacing any
+// job of the same name. Jobs scheduled before the scheduler starts are merged with those
+// saved, so that jobs set up on each start keep whether they're paused and their history.
+func (sc *Scheduler) Schedule(name, handler string, schedule Schedule, data any, opts ...JobOption) error {
+	sc.mu.Lock()
+	h, ok := sc.handlers[handler]
+	sc.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("scheduler: no job handler registered as %q", handler)
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("scheduler: encoding %q data: %w", name, err)
+	}
+
+	// Decoded from what's saved, so that data always has the handler's type
+	if data, err = h.decode(b); err != nil {
+		return fmt.Errorf("scheduler: decoding %q data: %w", name, err)
+	}
+
+	saved := &model.ScheduledJob{
+		Name:     name,
+		Handler:  handler,
+		Schedule: schedule.String(),
+		Data:     b,
+	}
+
+	for _, opt := range opts {
+		opt(saved)
+	}
+
+	job := &scheduledJob{saved: saved, schedule: schedule, handler: h, data: data}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if old, ok := sc.jobs[name]; ok {
+		old.seq++
+		old.health.Stop()
+
+		// Keep the history of the job being replaced
+		saved.LastRun, saved.LastDuration, saved.LastError = old.saved.LastRun, old.saved.LastDuration, old.saved.LastError
+		saved.Runs, saved.Failures, saved.Skips = old.saved.Runs, old.saved.Failures, old.saved.Skips
+		saved.CreatedAt = old.saved.CreatedAt
+	}
+
+	sc.jobs[name] = job
+	saved.NextRun = sc.first(job, time.Now())
+
+	if sc.started {
+		sc.enqueue(job)
+		sc.save(job)
+	}
+
+	return nil
+}
+
+// Pause stops a job running until it's resumed.
+func (sc *Scheduler) Pause(name string) error {
+	return sc.update(name, func(job *scheduledJob) {
+		job.saved.Paused = true
+		job.seq++
+		job.health.Stop()
+	})
+}
+
+// Resume runs a paused job again, from the next time its schedule gives.
+func (sc *Scheduler) Resume(name string) error {
+	return sc.update(name, func(job *scheduledJob) {
+		if !job.saved.Paused {
+			return
+		}
+
+		job.saved.Paused = false
+		job.saved.NextRun = sc.first(job, time.Now())
+		sc.enqueue(job)
+		job.health.Start()
+	})
+}
+
+// RunNow runs a job straight away, then on its schedule as before, even if it's paused.
+func (sc *Scheduler) RunNow(name string) error {
+	return sc.update(name, func(job *scheduledJob) {
+		job.seq++
+		sc.queue.Push(&queuedJob{time.Now(), job, job.seq})
+		sc.notify()
+	})
+}
+
+// Cancel removes a job, letting any run in progress finish.
+func (sc *Scheduler) Cancel(name string) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	job, ok := sc.jobs[name]
+	if !ok {
+		return fmt.Errorf("scheduler: no job %q", name)
+	}
+
+	job.seq++
+	job.health.Stop()
+	delete(sc.jobs, name)
+
+	if sc.server.DB != nil {
+		return repo.NewScheduledJobRepo(sc.server.DB).Remove(name)
+	}
+
+	return nil
+}
+
+// Jobs returns the state of every job, by name.
+func (sc *Scheduler) Jobs() []JobInfo {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	res := make([]JobInfo, 0, len(sc.jobs))
+	for _, job := range sc.jobs {
+		res = append(res, JobInfo{*job.saved, job.running > 0})
+	}
+
+	slices.SortFunc(res, func(a, b JobInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return res
+}
+
+// Start loads saved jobs and runs jobs as they come due, until the returned Stopper is
+// called, which waits for any runs in progress.
+func (sc *Scheduler) Start() Stopper {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	sc.mu.Lock()
+	sc.ctx = ctx
+	sc.load()
+	sc.started = true
+	sc.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		sc.loop(ctx)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		sc.runs.Wait()
+
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+
+		sc.started = false
+		for _, job := range sc.jobs {
+			job.health.Stop()
+			job.health = nil
+		}
+	}
+}
+
+// load merges saved jobs with those scheduled so far, then queues them all. Saved jobs
+// whose handler isn't registered, as when their module is off, are left as they are.
+func (sc *Scheduler) load() {
+	if sc.server.DB != nil {
+		for _, saved := range repo.NewScheduledJobRepo(sc.server.DB).All() {
+			if job, ok := sc.jobs[saved.Name]; ok {
+				merge(job, saved)
+				continue
+			}
+
+			if job := sc.restore(saved); job != nil {
+				sc.jobs[saved.Name] = job
+			}
+		}
+	}
+
+	now := time.Now()
+
+	for _, job := range sc.jobs {
+		if job.saved.NextRun.IsZero() {
+			job.saved.NextRun = sc.first(job, now)
+		}
+
+		sc.enqueue(job)
+		sc.save(job)
+	}
+}
+
+// merge carries what was saved of a job over to the same job scheduled again.
+func merge(job *scheduledJob, saved *model.ScheduledJob) {
+	s := job.saved
+
+	s.Paused = saved.Paused
+	s.LastRun, s.LastDuration, s.LastError = saved.LastRun, saved.LastDuration, saved.LastError
+	s.Runs, s.Failures, s.Skips = saved.Runs, saved.Failures, saved.Skips
+	s.CreatedAt = saved.CreatedAt
+
+	if saved.Schedule == s.Schedule && !saved.NextRun.IsZero() {
+		s.NextRun = saved.NextRun
+	}
+}
+
+func (sc *Scheduler) restore(saved *model.ScheduledJob) *scheduledJob {
+	h, ok := sc.handlers[saved.Handler]
+	if !ok {
+		log.Printf("Scheduler: no handler %q for job %q, leaving it", saved.Handler, saved.Name)
+		return nil
+	}
+
+	schedule, err := ParseSchedule(saved.Schedule)
+	if err != nil {
+		log.Printf("Scheduler: error reading job %q schedule: %q", saved.Name, err.Error())
+		return nil
+	}
+
+	data, err := h.decode(saved.Data)
+	if err != nil {
+		log.Printf("Scheduler: error reading job %q data: %q", saved.Name, err.Error())
+		return nil
+	}
+
+	return &scheduledJob{saved: saved, schedule: schedule, handler: h, data: data}
+}
+
+func (sc *Scheduler) loop(ctx context.Context) {
+	timer := time.NewTimer(schedulerIdle)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(sc.runDue(ctx))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-sc.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// runDue starts every job that's due, returning how long until the next is.
+func (sc *Scheduler) runDue(ctx context.Context) time.Duration {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	now := time.Now()
+
+	for sc.queue.Len() > 0 {
+		next := sc.queue.Top()
+
+		if next.seq != next.job.seq {
+			sc.queue.Pop()
+			continue
+		}
+
+		if next.at.After(now) {
+			return next.at.Sub(now)
+		}
+
+		sc.queue.Pop()
+
+		job := next.job
+
+		if job.running > 0 && !job.saved.Overlap {
+			job.saved.Skips++
+			log.Printf("Scheduler: skipped %q, as its last run hasn't finished", job.saved.Name)
+		} else {
+			job.running++
+			sc.runs.Add(1)
+
+			go sc.run(ctx, job)
+		}
+
+		if after := job.schedule.Next(now); !job.saved.Paused && after.After(now) {
+			job.saved.NextRun = sc.jitter(job, after)
+			sc.enqueue(job)
+		} else {
+			job.saved.NextRun = time.Time{}
+		}
+
+		sc.save(job)
+	}
+
+	return schedulerIdle
+}
+
+func (sc *Scheduler) run(ctx context.Context, job *scheduledJob) {
+	defer sc.runs.Done()
+
+	start := time.Now()
+	err := sc.call(ctx, job)
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	job.running--
+	job.health.Ran()
+
+	s := job.saved
+	s.LastRun, s.LastDuration, s.LastError = start, time.Since(start), ""
+	s.Runs++
+
+	if err != nil {
+		s.Failures++
+		s.LastError = err.Error()
+		log.Printf("Scheduler: job %q failed: %q", s.Name, s.LastError)
+	}
+
+	if sc.jobs[s.Name] != job {
+		// Cancelled or replaced while running
+		return
+	}
+
+	if s.NextRun.IsZero() && !s.Paused && job.running == 0 {
+		// Its schedule's done
+		delete(sc.jobs, s.Name)
+		job.health.Stop()
+
+		if sc.server.DB != nil {
+			repo.NewScheduledJobRepo(sc.server.DB).Remove(s.Name)
+		}
+
+		return
+	}
+
+	sc.save(job)
+}
+
+// call runs the job's handler, recovering any panic as an error.
+func (sc *Scheduler) call(ctx context.Context, job *scheduledJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return job.handler.run(ctx, sc.server, job.data)
+}
+
+// first returns when a job should first run, from now.
+func (sc *Scheduler) first(job *scheduledJob, now time.Time) time.Time {
+	if job.saved.Paused {
+		return time.Time{}
+	}
+
+	at := job.schedule.Next(now)
+	if at.IsZero() {
+		return at
+	}
+
+	return sc.jitter(job, at)
+}
+
+func (sc *Scheduler) jitter(job *scheduledJob, at time.Time) time.Time {
+	if j := job.saved.Jitter; j > 0 {
+		at = at.Add(time.Duration(rand.Int63n(int64(j))))
+	}
+
+	return at
+}
+
+// enqueue queues the job's next run, replacing any queued before, and registers it for
+// the health checks if it repeats.
+func (sc *Scheduler) enqueue(job *scheduledJob) {
+	at := job.saved.NextRun
+	if job.saved.Paused || at.IsZero() {
+		return
+	}
+
+	job.seq++
+	sc.queue.Push(&queuedJob{at, job, job.seq})
+
+	if every := interval(job.schedule, at); job.health == nil && every > 0 {
+		job.health = util.NewJob(job.saved.Name, every)
+		job.health.Start()
+	}
+
+	sc.notify()
+}
+
+// notify wakes the loop to check what's next.
+func (sc *Scheduler) notify() {
+	select {
+	case sc.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (sc *Scheduler) update(name string, change func(*scheduledJob)) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	job, ok := sc.jobs[name]
+	if !ok {
+		return fmt.Errorf("scheduler: no job %q", name)
+	}
+
+	change(job)
+
+	if sc.started {
+		sc.save(job)
+	}
+
+	return nil
+}
+
+func (sc *Scheduler) save(job *scheduledJob) {
+	if sc.server.DB != nil {
+		repo.NewScheduledJobRepo(sc.server.DB).Put(job.saved)
+	}
+}
+
+// interval estimates how often a schedule repeats from at, or returns 0 if it doesn't.
+func interval(schedule Schedule, at time.Time) time.Duration {
+	if next := schedule.Next(at); next.After(at) {
+		return next.Sub(at)
+	}
+
+	return 0
+}
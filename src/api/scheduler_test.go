@@ -0,0 +1,230 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/apitest"
+)
+
+type testJobData struct {
+	N int `json:"n"`
+}
+
+func TestParseSchedule(t *testing.T) {
+	for _, spec := range []string{"* * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "* * * 13 *", "@every -1s", "@once tomorrow"} {
+		if _, err := api.ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) - Expected an error; Got nil", spec)
+		}
+	}
+
+	at := func(s string) time.Time {
+		res, _ := time.Parse(time.DateTime, s)
+		return res
+	}
+
+	cases := []struct {
+		spec, from, want string
+	}{
+		{"*/15 * * * *", "2024-06-01 10:07:30", "2024-06-01 10:15:00"},
+		{"0 9 * * 1-5", "2024-06-01 12:00:00", "2024-06-03 09:00:00"},
+		{"0 0 13 * 5", "2024-06-01 00:00:00", "2024-06-07 00:00:00"},
+		{"30 2 1 1,7 *", "2024-06-01 00:00:00", "2024-07-01 02:30:00"},
+		{"0 0 * * 7", "2024-06-03 00:00:00", "2024-06-09 00:00:00"},
+		{"@every 1h30m0s", "2024-06-01 10:07:30", "2024-06-01 11:37:30"},
+		{"@once 2024-06-02T00:00:00Z", "2024-06-01 00:00:00", "2024-06-02 00:00:00"},
+	}
+
+	for _, c := range cases {
+		s, err := api.ParseSchedule(c.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q) - Unexpected error: %q", c.spec, err.Error())
+			continue
+		}
+
+		if got := s.Next(at(c.from)); !got.Equal(at(c.want)) {
+			t.Errorf("Next(%s) - Expected %q from %q; Got %s", c.from, c.want, c.spec, got)
+		}
+
+		if s.String() != c.spec {
+			t.Errorf("String() - Expected %q; Got %q", c.spec, s.String())
+		}
+	}
+
+	if s, _ := api.ParseSchedule("0 0 30 2 *"); !s.Next(at("2024-06-01 00:00:00")).IsZero() {
+		t.Errorf("Next() - Expected no time for a date that never comes; Got %s", s.Next(at("2024-06-01 00:00:00")))
+	}
+}
+
+// eventually waits for cond, failing the test if it isn't met within a couple of seconds.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+
+	t.Fatalf("Scheduler - Expected %s; Got timeout", what)
+}
+
+func job(sc *api.Scheduler, name string) (api.JobInfo, bool) {
+	for _, j := range sc.Jobs() {
+		if j.Name == name {
+			return j, true
+		}
+	}
+
+	return api.JobInfo{}, false
+}
+
+func TestScheduler(t *testing.T) {
+	ran := make(chan int, 1)
+	ticks := atomic.Int64{}
+	block := make(chan struct{})
+
+	h := apitest.New(t)
+	sc := h.Server.Scheduler
+
+	register := func(sc *api.Scheduler) {
+		api.RegisterJobHandler(sc, "test_once", func(_ context.Context, _ *api.Server, data testJobData) error {
+			ran <- data.N
+			return nil
+		})
+		api.RegisterJobHandler(sc, "test_tick", func(context.Context, *api.Server, struct{}) error {
+			ticks.Add(1)
+			return nil
+		})
+		api.RegisterJobHandler(sc, "test_fail", func(context.Context, *api.Server, *testJobData) error {
+			return errors.New("no luck")
+		})
+	}
+
+	register(sc)
+	api.RegisterJobHandler(sc, "test_panic", func(context.Context, *api.Server, struct{}) error {
+		panic("oh no")
+	})
+	api.RegisterJobHandler(sc, "test_block", func(ctx context.Context, _ *api.Server, _ struct{}) error {
+		select {
+		case <-block:
+		case <-ctx.Done():
+		}
+
+		return nil
+	})
+
+	if err := sc.Schedule("nope", "test_missing", api.Every(time.Minute), nil); err == nil {
+		t.Errorf("Schedule() - Expected an error for an unregistered handler; Got nil")
+	}
+
+	// Scheduled before starting, as modules do
+	sc.Schedule("tick", "test_tick", api.Every(20*time.Millisecond), nil)
+
+	stop := sc.Start()
+
+	sc.Schedule("once", "test_once", api.Once(time.Now()), testJobData{7})
+	sc.Schedule("later", "test_once", api.Once(time.Now().Add(time.Hour)), testJobData{8})
+
+	select {
+	case n := <-ran:
+		if n != 7 {
+			t.Errorf("Scheduler - Expected the job's data, 7; Got %d", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Scheduler - Expected a one-off job to run; Got timeout")
+	}
+
+	eventually(t, "a one-off job to be removed once run", func() bool {
+		_, ok := job(sc, "once")
+		return !ok
+	})
+
+	eventually(t, "an interval job to run repeatedly", func() bool { return ticks.Load() >= 2 })
+
+	sc.Pause("tick")
+	time.Sleep(50 * time.Millisecond)
+
+	paused := ticks.Load()
+	time.Sleep(100 * time.Millisecond)
+
+	if n := ticks.Load(); n != paused {
+		t.Errorf("Pause() - Expected no runs once paused; Got %d more", n-paused)
+	}
+
+	sc.RunNow("tick")
+	eventually(t, "a paused job to run when asked", func() bool { return ticks.Load() == paused+1 })
+
+	sc.Schedule("fail", "test_fail", api.Every(time.Hour), &testJobData{1})
+	sc.Schedule("panic", "test_panic", api.Every(time.Hour), nil)
+	sc.RunNow("fail")
+	sc.RunNow("panic")
+
+	eventually(t, "a failing job's error to be kept", func() bool {
+		j, _ := job(sc, "fail")
+		return j.Failures == 1 && j.LastError == "no luck"
+	})
+
+	eventually(t, "a panicking job's error to be kept", func() bool {
+		j, _ := job(sc, "panic")
+		return j.Failures == 1 && j.LastError == "panic: oh no"
+	})
+
+	sc.Schedule("block", "test_block", api.Every(10*time.Millisecond), nil)
+
+	eventually(t, "runs to be skipped while the last is going", func() bool {
+		j, _ := job(sc, "block")
+		return j.Running && j.Skips >= 2 && j.Runs == 0
+	})
+
+	close(block)
+
+	eventually(t, "a blocked job to finish", func() bool {
+		j, _ := job(sc, "block")
+		return j.Runs >= 1
+	})
+
+	sc.Cancel("block")
+
+	if _, ok := job(sc, "block"); ok {
+		t.Errorf("Cancel() - Expected the job to be removed; Got it listed")
+	}
+
+	stop()
+
+	// As after a restart, with only some jobs scheduled again, and the panicking job's
+	// handler left out as if its module were turned off
+	again := api.NewScheduler(h.Server)
+	register(again)
+	again.Schedule("tick", "test_tick", api.Every(20*time.Millisecond), nil)
+
+	defer again.Start()()
+
+	if j, ok := job(again, "tick"); !ok || !j.Paused || j.Runs < 3 {
+		t.Errorf("Start() - Expected a paused job to stay paused and keep its history; Got %+v", j)
+	}
+
+	if j, ok := job(again, "later"); !ok || j.NextRun.Before(time.Now().Add(59*time.Minute)) {
+		t.Errorf("Start() - Expected a saved one-off job to be restored; Got %+v", j)
+	}
+
+	if _, ok := job(again, "block"); ok {
+		t.Errorf("Start() - Expected a cancelled job to stay cancelled; Got it restored")
+	}
+
+	if j, ok := job(again, "once"); ok {
+		t.Errorf("Start() - Expected a finished one-off job to be gone; Got %+v", j)
+	}
+
+	if j, ok := job(again, "fail"); !ok || j.LastError != "no luck" {
+		t.Errorf("Start() - Expected a saved job to be restored with its last error; Got %+v", j)
+	}
+
+	if j, ok := job(again, "panic"); ok {
+		t.Errorf("Start() - Expected a job without its handler to be left; Got %+v", j)
+	}
+}
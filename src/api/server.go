@@ -1,29 +1,44 @@
 package api
 
 import (
-	"bufio"
 	"context"
 	"log"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	d "github.com/willmroliver/plathbot/src/db"
+	"github.com/willmroliver/plathbot/src/repo"
 	"github.com/willmroliver/plathbot/src/util"
 	"gorm.io/gorm"
 )
 
 var (
+	allowedUpdates = []string{
+		"message",
+		"callback_query",
+		"inline_query",
+		"message_reaction",
+		"message_reaction_count",
+		"chat_member",
+		"my_chat_member",
+	}
+
 	inlineActions  = map[string]InlineAction{}
+	inlineOptions  = map[string][]InlineOption{}
 	commandActions = map[string]CommandAction{}
+	commandOptions = map[string][]CommandOption{}
 	callbackAPIs   = map[string]func() *CallbackAPI{}
 
 	onCreateHooks     = []func(*Server){}
-	beforeListenHooks = []func(*Server){}
+	beforeListenHooks = []func(*Server) Stopper{}
 )
 
 type Server struct {
@@ -32,11 +47,32 @@ type Server struct {
 	CallbackAPI *CallbackAPI
 	CommandAPI  *CommandAPI
 	InlineAPI   *InlineAPI
+	Scheduler   *Scheduler
 
-	chatHooks sync.Map
-	userHooks sync.Map
+	hooks         hookRegistry
+	conversations sync.Map
 
 	callbackAPIs []*CallbackAPI
+	admins       *adminCache
+	modules      []string
+
+	settings     *repo.ChatSettingsRepo
+	settingsOnce sync.Once
+
+	middlewares []Middleware
+	handler     Handler
+
+	dispatcher        *Dispatcher
+	stoppers          []Stopper
+	beforeListenHooks []func(*Server) Stopper
+
+	// For the monitoring endpoints
+	created    time.Time
+	listening  time.Time
+	ready      atomic.Bool
+	lastUpdate atomic.Int64
+	statuses   map[string]func() any
+	statusMux  sync.Mutex
 }
 
 func NewServer(db *gorm.DB) *Server {
@@ -61,23 +97,32 @@ func NewServer(db *gorm.DB) *Server {
 		Timeout:   30 * time.Second,
 	}
 
-	bot, err := botapi.NewBotAPI(os.Getenv("BOT_TOKEN"))
+	// BOT_API_ENDPOINT points the bot at another Bot API server, e.g. a local one or a fake
+	endpoint := os.Getenv("BOT_API_ENDPOINT")
+	if endpoint == "" {
+		endpoint = botapi.APIEndpoint
+	}
+
+	bot, err := botapi.NewBotAPIWithClient(os.Getenv("BOT_TOKEN"), endpoint, httpClient)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	bot.Client = httpClient
 	s := &Server{
-		Bot: bot,
-		DB:  db,
+		Bot:     bot,
+		DB:      db,
+		admins:  newAdminCache(envDuration("ADMINS_TTL", defaultAdminsTTL)),
+		created: time.Now(),
 	}
 
 	s.InlineAPI = &InlineAPI{
 		Actions: map[string]InlineAction{},
+		Meta:    map[string]*InlineMeta{},
 	}
 
 	s.CommandAPI = &CommandAPI{
 		Actions: map[string]CommandAction{},
+		Meta:    map[string]*CommandMeta{},
 	}
 
 	s.CallbackAPI = &CallbackAPI{
@@ -86,14 +131,18 @@ func NewServer(db *gorm.DB) *Server {
 		DynamicOptions: func(ctx *Context, cq *botapi.CallbackQuery, cc *CallbackCmd) (opts []map[string]string) {
 			apis := s.callbackAPIs
 
-			opts = make([]map[string]string, len(apis))
+			opts = make([]map[string]string, 0, len(apis))
 			public := ctx.Chat.Type != "private"
 
-			for i, a := range apis {
+			for _, a := range apis {
+				if !ctx.HasRole(a.RequireRole) || !ctx.Enabled(a.Path) {
+					continue
+				}
+
 				if a.PrivateOnly && public {
-					opts[i] = map[string]string{a.Title: KeyboardLink(ToPrivateString(ctx.Bot, a.Path))}
+					opts = append(opts, map[string]string{a.Title: KeyboardLink(ToPrivateString(ctx.Bot, a.Path))})
 				} else {
-					opts[i] = map[string]string{a.Title: a.Path}
+					opts = append(opts, map[string]string{a.Title: a.Path})
 				}
 			}
 
@@ -104,17 +153,29 @@ func NewServer(db *gorm.DB) *Server {
 	s.callbackAPIs = make([]*CallbackAPI, 0)
 
 	for cmd, action := range inlineActions {
-		s.RegisterInlineAction(cmd, action)
+		s.RegisterInlineAction(cmd, action, inlineOptions[cmd]...)
 	}
 
 	for cmd, action := range commandActions {
-		s.RegisterCommandAction(cmd, action)
+		s.RegisterCommandAction(cmd, action, commandOptions[cmd]...)
 	}
 
 	for _, api := range callbackAPIs {
 		s.RegisterCallbackAPI(api())
 	}
 
+	s.Scheduler = NewScheduler(s)
+	RegisterJobHandler(s.Scheduler, "locker_tidy", func(context.Context, *Server, struct{}) error {
+		util.TidyLocks()
+		return nil
+	})
+	s.Scheduler.Schedule("locker tidy", "locker_tidy", Every(time.Minute*30), nil)
+
+	s.setupModules(EnabledModules())
+
+	s.Use(Recover, RequestID, Timing, Metrics, Enrich)
+	s.Use(middlewares...)
+
 	for _, hook := range onCreateHooks {
 		hook(s)
 	}
@@ -127,69 +188,189 @@ func NewServer(db *gorm.DB) *Server {
 	return s
 }
 
+// Listen receives and handles updates until the process is sent SIGINT or SIGTERM,
+// then shuts the server down gracefully.
 func (s *Server) Listen() {
-	u := botapi.NewUpdate(0)
-	u.Timeout = 60
-	u.AllowedUpdates = []string{
-		"message",
-		"callback_query",
-		"inline_query",
-		"message_reaction",
-		"message_reaction_count",
-	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
 
-	updates := s.Bot.GetUpdatesChan(u)
+	s.serve(ctx)
+}
+
+// serve receives and handles updates until ctx is done, then shuts the server down.
+func (s *Server) serve(ctx context.Context) {
+	updates, stop := s.receive()
+	done := make(chan struct{})
 
-	ctx := context.Background()
-	ctx, cancel := context.WithCancel(ctx)
+	s.handler = s.chain()
+	s.dispatcher = NewDispatcher(
+		envInt("WORKERS", defaultWorkers),
+		envInt("QUEUE_DEPTH", defaultQueueDepth),
+		s.Handle,
+	)
 
 	listen := func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case update := <-updates:
-				go func() {
-					if s.DoMessageHook(update.Message) {
-						return
-					}
+		defer close(done)
 
-					NewContext(s, &update).HandleUpdate()
-				}()
-			}
+		for update := range updates {
+			s.dispatcher.Dispatch(update)
 		}
 	}
 
-	util.InitLockerTidy(time.Minute * 30)
+	if stopper := s.serveMonitor(); stopper != nil {
+		s.stoppers = append(s.stoppers, stopper)
+	}
 
-	for _, hook := range beforeListenHooks {
-		hook(s)
+	for _, hook := range append(beforeListenHooks, s.beforeListenHooks...) {
+		if stopper := hook(s); stopper != nil {
+			s.stoppers = append(s.stoppers, stopper)
+		}
 	}
 
+	// Started after the hooks, so that jobs they schedule are merged with those saved
+	s.stoppers = append(s.stoppers, s.Scheduler.Start())
+
+	s.PublishCommands()
+	s.ResumeHooks()
+
 	go listen()
 
-	bufio.NewReader(os.Stdin).ReadBytes('\n')
-	cancel()
+	s.listening = time.Now()
+	s.ready.Store(true)
+	running.Store(s)
+
+	<-ctx.Done()
+	log.Println("Server: Shutting down...")
+
+	s.ready.Store(false)
+
+	// Stop receiving, then handle everything received up to when updates is closed
+	stop()
+	<-done
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout())
+	defer cancel()
+
+	s.Shutdown(ctx)
+}
+
+// Handle runs a single update through the middlewares, then any conversation or message hook
+// and, if neither consumed it, the Context handlers. Both long polling and webhook delivery feed into this.
+func (s *Server) Handle(update botapi.Update) {
+	h := s.handler
+	if h == nil {
+		h = s.chain()
+	}
+
+	h(NewContext(s, &update))
+	s.lastUpdate.Store(time.Now().UnixNano())
 }
 
-func RegisterInlineAction(cmd string, action InlineAction) {
+// LastUpdate returns when the server last finished handling an update, or the zero
+// time if it hasn't yet.
+func (s *Server) LastUpdate() time.Time {
+	if ns := s.lastUpdate.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+
+	return time.Time{}
+}
+
+// receive starts update delivery, via webhook if WEBHOOK_URL is set, otherwise via long polling.
+// The returned func stops delivery and releases anything registered with Telegram.
+func (s *Server) receive() (botapi.UpdatesChannel, func()) {
+	if addr := os.Getenv("WEBHOOK_URL"); addr != "" {
+		wh, err := newWebhook(addr, os.Getenv("WEBHOOK_SECRET"))
+		if err != nil {
+			log.Panicf("Server: invalid webhook config: %q", err.Error())
+		}
+
+		updates, err := wh.Start(s.Bot)
+		if err != nil {
+			log.Panicf("Server: error starting webhook: %q", err.Error())
+		}
+
+		log.Printf("Server: Receiving updates via webhook at %s\n", wh.url.Redacted())
+		return updates, func() { wh.Stop(s.Bot) }
+	}
+
+	// Clear any webhook left over from a previous run, else getUpdates is refused
+	if _, err := s.Bot.Request(botapi.DeleteWebhookConfig{}); err != nil {
+		log.Printf("Server: error deleting webhook: %q", err.Error())
+	}
+
+	u := botapi.NewUpdate(0)
+	u.Timeout = 60
+	u.AllowedUpdates = allowedUpdates
+
+	polled := s.Bot.GetUpdatesChan(u)
+	updates := make(chan botapi.Update)
+	stopped := make(chan struct{})
+
+	// Updates are only confirmed by the next poll, so those from the poll in flight when
+	// we stop are redelivered next run. Only what's already been polled is handed over.
+	go func() {
+		defer close(updates)
+
+		for {
+			select {
+			case update, ok := <-polled:
+				if !ok {
+					return
+				}
+
+				updates <- update
+			case <-stopped:
+				for {
+					select {
+					case update, ok := <-polled:
+						if !ok {
+							return
+						}
+
+						updates <- update
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	log.Println("Server: Receiving updates via long polling")
+	return updates, func() {
+		s.Bot.StopReceivingUpdates()
+		close(stopped)
+	}
+}
+
+func RegisterInlineAction(cmd string, action InlineAction, opts ...InlineOption) {
 	inlineActions[cmd] = action
+	inlineOptions[cmd] = opts
 }
 
-func RegisterCommandAction(cmd string, action CommandAction) {
+func RegisterCommandAction(cmd string, action CommandAction, opts ...CommandOption) {
 	commandActions[cmd] = action
+	commandOptions[cmd] = opts
 }
 
 func RegisterCallbackAPI(cmd string, api func() *CallbackAPI) {
 	callbackAPIs[cmd] = api
 }
 
-func (s *Server) RegisterInlineAction(cmd string, action InlineAction) {
+func (s *Server) RegisterInlineAction(cmd string, action InlineAction, opts ...InlineOption) {
 	s.InlineAPI.Actions[cmd] = action
+	s.InlineAPI.Meta[cmd] = newInlineMeta(opts...)
 }
 
-func (s *Server) RegisterCommandAction(cmd string, action CommandAction) {
+func (s *Server) RegisterCommandAction(cmd string, action CommandAction, opts ...CommandOption) {
+	meta := &CommandMeta{}
+	for _, opt := range opts {
+		opt(meta)
+	}
+
 	s.CommandAPI.Actions[cmd] = action
+	s.CommandAPI.Meta[cmd] = meta
 }
 
 func (s *Server) RegisterCallbackAPI(api *CallbackAPI) {
@@ -202,44 +383,62 @@ func (s *Server) RegisterCallbackAPI(api *CallbackAPI) {
 	s.CallbackAPI.Actions[cmd] = api.Select
 }
 
+// CallbackAPIs returns the menus registered with the server, in the order they're shown in the hub.
+func (s *Server) CallbackAPIs() []*CallbackAPI {
+	return s.callbackAPIs
+}
+
+// FindCallbackAPI returns the registered menu at path, or nil if there's none.
+func (s *Server) FindCallbackAPI(path string) *CallbackAPI {
+	for _, api := range s.callbackAPIs {
+		if api.Path == path {
+			return api
+		}
+	}
+
+	return nil
+}
+
+// RegisterChatHook adds a hook run on messages in the chat, replacing any pending there
+// in the same namespace.
 func (s *Server) RegisterChatHook(chatID int64, hook *MessageHook) {
-	s.chatHooks.Store(chatID, hook)
+	s.hooks.add(HookChat, chatID, hook)
+	s.saveHook(hook)
 }
 
+// RegisterUserHook adds a hook run on messages from the user, replacing any pending for
+// them in the same namespace.
 func (s *Server) RegisterUserHook(userID int64, hook *MessageHook) {
-	s.userHooks.Store(userID, hook)
+	s.hooks.add(HookUser, userID, hook)
+	s.saveHook(hook)
 }
 
-func (s *Server) DoMessageHook(m *botapi.Message) (success bool) {
-	if m == nil {
-		return
-	}
-
-	ids := [2]int64{m.Chat.ID, m.From.ID}
-	hooks := [2]*MessageHook{nil, nil}
-	maps := [2]*sync.Map{&s.chatHooks, &s.userHooks}
+// PendingHooks returns the live hooks registered against the chat or the user, newest first.
+func (s *Server) PendingHooks(chatID, userID int64) []*MessageHook {
+	return s.hooks.pending(chatID, userID)
+}
 
-	if data, ok := s.chatHooks.Load(m.Chat.ID); ok {
-		hooks[0] = data.(*MessageHook)
+// CancelHook removes a pending hook, reporting whether it was still registered.
+func (s *Server) CancelHook(hook *MessageHook) bool {
+	if !s.hooks.remove(hook) {
+		return false
 	}
 
-	if data, ok := s.userHooks.Load(m.From.ID); ok {
-		hooks[1] = data.(*MessageHook)
-	}
+	s.forgetHook(hook)
+	return true
+}
 
-	for i, hook := range hooks {
-		if hook == nil {
-			continue
-		}
+// DoMessageHook offers the message to its matching hooks in priority order, stopping at
+// the first which succeeds. That hook is removed and the message counts as handled.
+func (s *Server) DoMessageHook(m *botapi.Message) (success bool) {
+	if m == nil || m.From == nil {
+		return
+	}
 
-		if hook.ExpiresAt.After(time.Now()) {
-			go func() {
-				if success = hook.Execute(s, m); success {
-					maps[i].Delete(ids[i])
-				}
-			}()
-		} else {
-			maps[i].Delete(ids[i])
+	for _, hook := range s.hooks.match(m) {
+		if success = hook.Execute(s, m); success {
+			s.CancelHook(hook)
+			return
 		}
 	}
 
@@ -250,6 +449,14 @@ func OnCreate(hook func(*Server)) {
 	onCreateHooks = append(onCreateHooks, hook)
 }
 
-func BeforeListen(hook func(*Server)) {
+// BeforeListen registers a hook run just before the server starts receiving updates.
+// Hooks starting background jobs should return a Stopper, which is called on shutdown.
+func BeforeListen(hook func(*Server) Stopper) {
 	beforeListenHooks = append(beforeListenHooks, hook)
 }
+
+// BeforeListen registers a hook run just before this server starts receiving updates,
+// as modules do from Setup.
+func (s *Server) BeforeListen(hook func(*Server) Stopper) {
+	s.beforeListenHooks = append(s.beforeListenHooks, hook)
+}
@@ -0,0 +1,101 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/apitest"
+)
+
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() - Unexpected error: %q", err.Error())
+	}
+	defer l.Close()
+
+	return strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+}
+
+func TestServeWebhookShutdown(t *testing.T) {
+	port := freePort(t)
+	t.Setenv("PORT", port)
+	t.Setenv("WEBHOOK_URL", "https://example.com/hook")
+	t.Setenv("WEBHOOK_SECRET", "secret")
+	t.Setenv("SHUTDOWN_TIMEOUT", "5s")
+
+	h := apitest.New(t)
+	hook := "http://127.0.0.1:" + port + "/hook"
+
+	user := apitest.User(1, "sylvia")
+	update := botapi.Update{UpdateID: 1, Message: &botapi.Message{
+		MessageID: 1,
+		From:      user,
+		Chat:      apitest.Private(user),
+		Text:      "hello",
+	}}
+
+	// Telegram can still deliver an update while the webhook is being removed
+	posted := make(chan int, 1)
+	h.OnRequest("deleteWebhook", func(*apitest.Request) {
+		body, _ := json.Marshal(update)
+
+		r, _ := http.NewRequest(http.MethodPost, hook, bytes.NewReader(body))
+		r.Header.Set("X-Telegram-Bot-Api-Secret-Token", "secret")
+
+		res, err := http.DefaultClient.Do(r)
+		if err != nil {
+			t.Errorf("POST %s - Unexpected error: %q", hook, err.Error())
+			posted <- 0
+			return
+		}
+
+		res.Body.Close()
+		posted <- res.StatusCode
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan struct{})
+
+	go func() {
+		h.Server.Serve(ctx)
+		close(served)
+	}()
+
+	// Wait for the webhook to accept connections before shutting down
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if res, err := http.Get(hook); err == nil {
+			res.Body.Close()
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("Serve() - Expected the webhook to listen on port %s", port)
+		}
+	}
+
+	cancel()
+
+	select {
+	case <-served:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Serve() - Expected to return after ctx is done")
+	}
+
+	if code := <-posted; code != http.StatusOK {
+		t.Errorf("Serve() - Expected the update posted during shutdown to be accepted; Got %d", code)
+	}
+
+	if h.Server.LastUpdate().IsZero() {
+		t.Errorf("Serve() - Expected the update posted during shutdown to be handled; Got none handled")
+	}
+}
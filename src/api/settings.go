@@ -0,0 +1,67 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/repo"
+)
+
+// ChatSettings returns the chat's settings, or the defaults if it has none. The result is
+// shared, so copy it before making changes to save.
+func (s *Server) ChatSettings(chatID int64) *model.ChatSettings {
+	if s.DB == nil {
+		return &model.ChatSettings{ChatID: chatID}
+	}
+
+	return s.SettingsRepo().Get(chatID)
+}
+
+// SettingsRepo returns the server's chat settings repo, which caches them, so settings
+// should be saved through it.
+func (s *Server) SettingsRepo() *repo.ChatSettingsRepo {
+	s.settingsOnce.Do(func() {
+		s.settings = repo.NewChatSettingsRepo(s.DB)
+	})
+
+	return s.settings
+}
+
+// Settings returns the current chat's settings.
+func (ctx *Context) Settings() *model.ChatSettings {
+	if ctx.Chat == nil {
+		return &model.ChatSettings{}
+	}
+
+	return ctx.Server.ChatSettings(ctx.Chat.ID)
+}
+
+// Enabled reports whether a menu, by path, or a command is on in the current chat.
+// Nested menus and subcommands are off when their parent is.
+func (ctx *Context) Enabled(module string) bool {
+	if module == "" {
+		return true
+	}
+
+	settings := ctx.Settings()
+
+	if strings.HasPrefix(module, "/") {
+		cmd, _, _ := strings.Cut(module, " ")
+		return settings.Enabled(cmd) && settings.Enabled(module)
+	}
+
+	root, _, _ := strings.Cut(module, "/")
+	return settings.Enabled(root)
+}
+
+// Cooldown returns how long something used in public should wait before it's used again,
+// def unless the chat has set its own.
+func (ctx *Context) Cooldown(def time.Duration) time.Duration {
+	return ctx.Settings().CooldownOr(def)
+}
+
+// XP returns what kind earns in the current chat.
+func (ctx *Context) XP(kind model.XPKind) int64 {
+	return ctx.Settings().XP(kind)
+}
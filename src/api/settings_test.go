@@ -0,0 +1,74 @@
+package api_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/db"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/repo"
+)
+
+func TestChatSettings(t *testing.T) {
+	conn, err := db.Open(filepath.Join(t.TempDir(), "settings.db"))
+	if err != nil {
+		t.Fatalf("Open() - Unexpected error: %q", err.Error())
+	}
+	defer db.Close(conn)
+
+	db.Migrate(conn)
+
+	const chatID = -4242
+
+	cooldown, xp := time.Second*30, int64(25)
+
+	settings := &model.ChatSettings{ChatID: chatID, Cooldown: &cooldown}
+	settings.SetEnabled("games", false)
+	settings.SetEnabled("/pfp", false)
+	settings.SetXP(model.XPGameWin, &xp)
+
+	if err := repo.NewChatSettingsRepo(conn).Save(settings); err != nil {
+		t.Fatalf("Save() - Unexpected error: %q", err.Error())
+	}
+
+	saved := &model.ChatSettings{}
+	if err := conn.First(saved, chatID).Error; err != nil || saved.CooldownOr(0) != cooldown || saved.XP(model.XPGameWin) != xp || saved.Disabled != "/pfp,games" {
+		t.Errorf("Save() - Expected the settings to be stored; Got %+v, %v", saved, err)
+	}
+
+	ctx := &api.Context{Server: &api.Server{DB: conn}, Chat: &botapi.Chat{ID: chatID, Type: "supergroup"}}
+
+	for module, want := range map[string]bool{"games": false, "games/cointoss": false, "stats": true, "/pfp": false, "/pfp add": false, "/fact": true, "": true} {
+		if got := ctx.Enabled(module); got != want {
+			t.Errorf("Enabled(%q) - Expected %v; Got %v", module, want, got)
+		}
+	}
+
+	if got := ctx.Cooldown(time.Second * 3); got != cooldown {
+		t.Errorf("Cooldown() - Expected %s; Got %s", cooldown, got)
+	}
+
+	if got := ctx.XP(model.XPGameWin); got != xp {
+		t.Errorf("XP() - Expected %d; Got %d", xp, got)
+	}
+
+	if got := ctx.XP(model.XPMessage); got != model.DefaultXP[model.XPMessage] {
+		t.Errorf("XP() - Expected the default %d; Got %d", model.DefaultXP[model.XPMessage], got)
+	}
+
+	// Settings are cached per repo, so another database doesn't see these
+	other, err := db.Open(filepath.Join(t.TempDir(), "other.db"))
+	if err != nil {
+		t.Fatalf("Open() - Unexpected error: %q", err.Error())
+	}
+	defer db.Close(other)
+
+	db.Migrate(other)
+
+	if got := (&api.Server{DB: other}).ChatSettings(chatID); got.CooldownOr(0) != 0 || got.Disabled != "" {
+		t.Errorf("ChatSettings() - Expected the defaults from another database; Got %+v", got)
+	}
+}
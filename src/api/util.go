@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/render"
 )
 
 const (
@@ -14,48 +15,70 @@ const (
 )
 
 func SendBasic(bot *botapi.BotAPI, chatID int64, msg string) (*botapi.Message, error) {
-	m, err := bot.Send(botapi.NewMessage(chatID, msg))
-	if err != nil {
-		log.Printf("SendBasic error: %q, attempting to send %q:", err.Error(), msg)
+	d := <-OutboxFor(bot).Send(botapi.NewMessage(chatID, msg))
+	if d.Err != nil {
+		log.Printf("SendBasic error: %q, attempting to send %q:", d.Err.Error(), msg)
 	}
 
-	return &m, err
+	return d.Message, d.Err
 }
 
 func SendConfig(bot *botapi.BotAPI, msg botapi.Chattable) (*botapi.Message, error) {
-	m, err := bot.Send(msg)
-	if err != nil {
-		log.Printf("SendConfig error: %q, attempting to send %+v:", err.Error(), msg)
+	d := <-OutboxFor(bot).Send(msg)
+	if d.Err != nil {
+		log.Printf("SendConfig error: %q, attempting to send %+v:", d.Err.Error(), msg)
 	}
 
-	return &m, err
+	return d.Message, d.Err
 }
 
 func SendUpdate(bot *botapi.BotAPI, msg *botapi.EditMessageTextConfig) (err error) {
-	if _, err = bot.Send(*msg); err != nil {
+	if err = (<-OutboxFor(bot).Send(*msg)).Err; err != nil {
 		log.Printf("SendUpdate error: %q, attempting to send %q", err.Error(), msg.Text)
 	}
 
 	return
 }
 
-func RequestBasic(bot *botapi.BotAPI, query *botapi.InlineQuery, title, msg string) (err error) {
-	a := botapi.NewInlineQueryResultArticleHTML(query.ID, title, msg)
-	c := botapi.InlineConfig{
-		InlineQueryID: query.ID,
-		IsPersonal:    true,
-		CacheTime:     3600,
-		Results:       []interface{}{a},
+// SendLong sends msg, whose ParseMode is HTML or MarkdownV2, split into as many messages as
+// it takes to fit Telegram's length limit, returning the last. Only the last has msg's
+// reply markup.
+func SendLong(bot *botapi.BotAPI, msg botapi.MessageConfig) (m *botapi.Message, err error) {
+	parts := render.Mode(msg.ParseMode).Split(msg.Text, render.MaxLength)
+
+	for i, text := range parts {
+		part := msg
+		part.Text = text
+
+		if i+1 < len(parts) {
+			part.ReplyMarkup = nil
+		}
+
+		if m, err = SendConfig(bot, part); err != nil {
+			return
+		}
 	}
 
-	_, err = bot.Request(c)
 	return
 }
 
+// SendLater queues msg without waiting for it to be delivered. Failures are logged.
+func SendLater(bot *botapi.BotAPI, msg botapi.Chattable) {
+	ch := OutboxFor(bot).Send(msg)
+
+	go func() {
+		if d := <-ch; d.Err != nil {
+			log.Printf("SendLater error: %q, attempting to send %+v:", d.Err.Error(), msg)
+		}
+	}()
+}
+
 // InlineKeyboard converts an array of Text:Data maps into a TG inline keyboard.
 // Data supports functions which can request special button types.
 //
 // Optional tags can be passed which are prepended as a comma-separated list: "data" -> "arg1,arg2,... data"
+//
+// Data buttons are signed, and compacted if too long, so all callback buttons should be made here.
 func InlineKeyboard(data []map[string]string, tags ...string) *botapi.InlineKeyboardMarkup {
 	rows := make([][]botapi.InlineKeyboardButton, len(data))
 
@@ -119,15 +142,16 @@ func KeyboardButton(text, data string, tags ...string) botapi.InlineKeyboardButt
 		data = prefix + "|" + data
 	}
 
-	return botapi.NewInlineKeyboardButtonData(text, data)
+	return botapi.NewInlineKeyboardButtonData(text, CompactCallbackData(SignCallbackData(data)))
 }
 
 func KeyboardNavRow(back string) map[string]string {
 	return map[string]string{"👈 Back": back, "👋 Done": BuiltInDelete}
 }
 
-func AtString(text string, id int64) string {
-	return fmt.Sprintf("[%s](tg://user?id=%d)", text, id)
+// AtString mentions the user by id as text, formatted as HTML.
+func AtString(text string, id int64) render.Markup {
+	return render.HTML.Mention(text, id)
 }
 
 func DisplayName(user *botapi.User) (text string) {
@@ -142,7 +166,8 @@ func DisplayName(user *botapi.User) (text string) {
 	return
 }
 
-func AtUserString(user *botapi.User) string {
+// AtUserString mentions the user by name, formatted as HTML.
+func AtUserString(user *botapi.User) render.Markup {
 	if user == nil {
 		return ""
 	}
@@ -157,18 +182,20 @@ func AtUserString(user *botapi.User) string {
 		text = fmt.Sprintf("user-%d", user.ID)
 	}
 
-	return fmt.Sprintf("[%s](tg://user?id=%d)", text, user.ID)
+	return render.HTML.Mention(text, user.ID)
 }
 
-func AtBotString(bot *botapi.BotAPI) string {
-	return "[@" + bot.Self.UserName + "](https://t.me/" + bot.Self.UserName + ")"
+// AtBotString links to the bot, formatted as HTML.
+func AtBotString(bot *botapi.BotAPI) render.Markup {
+	return render.HTML.Link("@"+bot.Self.UserName, "https://t.me/"+bot.Self.UserName)
 }
 
 func ToPrivateString(bot *botapi.BotAPI, cmd string) string {
 	return fmt.Sprintf("tg://resolve?domain=%s&start=%s", bot.Self.UserName, cmd)
 }
 
-func MarkdownV2Cols(items []string, cols int) (res string) {
+// MarkdownV2Cols lays items out in a MarkdownV2 code block, cols to a row.
+func MarkdownV2Cols(items []string, cols int) string {
 	maxes := make([]int, cols)
 
 	for i := range len(items) {
@@ -181,17 +208,15 @@ func MarkdownV2Cols(items []string, cols int) (res string) {
 		maxes[i] += 2
 	}
 
-	res += "```\n"
+	res := &strings.Builder{}
 
 	for i, item := range items {
-		res += item + strings.Repeat(" ", maxes[i%cols]-len(item))
+		res.WriteString(item + strings.Repeat(" ", maxes[i%cols]-len(item)))
 
 		if i%cols == cols-1 {
-			res += "\n"
+			res.WriteString("\n")
 		}
 	}
 
-	res += "\n```"
-
-	return
+	return string(render.MarkdownV2.Pre(res.String()))
 }
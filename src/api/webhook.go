@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const webhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// webhook receives updates pushed by Telegram over HTTP.
+//
+// The server listens on PORT and accepts updates at the path of the public URL,
+// which is what docker-compose forwards EXPOSE_PORT to.
+type webhook struct {
+	url     *url.URL
+	secret  string
+	server  *http.Server
+	updates chan botapi.Update
+	done    chan struct{}
+}
+
+func newWebhook(addr, secret string) (wh *webhook, err error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return
+	}
+
+	if u.Scheme != "https" {
+		err = errors.New("webhook URL must use https")
+		return
+	}
+
+	if secret == "" {
+		bytes := make([]byte, 32)
+		if _, err = rand.Read(bytes); err != nil {
+			return
+		}
+
+		secret = hex.EncodeToString(bytes)
+	}
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	wh = &webhook{
+		url:     u,
+		secret:  secret,
+		updates: make(chan botapi.Update, 100),
+		done:    make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, wh)
+
+	wh.server = &http.Server{
+		Addr:              ":" + port,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return
+}
+
+// Start begins serving and registers the webhook with Telegram.
+func (wh *webhook) Start(bot *botapi.BotAPI) (botapi.UpdatesChannel, error) {
+	go func() {
+		if err := wh.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("Webhook: server error: %q", err.Error())
+		}
+	}()
+
+	params := botapi.Params{}
+	params["url"] = wh.url.String()
+	params["secret_token"] = wh.secret
+
+	if err := params.AddInterface("allowed_updates", allowedUpdates); err != nil {
+		return nil, err
+	}
+
+	if _, err := bot.MakeRequest("setWebhook", params); err != nil {
+		wh.server.Close()
+		return nil, err
+	}
+
+	return wh.updates, nil
+}
+
+// Stop removes the webhook from Telegram and waits for in-flight requests to finish.
+func (wh *webhook) Stop(bot *botapi.BotAPI) {
+	if _, err := bot.Request(botapi.DeleteWebhookConfig{}); err != nil {
+		log.Printf("Webhook: error deleting webhook: %q", err.Error())
+	}
+
+	close(wh.done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := wh.server.Shutdown(ctx); err != nil {
+		log.Printf("Webhook: error shutting down server: %q", err.Error())
+	}
+
+	close(wh.updates)
+}
+
+func (wh *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	token := r.Header.Get(webhookSecretHeader)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(wh.secret)) != 1 {
+		log.Printf("Webhook: rejected request from %s with bad secret", r.RemoteAddr)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var update botapi.Update
+
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		log.Printf("Webhook: error decoding update: %q", err.Error())
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case wh.updates <- update:
+		w.WriteHeader(http.StatusOK)
+	case <-wh.done:
+		// Telegram will redeliver the update once a webhook is registered again
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
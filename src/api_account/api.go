@@ -1,6 +1,3 @@
-//go:build account
-// +build account
-
 package account
 
 import (
@@ -14,25 +11,31 @@ const (
 )
 
 var (
-	walletAPI = WalletAPI()
-
-	Extensions api.CallbackExtensions
+	walletAPI   = WalletAPI()
+	languageAPI = LanguageAPI()
 )
 
 func init() {
-	api.RegisterCallbackAPI(Path, API)
+	api.RegisterModule(&api.Module{
+		Name: "account",
+		Setup: func(s *api.Server) {
+			s.RegisterCallbackAPI(API())
+		},
+	})
 }
 
 func API() *api.CallbackAPI {
-	wallet := "wallet"
+	wallet, language := "wallet", "language"
 
 	return api.NewCallbackAPI(
 		Title,
 		Path,
 		&api.CallbackConfig{
+			Description: "Your XP, wallet and linked accounts",
 			DynamicActions: func(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) (opts map[string]api.CallbackAction) {
 				opts = map[string]api.CallbackAction{
-					wallet: walletAPI.Select,
+					wallet:   walletAPI.Select,
+					language: languageAPI.Select,
 				}
 
 				return
@@ -40,13 +43,13 @@ func API() *api.CallbackAPI {
 			DynamicOptions: func(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) (opts []map[string]string) {
 				opts = []map[string]string{
 					{WalletTitle: wallet},
+					{LanguageTitle: language},
 					api.KeyboardNavRow(".."),
 				}
 
 				return
 			},
 			PrivateOnly: true,
-			Extensions:  Extensions,
 		},
 	)
 }
@@ -0,0 +1,77 @@
+package account
+
+import (
+	"fmt"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/i18n"
+)
+
+const (
+	LanguageTitle = "🌐 Language"
+	LanguagePath  = Path + "/language"
+
+	// languageAuto clears the user's choice, going back to their Telegram app's language.
+	languageAuto = "auto"
+)
+
+func LanguageAPI() *api.CallbackAPI {
+	return api.NewCallbackAPI(
+		LanguageTitle,
+		LanguagePath,
+		&api.CallbackConfig{
+			DynamicActions: func(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) map[string]api.CallbackAction {
+				actions := map[string]api.CallbackAction{languageAuto: setLanguage("")}
+
+				for _, l := range i18n.Languages() {
+					actions[l.Lang] = setLanguage(l.Lang)
+				}
+
+				return actions
+			},
+			DynamicOptions: func(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) (opts []map[string]string) {
+				chosen := c.GetUser().Language
+
+				for _, l := range i18n.Languages() {
+					opts = append(opts, map[string]string{languageLabel(l.Name, l.Lang == chosen): l.Lang})
+				}
+
+				opts = append(opts,
+					map[string]string{languageLabel(c.T("🤖 Same as Telegram"), chosen == ""): languageAuto},
+					api.KeyboardNavRow(".."),
+				)
+
+				return
+			},
+			PrivateOnly: true,
+		},
+	)
+}
+
+func languageLabel(name string, chosen bool) string {
+	if chosen {
+		return "✅ " + name
+	}
+
+	return name
+}
+
+func setLanguage(lang string) api.CallbackAction {
+	return func(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+		if err := c.UserRepo.UpdateLanguage(c.User, lang); err != nil {
+			api.SendBasic(c.Bot, c.Chat.ID, c.T("Oops, something went wrong."))
+			return
+		}
+
+		// Reply in the new language rather than the one resolved for this update
+		now := c.Server.Lang(c.Chat, c.User)
+		mu := api.InlineKeyboard(
+			api.TranslateOptions(now, []map[string]string{{Title: Path}}),
+			fmt.Sprintf("user=%d", c.User.ID),
+		)
+
+		msg := botapi.NewEditMessageTextAndMarkup(c.Chat.ID, q.Message.MessageID, i18n.T(now, "✅ I'll speak %s", i18n.Name(now)), *mu)
+		api.SendUpdate(c.Bot, &msg)
+	}
+}
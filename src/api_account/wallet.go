@@ -2,11 +2,11 @@ package account
 
 import (
 	"fmt"
-	"sync"
 	"time"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/i18n"
 	"github.com/willmroliver/plathbot/src/model"
 	"github.com/willmroliver/plathbot/src/repo"
 	"gorm.io/gorm"
@@ -17,7 +17,9 @@ const (
 	WalletPath  = Path + "/wallet"
 )
 
-var walletsOpen = sync.Map{}
+func init() {
+	api.RegisterHookHandler("wallet", updateWallet)
+}
 
 func WalletAPI() *api.CallbackAPI {
 	return api.NewCallbackAPI(
@@ -65,22 +67,8 @@ func NewWallet(db *gorm.DB, query *botapi.CallbackQuery) *Wallet {
 	}
 }
 
-func OpenWallet(db *gorm.DB, query *botapi.CallbackQuery) (wallet *Wallet) {
-	walletsOpen.Range(func(key any, value any) bool {
-		if value.(*api.Interaction[any]).Age() > time.Minute*5 {
-			walletsOpen.Delete(key)
-		}
-
-		return true
-	})
-
-	if data, exists := walletsOpen.Load(query.From.ID); exists {
-		wallet = data.(*Wallet)
-	} else {
-		wallet = NewWallet(db, query)
-	}
-
-	return
+func OpenWallet(db *gorm.DB, query *botapi.CallbackQuery) *Wallet {
+	return NewWallet(db, query)
 }
 
 func (w *Wallet) User() *model.User {
@@ -91,42 +79,51 @@ func (w *Wallet) View(c *api.Context, query *botapi.CallbackQuery) {
 	api.SendBasic(c.Bot, query.Message.Chat.ID, w.User().PublicWallet)
 }
 
+// walletReply is the saved state of a pending wallet update.
+type walletReply struct {
+	Interaction *api.Interaction[string] `json:"interaction"`
+	User        *botapi.User             `json:"user"`
+}
+
 func (w *Wallet) Update(c *api.Context, query *botapi.CallbackQuery) {
 	w.Mutate("update", query.Message)
 
-	api.SendConfig(c.Bot, w.NewMessage("Okay! Send me a public wallet address to associate to your account.", nil))
+	api.SendConfig(c.Bot, w.NewMessage(c.T("Okay! Send me a public wallet address to associate to your account."), nil))
 
-	hook := api.NewMessageHook(func(s *api.Server, m *botapi.Message, data any) (done bool) {
-		done = true
-		wa := data.(*Wallet)
-
-		if !w.Is("update") {
-			return
-		}
+	hook := api.NewDurableHook("wallet", query.Message.Chat.ID, &walletReply{w.Interaction, w.user}, time.Minute*5)
+	c.Server.RegisterChatHook(query.Message.Chat.ID, hook.Named("wallet"))
+}
 
-		if err := wa.repo.UpdateWallet(wa.user, m.Text); err != nil {
-			api.SendConfig(s.Bot, wa.NewMessage("Something went wrong updating your wallet details", nil))
-		}
+func updateWallet(s *api.Server, m *botapi.Message, r *walletReply) (done bool) {
+	done = true
+	w := &Wallet{r.Interaction, repo.NewUserRepo(s.DB), r.User}
+	lang := s.Lang(m.Chat, m.From)
 
-		api.SendConfig(s.Bot, wa.NewMessage(
-			"✅ Saved",
-			api.InlineKeyboard([]map[string]string{{Title: Path}}, fmt.Sprintf("user=%d", w.user.ID)),
-		))
+	if !w.Is("update") {
+		return
+	}
 
+	if err := w.repo.UpdateWallet(w.user, m.Text); err != nil {
+		api.SendConfig(s.Bot, w.NewMessage(i18n.T(lang, "Something went wrong updating your wallet details"), nil))
 		return
-	}, w, time.Minute*5)
+	}
+
+	api.SendConfig(s.Bot, w.NewMessage(
+		i18n.T(lang, "✅ Saved"),
+		api.InlineKeyboard([]map[string]string{{i18n.T(lang, Title): Path}}, fmt.Sprintf("user=%d", w.user.ID)),
+	))
 
-	c.Server.RegisterChatHook(query.Message.Chat.ID, hook)
+	return
 }
 
 func (w *Wallet) Remove(c *api.Context, query *botapi.CallbackQuery) {
 	if err := w.repo.UpdateWallet(w.user, ""); err != nil {
-		api.SendBasic(c.Bot, query.Message.Chat.ID, "Something went wrong deleting your wallet details.")
+		api.SendBasic(c.Bot, query.Message.Chat.ID, c.T("Something went wrong deleting your wallet details."))
 		return
 	}
 
 	api.SendUpdate(c.Bot, w.NewMessageUpdate(
-		"✅ Deleted",
-		api.InlineKeyboard([]map[string]string{{Title: Path}}, fmt.Sprintf("user=%d", w.user.ID)),
+		c.T("✅ Deleted"),
+		c.InlineKeyboard([]map[string]string{{Title: Path}}, fmt.Sprintf("user=%d", w.user.ID)),
 	))
 }
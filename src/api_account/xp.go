@@ -1,8 +1,6 @@
 package account
 
 import (
-	"fmt"
-
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/willmroliver/plathbot/src/api"
 	"github.com/willmroliver/plathbot/src/service"
@@ -17,9 +15,9 @@ func XPQuery(c *api.Context, query *botapi.CallbackQuery, cmd *api.CallbackCmd)
 	msg := botapi.NewEditMessageText(
 		c.Chat.ID,
 		c.Message.MessageID,
-		fmt.Sprintf("📊 Current XP: %d", c.GetUser().UserXPMap[service.XPTitleEngage].XP),
+		c.T("📊 Current XP: %d", c.GetUser().TotalXP(service.XPTitleEngage).XP),
 	)
-	msg.ReplyMarkup = api.InlineKeyboard([]map[string]string{api.KeyboardNavRow(Path)})
+	msg.ReplyMarkup = c.InlineKeyboard([]map[string]string{api.KeyboardNavRow(Path)})
 
 	api.SendUpdate(c.Bot, &msg)
 }
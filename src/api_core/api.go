@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/willmroliver/plathbot/src/api"
 	"github.com/willmroliver/plathbot/src/db"
+	"github.com/willmroliver/plathbot/src/render"
 	"github.com/willmroliver/plathbot/src/util"
 )
 
@@ -17,6 +21,24 @@ const (
 	DonateLink string = "https://support.wwf.org.uk/"
 )
 
+var facts = []string{
+	"The collective noun for 'Platypus' is a 'Pandemonium'.",
+	"When European naturalist George Shaw was first presented with a platypus in the 1790s, he thought someone was pulling an elaborate prank.",
+	"Bizarrely, platypuses lack a traditional stomach that secretes hydrochloric acid or digestive juices.",
+	"Platypuses glow under a blacklight.",
+	"A baby platypus is called a 'Puggle'.",
+	"Platypuses can sense electrical fields.",
+	"Platypuses are one of only two egg-laying mammals.",
+	"Platypuses are venomous: Male platypuses have a hollow spur on each hind leg connected to a venom secreting gland.",
+	"Platypuses are thought to have evolved from one of Australia's oldest mammals, the Steropodon Galmani",
+	"The 20-cent coin in Australia has the image of a platypus on it.",
+	"Until the magazine National Geographic published a picture of a platypus in 1939, most of the world had never heard of the platypus.",
+	"The platypus will sometimes bury its bill into mud and then wiggle it to attract prey.",
+	"The platypus was one of the mascots for the 2000 Summer Olympics held in Sydney, Australia.",
+	"One nickname for the platypus is the duck mole because it resembles both of these species.",
+	"To date, the oldest platypus fossil found is over 100,000 years old.",
+}
+
 func NewServer() *api.Server {
 	dbn := os.Getenv("MOUNT_DIR") + "/" + os.Getenv("DB_NAME")
 
@@ -29,89 +51,101 @@ func NewServer() *api.Server {
 
 	s := api.NewServer(conn)
 
-	s.RegisterInlineAction("fact", requestFact)
-	s.RegisterInlineAction("adopt", requestAdopt)
-	s.RegisterInlineAction("donate", requestDonate)
+	s.RegisterInlineAction("fact", requestFact, api.InlineCache(time.Hour))
+	s.RegisterInlineAction("adopt", requestAdopt, api.InlineCache(time.Hour))
+	s.RegisterInlineAction("donate", requestDonate, api.InlineCache(time.Hour))
 
 	s.RegisterCommandAction("/start", func(c *api.Context, m *botapi.Message, args ...string) {
 		s.CallbackAPI.Expose(c, nil, nil)
 	})
 	s.RegisterCommandAction("/help", func(ctx *api.Context, m *botapi.Message, s ...string) {
 		ctx.Server.CallbackAPI.SendHelp(ctx, nil, nil)
-	})
+	}, api.Describe("List commands"))
 	s.RegisterCommandAction("/hub", func(c *api.Context, m *botapi.Message, args ...string) {
 		s.CallbackAPI.Expose(c, nil, nil)
-	})
+	}, api.Describe("Open the P1ath Hub"))
+
+	s.RegisterCommandAction("/fact", sendFact,
+		api.Describe("A platypus fact, at random or by number"),
+		api.Params(api.ArgInt("number").Opt()),
+	)
 
-	s.RegisterCommandAction("/fact", sendFact)
+	s.RegisterCallbackAPI(RolesAPI())
+	s.RegisterCallbackAPI(SettingsAPI())
+	s.RegisterCallbackAPI(JobsAPI())
 
 	s.RegisterCommandAction("/adopt", func(c *api.Context, m *botapi.Message, args ...string) {
-		if util.TryLockFor(fmt.Sprintf("%d adopt&donate", c.Chat.ID), time.Second*3) {
+		if util.TryLockFor(fmt.Sprintf("%d adopt&donate", c.Chat.ID), c.Cooldown(time.Second*3)) {
 			api.SendBasic(c.Bot, c.Chat.ID, AdoptLink)
 		}
-	})
+	}, api.Describe("Adopt a platypus"))
 	s.RegisterCommandAction("/donate", func(c *api.Context, m *botapi.Message, args ...string) {
-		if util.TryLockFor(fmt.Sprintf("%d adopt&donate", c.Chat.ID), time.Second*3) {
+		if util.TryLockFor(fmt.Sprintf("%d adopt&donate", c.Chat.ID), c.Cooldown(time.Second*3)) {
 			api.SendBasic(c.Bot, c.Chat.ID, DonateLink)
 		}
-	})
+	}, api.Describe("Donate to WWF"))
 
 	return s
 }
 
 func sendFact(c *api.Context, m *botapi.Message, args ...string) {
-	if c.Chat.Type != "private" && !util.TryLockFor(fmt.Sprintf("%d fact", c.Chat.ID), time.Second*5) {
+	if c.Chat.Type != "private" && !util.TryLockFor(fmt.Sprintf("%d fact", c.Chat.ID), c.Cooldown(time.Second*5)) {
 		return
 	}
 
-	if _, err := c.Bot.Send(botapi.NewMessage(
-		c.Chat.ID,
-		getFact(),
-	)); err != nil {
-		log.Printf("Got an error sending a fact: %q", err.Error())
-	}
+	api.SendLater(c.Bot, botapi.NewMessage(c.Chat.ID, c.T(getFact(int(c.Args.Int("number"))))))
 }
 
-func getFact() string {
-	facts := []string{
-		"The collective noun for 'Platypus' is a 'Pandemonium'.",
-		"When European naturalist George Shaw was first presented with a platypus in the 1790s, he thought someone was pulling an elaborate prank.",
-		"Bizarrely, platypuses lack a traditional stomach that secretes hydrochloric acid or digestive juices.",
-		"Platypuses glow under a blacklight.",
-		"A baby platypus is called a 'Puggle'.",
-		"Platypuses can sense electrical fields.",
-		"Platypuses are one of only two egg-laying mammals.",
-		"Platypuses are venomous: Male platypuses have a hollow spur on each hind leg connected to a venom secreting gland.",
-		"Platypuses are thought to have evolved from one of Australia's oldest mammals, the Steropodon Galmani",
-		"The 20-cent coin in Australia has the image of a platypus on it.",
-		"Until the magazine National Geographic published a picture of a platypus in 1939, most of the world had never heard of the platypus.",
-		"The platypus will sometimes bury its bill into mud and then wiggle it to attract prey.",
-		"The platypus was one of the mascots for the 2000 Summer Olympics held in Sydney, Australia.",
-		"One nickname for the platypus is the duck mole because it resembles both of these species.",
-		"To date, the oldest platypus fossil found is over 100,000 years old.",
+// getFact returns the nth fact, counting from 1, or a random one if n is out of range.
+// Facts are in English, and translated by the caller.
+func getFact(n int) string {
+	if n < 1 || n > len(facts) {
+		return facts[util.PseudoRandInt(len(facts), true)]
 	}
 
-	return facts[util.PseudoRandInt(len(facts), true)]
+	return facts[n-1]
 }
 
-func requestAdopt(c *api.Context, query *botapi.InlineQuery) {
-	api.RequestBasic(c.Bot, query, "Adopt a Platypus", AdoptLink)
+func requestAdopt(c *api.Context, query *botapi.InlineQuery, args ...string) []any {
+	return []any{linkArticle("adopt", c.T("Adopt a Platypus"), AdoptLink)}
 }
 
-func requestDonate(c *api.Context, query *botapi.InlineQuery) {
-	api.RequestBasic(c.Bot, query, "Donate to WWF", DonateLink)
+func requestDonate(c *api.Context, query *botapi.InlineQuery, args ...string) []any {
+	return []any{linkArticle("donate", c.T("Donate to WWF"), DonateLink)}
+}
+
+func linkArticle(id, title, link string) botapi.InlineQueryResultArticle {
+	a := botapi.NewInlineQueryResultArticleHTML(id, title, render.HTML.Escape(link))
+	a.Description = link
+
+	return a
 }
 
-func requestFact(c *api.Context, query *botapi.InlineQuery) {
-	text := "/plath@fact"
-	if query.ChatType == "private" {
-		text = "fact"
+// requestFact answers with the numbered fact, or the facts containing every word searched
+// for, in English or the user's language. With no search, every fact is listed.
+func requestFact(c *api.Context, query *botapi.InlineQuery, args ...string) (results []any) {
+	if len(args) == 1 {
+		if n, err := strconv.Atoi(args[0]); err == nil && n >= 1 && n <= len(facts) {
+			return []any{factArticle(c, n)}
+		}
 	}
 
-	c.Bot.Request(botapi.InlineConfig{
-		InlineQueryID: query.ID,
-		IsPersonal:    true,
-		CacheTime:     0,
-		Results:       []interface{}{botapi.NewInlineQueryResultArticle(query.ID, "Plath Fact!", text)},
-	})
+	for i, fact := range facts {
+		text := strings.ToLower(fact + "\n" + c.T(fact))
+
+		if !slices.ContainsFunc(args, func(word string) bool { return !strings.Contains(text, strings.ToLower(word)) }) {
+			results = append(results, factArticle(c, i+1))
+		}
+	}
+
+	return
+}
+
+func factArticle(c *api.Context, n int) botapi.InlineQueryResultArticle {
+	text := c.T(facts[n-1])
+
+	a := botapi.NewInlineQueryResultArticle(fmt.Sprintf("fact-%d", n), c.T("Fact #%d", n), text)
+	a.Description = text
+
+	return a
 }
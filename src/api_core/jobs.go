@@ -0,0 +1,175 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/render"
+)
+
+const (
+	JobsTitle = "🗓️ Jobs"
+	JobsPath  = "jobs"
+
+	jobsTimeFormat = "Jan 2 15:04 MST"
+
+	// jobsErrorLength is as much of a job's last error as is listed, so the list fits
+	jobsErrorLength = 200
+)
+
+// JobsAPI lets owners see the bot's scheduled jobs and how they last went, and pause,
+// resume or run them.
+func JobsAPI() *api.CallbackAPI {
+	opts := func() []map[string]string {
+		return []map[string]string{
+			{"👀 View": "view"},
+			api.KeyboardNavRow(".."),
+		}
+	}
+
+	return api.NewCallbackAPI(
+		JobsTitle,
+		JobsPath,
+		&api.CallbackConfig{
+			Description: "Pause, resume and run scheduled jobs",
+			Actions: map[string]api.CallbackAction{
+				"view":  viewJobs,
+				"pause": pauseJob,
+				"run":   runJob,
+			},
+			PublicOptions:  opts(),
+			PrivateOptions: opts(),
+			RequireRole:    api.RoleOwner,
+		},
+	)
+}
+
+// chosenJob returns the job named by the rest of the path, which may hold slashes.
+func chosenJob(c *api.Context, cc *api.CallbackCmd) (api.JobInfo, bool) {
+	name := cc.Tail()
+
+	for _, job := range c.Server.Scheduler.Jobs() {
+		if job.Name == name {
+			return job, true
+		}
+	}
+
+	return api.JobInfo{}, false
+}
+
+// pauseJob pauses the chosen job, or resumes it if it's paused.
+func pauseJob(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	job, ok := chosenJob(c, cc)
+	if !ok {
+		return
+	}
+
+	toggle := c.Server.Scheduler.Pause
+	if job.Paused {
+		toggle = c.Server.Scheduler.Resume
+	}
+
+	if err := toggle(job.Name); err != nil {
+		api.SendBasic(c.Bot, c.Chat.ID, c.T("Oops, something went wrong."))
+		return
+	}
+
+	viewJobs(c, q, cc)
+}
+
+func runJob(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	job, ok := chosenJob(c, cc)
+	if !ok {
+		return
+	}
+
+	if err := c.Server.Scheduler.RunNow(job.Name); err != nil {
+		api.SendBasic(c.Bot, c.Chat.ID, c.T("Oops, something went wrong."))
+		return
+	}
+
+	viewJobs(c, q, cc)
+}
+
+// viewJobs lists each job with its schedule and last outcome, with buttons to pause or
+// resume it and to run it now.
+func viewJobs(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	jobs := c.Server.Scheduler.Jobs()
+
+	text := &strings.Builder{}
+	text.WriteString(string(render.HTML.Bold(c.T(JobsTitle))) + "\n\n")
+
+	if len(jobs) == 0 {
+		text.WriteString(render.HTML.Escape(c.T("No jobs scheduled.")))
+	}
+
+	kb := make([]map[string]string, 0, len(jobs)+1)
+
+	for _, job := range jobs {
+		text.WriteString(string(jobText(c, job)) + "\n\n")
+
+		toggle := "⏸️ " + job.Name
+		if job.Paused {
+			toggle = "▶️ " + job.Name
+		}
+
+		kb = append(kb, map[string]string{
+			toggle:           fmt.Sprintf("%s/pause/%s", JobsPath, job.Name),
+			c.T("⚡ Run now"): fmt.Sprintf("%s/run/%s", JobsPath, job.Name),
+		})
+	}
+
+	kb = append(kb, api.KeyboardNavRow(JobsPath))
+
+	m := botapi.NewEditMessageTextAndMarkup(c.Chat.ID, q.Message.MessageID, text.String(), *c.InlineKeyboard(kb, fmt.Sprintf("user=%d", c.User.ID)))
+	m.ParseMode = botapi.ModeHTML
+	api.SendUpdate(c.Bot, &m)
+}
+
+func jobText(c *api.Context, job api.JobInfo) render.Markup {
+	lines := []render.Markup{
+		render.HTML.Bold(job.Name) + " " + render.HTML.Code(job.Schedule),
+	}
+
+	switch {
+	case job.Running:
+		lines = append(lines, render.HTML.Text(c.T("🏃 Running now")))
+	case job.Paused:
+		lines = append(lines, render.HTML.Text(c.T("⏸️ Paused")))
+	case !job.NextRun.IsZero():
+		lines = append(lines, render.HTML.Sprintf(c.T("Next run: %s"), job.NextRun.Local().Format(jobsTimeFormat)))
+	}
+
+	if job.LastRun.IsZero() {
+		lines = append(lines, render.HTML.Text(c.T("Not run yet.")))
+	} else {
+		outcome := "✅"
+		if job.LastError != "" {
+			outcome = "❌"
+		}
+
+		lines = append(lines, render.HTML.Sprintf(c.T("Last run: %s %s, took %s"),
+			outcome,
+			job.LastRun.Local().Format(jobsTimeFormat),
+			job.LastDuration.Round(time.Millisecond),
+		))
+
+		if err := []rune(job.LastError); len(err) > jobsErrorLength {
+			lines = append(lines, render.HTML.Italic(string(err[:jobsErrorLength])+"…"))
+		} else if len(err) > 0 {
+			lines = append(lines, render.HTML.Italic(job.LastError))
+		}
+	}
+
+	lines = append(lines, render.HTML.Sprintf(c.T("Runs: %d, failures: %d, skips: %d"), job.Runs, job.Failures, job.Skips))
+
+	res := make([]string, len(lines))
+	for i, line := range lines {
+		res[i] = string(line)
+	}
+
+	return render.Markup(strings.Join(res, "\n"))
+}
@@ -0,0 +1,69 @@
+package core
+
+import (
+	"strings"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/i18n"
+)
+
+func init() {
+	api.RegisterCommandAction("/language", setLanguage,
+		api.Describe("Choose the language I reply in"),
+		api.Params(api.ArgWord("code").Opt()),
+	)
+}
+
+// setLanguage sets the chat's language in groups, which only admins may do, or the user's
+// own in private. "auto" clears it. Without a code, the languages available are listed.
+func setLanguage(c *api.Context, m *botapi.Message, args ...string) {
+	group := c.Chat.Type != "private"
+	if group && !c.HasRole(api.RoleAdmin) {
+		return
+	}
+
+	code := strings.ToLower(c.Args.String("code"))
+	if code == "" {
+		api.SendBasic(c.Bot, c.Chat.ID, c.T("Languages: %s\nUse e.g. /language es, or /language auto to follow each user's Telegram app.", languageList()))
+		return
+	}
+
+	lang := ""
+	if code != "auto" {
+		if lang = i18n.Match(code); lang == "" {
+			api.SendBasic(c.Bot, c.Chat.ID, c.T("I don't speak %q yet. Languages: %s", code, languageList()))
+			return
+		}
+	}
+
+	var err error
+
+	if group {
+		r := c.Server.SettingsRepo()
+		settings := *r.Get(c.Chat.ID)
+		settings.Language = lang
+		err = r.Save(&settings)
+	} else {
+		err = c.UserRepo.UpdateLanguage(c.User, lang)
+	}
+
+	if err != nil {
+		api.SendBasic(c.Bot, c.Chat.ID, c.T("Oops, something went wrong."))
+		return
+	}
+
+	now := c.Server.Lang(c.Chat, c.User)
+	api.SendBasic(c.Bot, c.Chat.ID, i18n.T(now, "✅ I'll speak %s", i18n.Name(now)))
+}
+
+func languageList() string {
+	langs := i18n.Languages()
+	names := make([]string, len(langs))
+
+	for i, l := range langs {
+		names[i] = l.Lang + " (" + l.Name + ")"
+	}
+
+	return strings.Join(names, ", ")
+}
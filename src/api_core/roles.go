@@ -0,0 +1,209 @@
+package core
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/i18n"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/repo"
+)
+
+const (
+	RolesTitle = "🛡️ Roles"
+	RolesPath  = "roles"
+
+	grantRoleText = `
+Reply to this with the user's username and the role to grant. E.g:
+
+'@plathfan moderator'
+
+Roles are 'moderator', 'admin' (owners only), or a custom name of up to 16 letters, digits or underscores.
+	`
+)
+
+func init() {
+	api.RegisterHookHandler("roles_grant", grantRoleReply)
+}
+
+func RolesAPI() *api.CallbackAPI {
+	opts := func() []map[string]string {
+		return []map[string]string{
+			{"👀 View": "view"},
+			{"➕ Grant": "grant"},
+			api.KeyboardNavRow(".."),
+		}
+	}
+
+	return api.NewCallbackAPI(
+		RolesTitle,
+		RolesPath,
+		&api.CallbackConfig{
+			Description: "Grant and revoke roles",
+			Actions: map[string]api.CallbackAction{
+				"view":   viewRoles,
+				"grant":  grantRole,
+				"revoke": revokeRole,
+			},
+			PublicOptions:  opts(),
+			PrivateOptions: opts(),
+			RequireRole:    api.RoleAdmin,
+		},
+	)
+}
+
+// rolesChat is the chat roles are managed for. Roles managed from a private chat apply
+// in every chat, which only owners can reach.
+func rolesChat(c *api.Context) int64 {
+	if c.Chat.Type == "private" {
+		return 0
+	}
+
+	return c.Chat.ID
+}
+
+// canManage reports whether the user may grant or revoke role. Only owners manage admins.
+func canManage(c *api.Context, role api.Role) bool {
+	return canGrant(role, c.HasRole(api.RoleOwner))
+}
+
+func canGrant(role api.Role, owner bool) bool {
+	return role != api.RoleOwner && (role.Rank() < api.RoleAdmin.Rank() || owner)
+}
+
+func viewRoles(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	tag := fmt.Sprintf("user=%d", c.User.ID)
+
+	roles := repo.NewUserRoleRepo(c.Server.DB).Chat(rolesChat(c))
+	if roles == nil {
+		api.SendBasic(c.Bot, c.Chat.ID, c.T("Error fetching roles."))
+		return
+	}
+
+	text := &strings.Builder{}
+	text.WriteString(c.T(RolesTitle) + "\n\n")
+
+	if len(roles) == 0 {
+		text.WriteString(c.T("No roles granted yet."))
+	}
+
+	names := roleUserNames(c, roles)
+	kb := make([]map[string]string, 0, len(roles)+1)
+
+	for _, r := range roles {
+		name := names[r.UserID]
+		text.WriteString(fmt.Sprintf("%s - %s\n", name, r.Role))
+
+		if canManage(c, api.Role(r.Role)) {
+			kb = append(kb, map[string]string{
+				fmt.Sprintf("➖ %s %s", name, r.Role): fmt.Sprintf("%s/revoke/%d/%s", RolesPath, r.UserID, r.Role),
+			})
+		}
+	}
+
+	kb = append(kb, api.KeyboardNavRow(RolesPath))
+
+	m := botapi.NewEditMessageTextAndMarkup(c.Chat.ID, q.Message.MessageID, text.String(), *c.InlineKeyboard(kb, tag))
+	api.SendUpdate(c.Bot, &m)
+}
+
+func revokeRole(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	userID, err := strconv.ParseInt(cc.Get(), 10, 64)
+	if err != nil {
+		return
+	}
+
+	role := api.Role(cc.Next().Get())
+	if !canManage(c, role) {
+		return
+	}
+
+	repo.NewUserRoleRepo(c.Server.DB).Revoke(userID, rolesChat(c), string(role))
+	viewRoles(c, q, cc)
+}
+
+func grantRole(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	chatID, grantedBy := rolesChat(c), c.User.ID
+
+	m := botapi.NewEditMessageText(c.Chat.ID, q.Message.MessageID, c.T(grantRoleText))
+	api.SendUpdate(c.Bot, &m)
+
+	grant := &roleGrant{chatID, grantedBy, c.HasRole(api.RoleOwner)}
+	hook := api.NewDurableHook("roles_grant", c.Chat.ID, grant, time.Minute*5)
+
+	c.Server.RegisterUserHook(c.User.ID, hook.Named("roles_grant").ReplyingTo(q.Message))
+}
+
+// roleGrant is the saved state of a pending grant.
+type roleGrant struct {
+	ChatID    int64 `json:"chat_id"`
+	GrantedBy int64 `json:"granted_by"`
+	Owner     bool  `json:"owner"`
+}
+
+// grantRoleReply grants the role named in a reply to the prompt. The grant is finished
+// either way, so the admin can start again from the menu.
+func grantRoleReply(s *api.Server, m *botapi.Message, g *roleGrant) (done bool) {
+	done = true
+	lang := s.Lang(m.Chat, m.From)
+
+	target, arg := parseGrant(s, m)
+	if target == nil {
+		api.SendBasic(s.Bot, m.Chat.ID, i18n.T(lang, "I don't know that user yet."))
+		return
+	}
+
+	role, ok := api.ParseRole(arg)
+	if !ok || !canGrant(role, g.Owner) {
+		api.SendBasic(s.Bot, m.Chat.ID, i18n.T(lang, "That role can't be granted."))
+		return
+	}
+
+	if repo.NewUserRoleRepo(s.DB).Grant(target.ID, g.ChatID, string(role), g.GrantedBy) != nil {
+		api.SendBasic(s.Bot, m.Chat.ID, i18n.T(lang, "Oops, something went wrong."))
+	} else {
+		api.SendBasic(s.Bot, m.Chat.ID, i18n.T(lang, "✅ %s is now %s", target.DisplayName(), role))
+	}
+
+	return
+}
+
+// parseGrant reads the username and role name from a grant reply, returning a nil user if
+// not known.
+func parseGrant(s *api.Server, m *botapi.Message) (user *model.User, role string) {
+	fields := strings.Fields(m.Text)
+	if len(fields) != 2 {
+		return
+	}
+
+	if found := repo.NewUserRepo(s.DB).AllWhere("username = ?", strings.TrimPrefix(fields[0], "@")); len(found) != 0 {
+		user = found[0]
+	}
+
+	return user, fields[1]
+}
+
+func roleUserNames(c *api.Context, roles []*model.UserRole) map[int64]string {
+	ids := make([]int64, len(roles))
+	for i, r := range roles {
+		ids[i] = r.UserID
+	}
+
+	names := map[int64]string{}
+
+	for _, u := range repo.NewUserRepo(c.Server.DB).AllWhere("id IN ?", ids) {
+		names[u.ID] = u.DisplayName()
+	}
+
+	for _, id := range ids {
+		if _, ok := names[id]; !ok {
+			names[id] = fmt.Sprintf("%d", id)
+		}
+	}
+
+	return names
+}
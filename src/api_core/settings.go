@@ -0,0 +1,268 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/model"
+)
+
+const (
+	SettingsTitle = "⚙️ Settings"
+	SettingsPath  = "settings"
+
+	// settingsDefault clears a setting, going back to the default.
+	settingsDefault = "default"
+)
+
+var (
+	cooldownChoices = []time.Duration{0, time.Second * 3, time.Second * 10, time.Second * 30, time.Minute}
+	xpChoices       = []int64{0, 5, 10, 25, 50, 100, 250, 500}
+
+	xpKinds = []model.XPKind{model.XPMessage, model.XPReaction, model.XPGameWin}
+	xpNames = map[model.XPKind]string{
+		model.XPMessage:  "💬 Messages",
+		model.XPReaction: "👍 Reactions",
+		model.XPGameWin:  "🏆 Game wins",
+	}
+)
+
+// SettingsAPI lets admins tune how the bot behaves in their chat.
+func SettingsAPI() *api.CallbackAPI {
+	opts := func() []map[string]string {
+		return []map[string]string{
+			{"🧩 Modules": "modules"},
+			{"⏱️ Cooldown": "cooldown"},
+			{"📈 XP": "xp"},
+			{"🏆 Champions": "champions"},
+			api.KeyboardNavRow(".."),
+		}
+	}
+
+	return api.NewCallbackAPI(
+		SettingsTitle,
+		SettingsPath,
+		&api.CallbackConfig{
+			Description: "Turn modules on or off, tune cooldowns and XP, and post champions",
+			Actions: map[string]api.CallbackAction{
+				"modules":   moduleSettings,
+				"cooldown":  cooldownSettings,
+				"xp":        xpSettings,
+				"champions": championSettings,
+			},
+			PublicOptions:  opts(),
+			PrivateOptions: opts(),
+			RequireRole:    api.RoleAdmin,
+		},
+	)
+}
+
+// module is a menu or command which can be turned off.
+type module struct {
+	key, label string
+}
+
+// modules lists the menus then commands registered with the server, leaving out this menu
+// so that admins can't lock themselves out of it.
+func modules(c *api.Context) (res []module) {
+	for _, a := range c.Server.CallbackAPIs() {
+		if a.Path != SettingsPath {
+			res = append(res, module{a.Path, c.T(a.Title)})
+		}
+	}
+
+	slices.SortFunc(res, func(a, b module) int {
+		return strings.Compare(a.key, b.key)
+	})
+
+	for _, cmd := range slices.Sorted(maps.Keys(c.Server.CommandAPI.Actions)) {
+		res = append(res, module{cmd, cmd})
+	}
+
+	return
+}
+
+// moduleSettings lists each module with its state, toggling the one chosen by the rest of
+// the path, its key. Keys may hold slashes, as commands begin with one.
+func moduleSettings(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	mods := modules(c)
+
+	if key := cc.Tail(); slices.ContainsFunc(mods, func(m module) bool { return m.key == key }) {
+		if !saveSettings(c, func(s *model.ChatSettings) { s.SetEnabled(key, !s.Enabled(key)) }) {
+			return
+		}
+	}
+
+	settings := c.Settings()
+	kb := make([]map[string]string, 0, len(mods)+1)
+
+	for _, m := range mods {
+		state := "✅ "
+		if !settings.Enabled(m.key) {
+			state = "🚫 "
+		}
+
+		kb = append(kb, map[string]string{state + m.label: fmt.Sprintf("%s/modules/%s", SettingsPath, m.key)})
+	}
+
+	kb = append(kb, api.KeyboardNavRow(SettingsPath))
+
+	text := c.T("🧩 Modules") + "\n\n" + c.T("Tap a menu or command to turn it on or off in this chat.")
+	sendSettings(c, q, text, kb)
+}
+
+// cooldownSettings shows the chat's cooldown, setting it to the choice made, if any.
+func cooldownSettings(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	if choice := cc.Get(); choice != "" {
+		var cooldown *time.Duration
+
+		if choice != settingsDefault {
+			d, err := time.ParseDuration(choice)
+			if err != nil || d < 0 {
+				return
+			}
+
+			cooldown = &d
+		}
+
+		if !saveSettings(c, func(s *model.ChatSettings) { s.Cooldown = cooldown }) {
+			return
+		}
+	}
+
+	current := c.Settings().Cooldown
+
+	kb := []map[string]string{{
+		settingsLabel(c.T("↩️ Default"), current == nil): fmt.Sprintf("%s/cooldown/%s", SettingsPath, settingsDefault),
+	}}
+
+	for _, d := range cooldownChoices {
+		label := d.String()
+		if d == 0 {
+			label = c.T("Off")
+		}
+
+		kb = append(kb, map[string]string{
+			settingsLabel(label, current != nil && *current == d): fmt.Sprintf("%s/cooldown/%s", SettingsPath, d),
+		})
+	}
+
+	kb = append(kb, api.KeyboardNavRow(SettingsPath))
+
+	text := c.T("⏱️ Cooldown") + "\n\n" + c.T("How long menus and commands wait, once used in this chat, before they can be used again.")
+	sendSettings(c, q, text, kb)
+}
+
+// xpSettings lists the XP each kind earns in the chat. Choosing a kind offers amounts
+// to set it to, and choosing one of those saves it.
+func xpSettings(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	kind := model.XPKind(cc.Get())
+	if _, ok := xpNames[kind]; !ok {
+		xpKindSettings(c, q)
+		return
+	}
+
+	if choice := cc.Next().Get(); choice != "" {
+		var xp *int64
+
+		if choice != settingsDefault {
+			n, err := strconv.ParseInt(choice, 10, 64)
+			if err != nil || n < 0 {
+				return
+			}
+
+			xp = &n
+		}
+
+		if saveSettings(c, func(s *model.ChatSettings) { s.SetXP(kind, xp) }) {
+			xpKindSettings(c, q)
+		}
+
+		return
+	}
+
+	settings := c.Settings()
+	set := settings.OverridesXP(kind)
+
+	kb := []map[string]string{{
+		settingsLabel(c.T("↩️ Default (%d)", model.DefaultXP[kind]), !set): fmt.Sprintf("%s/xp/%s/%s", SettingsPath, kind, settingsDefault),
+	}}
+
+	for _, n := range xpChoices {
+		kb = append(kb, map[string]string{
+			settingsLabel(strconv.FormatInt(n, 10), set && settings.XP(kind) == n): fmt.Sprintf("%s/xp/%s/%d", SettingsPath, kind, n),
+		})
+	}
+
+	kb = append(kb, api.KeyboardNavRow(SettingsPath+"/xp"))
+
+	sendSettings(c, q, c.T("📈 XP")+"\n\n"+c.T(xpNames[kind]), kb)
+}
+
+func xpKindSettings(c *api.Context, q *botapi.CallbackQuery) {
+	settings := c.Settings()
+	kb := make([]map[string]string, 0, len(xpKinds)+1)
+
+	for _, kind := range xpKinds {
+		kb = append(kb, map[string]string{
+			fmt.Sprintf("%s: %d", c.T(xpNames[kind]), settings.XP(kind)): fmt.Sprintf("%s/xp/%s", SettingsPath, kind),
+		})
+	}
+
+	kb = append(kb, api.KeyboardNavRow(SettingsPath))
+
+	sendSettings(c, q, c.T("📈 XP")+"\n\n"+c.T("Choose how much XP is earned in this chat."), kb)
+}
+
+// championSettings shows whether the chat gets champions posts, turning them on or off
+// as chosen, if at all.
+func championSettings(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	if choice := cc.Get(); choice == "on" || choice == "off" {
+		if !saveSettings(c, func(s *model.ChatSettings) { s.Champions = choice == "on" }) {
+			return
+		}
+	}
+
+	on := c.Settings().Champions
+
+	kb := []map[string]string{
+		{settingsLabel(c.T("On"), on): SettingsPath + "/champions/on"},
+		{settingsLabel(c.T("Off"), !on): SettingsPath + "/champions/off"},
+		api.KeyboardNavRow(SettingsPath),
+	}
+
+	text := c.T("🏆 Champions") + "\n\n" + c.T("Post the top scorers in this chat as each week and month ends.")
+	sendSettings(c, q, text, kb)
+}
+
+// saveSettings applies change to a copy of the chat's settings and saves it, reporting success.
+func saveSettings(c *api.Context, change func(*model.ChatSettings)) bool {
+	settings := *c.Settings()
+	change(&settings)
+
+	if err := c.Server.SettingsRepo().Save(&settings); err != nil {
+		api.SendBasic(c.Bot, c.Chat.ID, c.T("Oops, something went wrong."))
+		return false
+	}
+
+	return true
+}
+
+func sendSettings(c *api.Context, q *botapi.CallbackQuery, text string, kb []map[string]string) {
+	m := botapi.NewEditMessageTextAndMarkup(c.Chat.ID, q.Message.MessageID, text, *api.InlineKeyboard(kb, fmt.Sprintf("user=%d", c.User.ID)))
+	api.SendUpdate(c.Bot, &m)
+}
+
+func settingsLabel(label string, chosen bool) string {
+	if chosen {
+		return "✅ " + label
+	}
+
+	return label
+}
@@ -1,16 +1,13 @@
-//go:build emoji
-// +build emoji
-
 package emoji
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/i18n"
+	"github.com/willmroliver/plathbot/src/render"
 	"github.com/willmroliver/plathbot/src/repo"
 	"github.com/willmroliver/plathbot/src/service"
 	"github.com/willmroliver/plathbot/src/util"
@@ -24,26 +21,30 @@ const (
 	AdminPath  = Path + "/admin"
 )
 
-var open = sync.Map{}
+func init() {
+	api.RegisterHookHandler("emoji_update", updateEmoji)
+	api.RegisterHookHandler("emoji_remove", removeEmoji)
+}
 
 func AdminAPI() *api.CallbackAPI {
 	return api.NewCallbackAPI(
 		AdminTitle,
 		AdminPath,
 		&api.CallbackConfig{
+			Description: "Manage tracked emoji reactions",
 			Actions: map[string]api.CallbackAction{
 				"view": func(c *api.Context, cq *botapi.CallbackQuery, cc *api.CallbackCmd) {
-					if a := OpenAdmin(c, cq, cc); a != nil {
+					if a := OpenAdmin(c, cq); a != nil {
 						a.View(c, cq)
 					}
 				},
 				"update": func(c *api.Context, cq *botapi.CallbackQuery, cc *api.CallbackCmd) {
-					if a := OpenAdmin(c, cq, cc); a != nil {
+					if a := OpenAdmin(c, cq); a != nil {
 						a.Update(c, cq)
 					}
 				},
 				"remove": func(c *api.Context, cq *botapi.CallbackQuery, cc *api.CallbackCmd) {
-					if a := OpenAdmin(c, cq, cc); a != nil {
+					if a := OpenAdmin(c, cq); a != nil {
 						a.Remove(c, cq)
 					}
 				},
@@ -53,7 +54,8 @@ func AdminAPI() *api.CallbackAPI {
 				{"👀 View": "view", "🗑️ Remove": "remove"},
 				api.KeyboardNavRow(".."),
 			},
-			PublicOnly: true,
+			PublicOnly:  true,
+			RequireRole: api.RoleAdmin,
 		},
 	)
 }
@@ -72,32 +74,11 @@ func NewAdmin(db *gorm.DB, q *botapi.CallbackQuery) *Admin {
 	}
 }
 
-func OpenAdmin(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) (admin *Admin) {
-	if u, ok := cc.Tags["user"]; ok {
-		if id, _ := strconv.ParseInt(u, 10, 64); id != q.From.ID {
-			return
-		}
-	}
-
-	if !c.IsAdmin() {
-		return
-	}
-
-	open.Range(func(key any, value any) bool {
-		if value.(*api.Interaction[any]).Age() > time.Minute*5 {
-			open.Delete(key)
-		}
-
-		return true
-	})
-
-	if data, exists := open.Load(q.From.ID); exists {
-		admin = data.(*Admin)
-	} else {
-		admin = NewAdmin(c.Server.DB, q)
-	}
-
-	return
+// OpenAdmin wraps the menu message pressed in an admin session. Update and Remove save it
+// with the reply hook they register, so a pending reply can still edit the menu after a
+// restart. The Admin API's RequireRole has checked access by now.
+func OpenAdmin(c *api.Context, q *botapi.CallbackQuery) *Admin {
+	return NewAdmin(c.Server.DB, q)
 }
 
 func (a *Admin) View(c *api.Context, query *botapi.CallbackQuery) {
@@ -105,76 +86,84 @@ func (a *Admin) View(c *api.Context, query *botapi.CallbackQuery) {
 	tracked := reactRepo.All()
 
 	text := &strings.Builder{}
-	text.WriteString("Currently tracked:\n\n")
+	text.WriteString(render.HTML.Escape(c.T("Currently tracked:")) + "\n\n")
 
 	for _, react := range tracked {
-		text.WriteString(react.Emoji + " - " + react.Title + "\n")
+		text.WriteString(string(render.HTML.Sprintf("%s - %s\n", react.Emoji, react.Title)))
 	}
 
-	api.SendUpdate(c.Bot, a.NewMessageUpdate(text.String(), api.InlineKeyboard([]map[string]string{
+	api.SendUpdate(c.Bot, a.NewMessageUpdate(text.String(), c.InlineKeyboard([]map[string]string{
 		api.KeyboardNavRow(AdminPath),
 	}, fmt.Sprintf("user=%d", a.user.ID))))
 }
 
-func (a *Admin) Update(c *api.Context, query *botapi.CallbackQuery) {
-	api.SendBasic(c.Bot, c.Chat.ID, `
-Okay, send the emoji you'd like to update and give it a title, space-separated.
-E.g: '💸 High-flyer'`)
+// adminReply is the saved state of a pending update or removal.
+type adminReply struct {
+	Interaction *api.Interaction[string] `json:"interaction"`
+	UserID      int64                    `json:"user_id"`
+}
 
-	hook := api.NewMessageHook(func(s *api.Server, m *botapi.Message, data any) (done bool) {
-		done = true
+func (a *Admin) Update(c *api.Context, query *botapi.CallbackQuery) {
+	prompt, _ := api.SendBasic(c.Bot, c.Chat.ID, c.T(`
+Okay, reply to this with the emoji you'd like to update and give it a title, space-separated.
+E.g: '💸 High-flyer'`))
 
-		ad := data.(*Admin)
+	hook := api.NewDurableHook("emoji_update", c.Chat.ID, &adminReply{a.Interaction, a.user.ID}, time.Minute*5)
+	c.Server.RegisterUserHook(c.User.ID, hook.Named("emoji_update").ReplyingTo(prompt))
+}
 
-		i := strings.Index(m.Text, " ")
-		if i == -1 || !util.IsEmoji(m.Text[:i]) || i+1 == len(m.Text) {
-			return
-		}
+func updateEmoji(s *api.Server, m *botapi.Message, r *adminReply) (done bool) {
+	done = true
 
-		e, t := util.NormalizeEmoji(m.Text[:i]), m.Text[i+1:]
-		if ad.service.ReactRepo.Save(e, t) != nil {
-			return
-		}
+	i := strings.Index(m.Text, " ")
+	if i == -1 || !util.IsEmoji(m.Text[:i]) || i+1 == len(m.Text) {
+		return
+	}
 
-		mu := api.InlineKeyboard([]map[string]string{
-			api.KeyboardNavRow(AdminPath),
-		}, fmt.Sprintf("user=%d", ad.user.ID))
+	e, t := util.NormalizeEmoji(m.Text[:i]), m.Text[i+1:]
+	if service.NewReactService(s.DB).ReactRepo.Save(e, t) != nil {
+		return
+	}
 
-		api.SendConfig(s.Bot, ad.NewMessage(fmt.Sprintf("%s saved as %q", e, t), mu))
+	lang := s.Lang(m.Chat, m.From)
+	mu := api.InlineKeyboard(api.TranslateOptions(lang, []map[string]string{
+		api.KeyboardNavRow(AdminPath),
+	}), fmt.Sprintf("user=%d", r.UserID))
 
-		return
-	}, a, time.Minute*5)
+	api.SendConfig(s.Bot, r.Interaction.NewMessage(string(render.HTML.Sprintf(i18n.T(lang, "%s saved as %q"), e, t)), mu))
 
-	c.Server.RegisterUserHook(c.User.ID, hook)
+	return
 }
 
 func (a *Admin) Remove(c *api.Context, query *botapi.CallbackQuery) {
-	api.SendBasic(c.Bot, c.Chat.ID, `
-Okay, send the emoji you'd like to stop tracking.`)
+	prompt, _ := api.SendBasic(c.Bot, c.Chat.ID, c.T(`
+Okay, reply to this with the emoji you'd like to stop tracking.`))
 
-	hook := api.NewMessageHook(func(s *api.Server, m *botapi.Message, userID any) (done bool) {
-		done = true
+	hook := api.NewDurableHook("emoji_remove", c.Chat.ID, &adminReply{a.Interaction, c.User.ID}, time.Minute*5)
+	c.Server.RegisterUserHook(c.User.ID, hook.Named("emoji_remove").ReplyingTo(prompt))
+}
 
-		e := m.Text
+func removeEmoji(s *api.Server, m *botapi.Message, r *adminReply) (done bool) {
+	done = true
 
-		if i := strings.Index(m.Text, " "); i > 0 {
-			if e = m.Text[:i]; !util.IsEmoji(e) {
-				return
-			}
-		}
+	e := m.Text
 
-		if reactService := service.NewReactService(s.DB); reactService.Untrack(e) != nil {
+	if i := strings.Index(m.Text, " "); i > 0 {
+		if e = m.Text[:i]; !util.IsEmoji(e) {
 			return
 		}
+	}
 
-		mu := api.InlineKeyboard([]map[string]string{
-			api.KeyboardNavRow(AdminPath),
-		}, fmt.Sprintf("user=%d", userID))
+	if reactService := service.NewReactService(s.DB); reactService.Untrack(e) != nil {
+		return
+	}
 
-		api.SendConfig(s.Bot, a.NewMessage(e+" removed", mu))
+	lang := s.Lang(m.Chat, m.From)
+	mu := api.InlineKeyboard(api.TranslateOptions(lang, []map[string]string{
+		api.KeyboardNavRow(AdminPath),
+	}), fmt.Sprintf("user=%d", r.UserID))
 
-		return
-	}, c.User.ID, time.Minute*5)
+	api.SendConfig(s.Bot, r.Interaction.NewMessage(string(render.HTML.Sprintf(i18n.T(lang, "%s removed"), e)), mu))
 
-	c.Server.RegisterUserHook(c.User.ID, hook)
+	return
 }
@@ -1,6 +1,3 @@
-//go:build emoji
-// +build emoji
-
 package emoji
 
 import (
@@ -20,7 +17,12 @@ var (
 )
 
 func init() {
-	api.RegisterCallbackAPI(Path, API)
+	api.RegisterModule(&api.Module{
+		Name: "emoji",
+		Setup: func(s *api.Server) {
+			s.RegisterCallbackAPI(API())
+		},
+	})
 }
 
 func API() *api.CallbackAPI {
@@ -28,6 +30,7 @@ func API() *api.CallbackAPI {
 		Title,
 		Path,
 		&api.CallbackConfig{
+			Description: "Emoji reaction rankings",
 			Actions: map[string]api.CallbackAction{
 				"table": tableAPI.Select,
 				"admin": adminAPI.Select,
@@ -1,13 +1,13 @@
-//go:build emoji && stats
-// +build emoji,stats
-
 package stats
 
 import (
+	"github.com/willmroliver/plathbot/src/api"
 	emoji "github.com/willmroliver/plathbot/src/api_emoji"
 	stats "github.com/willmroliver/plathbot/src/api_stats"
 )
 
 func init() {
-	stats.Extensions.ExtendAPI(emoji.Title, stats.Path+"/"+emoji.Path, emoji.API().Select)
+	api.RegisterIntegration("emoji", stats.Path, func(s *api.Server) {
+		s.FindCallbackAPI(stats.Path).Extend(emoji.Title, emoji.Path, emoji.API().Select)
+	})
 }
@@ -1,6 +1,3 @@
-//go:build emoji
-// +build emoji
-
 package emoji
 
 import (
@@ -10,6 +7,7 @@ import (
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/willmroliver/plathbot/src/api"
 	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/render"
 	"github.com/willmroliver/plathbot/src/repo"
 )
 
@@ -39,49 +37,63 @@ func TableAPI() *api.CallbackAPI {
 	)
 }
 
+// scoresGlobal is appended to a leaderboard's path to show every chat's counts combined.
+const scoresGlobal = "global"
+
 func getAll(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	global := cc.Get() == scoresGlobal
+
 	r := repo.NewReactCountRepo(c.Server.DB)
-	sendTable(c, "⏳ All-Time Leaderboard", r.TopCounts())
+	sendTable(c, "all", global, "⏳ All-Time Leaderboard", r.TopCounts(c.ScoresChatID(global)))
 }
 
 func getMonthly(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	global := cc.Get() == scoresGlobal
+
 	r := repo.NewReactCountRepo(c.Server.DB)
-	sendTable(c, "📆 Monthly Leaderboard", r.TopMonthly())
+	sendTable(c, "month", global, "📆 Monthly Leaderboard", r.TopMonthly(c.ScoresChatID(global)))
 }
 
 func getWeekly(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	global := cc.Get() == scoresGlobal
+
 	r := repo.NewReactCountRepo(c.Server.DB)
-	sendTable(c, "📰 Weekly Leaderboard", r.TopWeekly())
+	sendTable(c, "week", global, "📰 Weekly Leaderboard", r.TopWeekly(c.ScoresChatID(global)))
 }
 
-func sendTable(c *api.Context, title string, data []*model.ReactCount) {
+func sendTable(c *api.Context, period string, global bool, title string, data []*model.ReactCount) {
 	r := repo.NewReactRepo(c.Server.DB)
 
 	text := &strings.Builder{}
-	text.WriteString(title + "\n\n")
+	text.WriteString(render.HTML.Escape(c.T(title)) + "\n\n")
 
 	for _, count := range data {
 		if react := r.Get(count.Emoji); react != nil {
-			text.WriteString(fmt.Sprintf(
+			text.WriteString(string(render.HTML.Sprintf(
 				"%s %d\t %s - %s\n",
 				count.Emoji,
 				count.Count,
 				count.User.AtString(),
 				react.Title,
-			))
+			)))
 		}
 	}
 
+	kb := []map[string]string{}
+	if toggle := c.ScopeToggle(global, TablePath+"/"+period, TablePath+"/"+period+"/"+scoresGlobal); toggle != nil {
+		kb = append(kb, toggle)
+	}
+
 	msg := botapi.NewEditMessageTextAndMarkup(
 		c.Chat.ID,
 		c.Message.MessageID,
 		text.String(),
-		*api.InlineKeyboard(
-			[]map[string]string{api.KeyboardNavRow(TablePath)},
+		*c.InlineKeyboard(
+			append(kb, api.KeyboardNavRow(TablePath)),
 			fmt.Sprintf("user=%d", c.User.ID),
 		),
 	)
-	msg.ParseMode = "Markdown"
+	msg.ParseMode = botapi.ModeHTML
 
 	api.SendUpdate(c.Bot, &msg)
 }
@@ -1,6 +1,3 @@
-//go:build games
-// +build games
-
 package games
 
 import (
@@ -8,6 +5,7 @@ import (
 	"time"
 
 	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/metrics"
 )
 
 const (
@@ -15,11 +13,37 @@ const (
 	Path  = "games"
 )
 
-// moveMux protects against rate limits for all games occurring in a group
-var moveMux = &sync.Mutex{}
+var (
+	activeGames = metrics.NewGauge("plathbot_games_active", "Games in progress, by type.", "game")
+
+	// runningGames counts the games in progress, by type
+	runningGames = map[string]func() float64{
+		"cointoss":          countRunning(&cointossRunning, time.Minute*5),
+		"rockpaperscissors": countRunning(&rpsRunning, time.Minute*5),
+		"connect4":          countRunning(&c4Running, time.Minute*20),
+	}
+)
 
 func init() {
-	api.RegisterCallbackAPI(Path, API)
+	for game, count := range runningGames {
+		activeGames.Func(count, game)
+	}
+
+	api.RegisterModule(&api.Module{
+		Name: "games",
+		Setup: func(s *api.Server) {
+			s.RegisterCallbackAPI(API())
+
+			s.AddStatus("games", func() any {
+				running := make(map[string]int, len(runningGames))
+				for game, count := range runningGames {
+					running[game] = int(count())
+				}
+
+				return running
+			})
+		},
+	})
 }
 
 func API() *api.CallbackAPI {
@@ -27,6 +51,7 @@ func API() *api.CallbackAPI {
 		Title,
 		Path,
 		&api.CallbackConfig{
+			Description: "Play cointoss, rock paper scissors or connect 4",
 			Actions: map[string]api.CallbackAction{
 				"cointoss":          CointossQuery,
 				"rockpaperscissors": RockPaperScissorsQuery,
@@ -43,3 +68,19 @@ func API() *api.CallbackAPI {
 		},
 	)
 }
+
+// countRunning counts the games in running younger than expiry. Expired games are only
+// pruned when another starts, so they're left out here.
+func countRunning(running *sync.Map, expiry time.Duration) func() float64 {
+	return func() (n float64) {
+		running.Range(func(_, value any) bool {
+			if game, ok := value.(interface{ Age() time.Duration }); ok && game.Age() <= expiry {
+				n++
+			}
+
+			return true
+		})
+
+		return
+	}
+}
@@ -9,6 +9,9 @@ import (
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/i18n"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/render"
 	"github.com/willmroliver/plathbot/src/service"
 	"github.com/willmroliver/plathbot/src/util"
 )
@@ -16,6 +19,9 @@ import (
 const (
 	CointossTitle = "🪙 Cointoss"
 	CointossPath  = Path + "/cointoss"
+
+	// cointossSuspense is the pause before each step of the toss is revealed.
+	cointossSuspense = time.Millisecond * 500
 )
 
 type CoinToss struct {
@@ -24,6 +30,7 @@ type CoinToss struct {
 	Bot     *botapi.BotAPI
 	Players []*botapi.User
 	Chooses int
+	Lang    string
 	Mu      sync.Mutex
 }
 
@@ -45,6 +52,7 @@ func CointossQuery(c *api.Context, query *botapi.CallbackQuery, cmd *api.Callbac
 		_, exists := cointossRunning.Load(query.From.ID)
 		if !exists {
 			game := NewCoinToss(c.Bot, query.Message, query.From)
+			game.Lang = c.Lang()
 
 			if game.RequestToss(query) == nil {
 				cointossRunning.Store(game.ID, game)
@@ -88,10 +96,6 @@ func CointossQuery(c *api.Context, query *botapi.CallbackQuery, cmd *api.Callbac
 	default:
 		break
 	}
-
-	// Throttle a bit to protect message rate limit
-	// Works well with the mutex lock strategy above to stagger games
-	time.Sleep(time.Millisecond * 500)
 }
 
 func NewCoinToss(bot *botapi.BotAPI, message *botapi.Message, player *botapi.User) *CoinToss {
@@ -118,12 +122,11 @@ func (ct *CoinToss) RequestToss(query *botapi.CallbackQuery) (err error) {
 	}
 
 	msg := ct.NewMessageUpdate(
-		api.AtUserString(ct.Players[0])+" wants to toss a coin...",
-		api.InlineKeyboard([]map[string]string{{"Play!": ct.getCmd("accept")}}),
+		i18n.T(ct.Lang, "%s wants to toss a coin...", api.AtUserString(ct.Players[0])),
+		api.InlineKeyboard([]map[string]string{{i18n.T(ct.Lang, "Play!"): ct.getCmd("accept")}}),
 	)
 
-	if _, err = ct.Bot.Send(msg); err != nil {
-		log.Printf("Error in RequestToss(): %q", err.Error())
+	if err = api.SendUpdate(ct.Bot, msg); err != nil {
 		return
 	}
 
@@ -139,15 +142,14 @@ func (ct *CoinToss) AcceptToss(query *botapi.CallbackQuery) (err error) {
 	ct.Players[1] = query.From
 
 	msg := ct.NewMessageUpdate(
-		api.AtUserString(ct.GetChosen())+", heads or tails?",
-		api.InlineKeyboard([]map[string]string{{
+		i18n.T(ct.Lang, "%s, heads or tails?", api.AtUserString(ct.GetChosen())),
+		api.InlineKeyboard(api.TranslateOptions(ct.Lang, []map[string]string{{
 			"🙉 Heads": ct.getCmd("heads"),
 			"🐒 Tails": ct.getCmd("tails"),
-		}}),
+		}})),
 	)
 
-	if _, err = ct.Bot.Send(msg); err != nil {
-		log.Printf("Error in AcceptToss(): %q", err.Error())
+	if err = api.SendUpdate(ct.Bot, msg); err != nil {
 		return
 	}
 
@@ -169,13 +171,14 @@ func (ct *CoinToss) Toss(c *api.Context, query *botapi.CallbackQuery, heads bool
 		choice = "🙉"
 	}
 
-	gameText := fmt.Sprintf(`
-%s: %s
-%s chooses %s ...`, CointossTitle, ct.playerPrefix(), api.AtUserString(ct.GetChosen()), choice)
+	gameText := fmt.Sprintf("\n%s: %s\n%s",
+		render.HTML.Escape(i18n.T(ct.Lang, CointossTitle)),
+		ct.playerPrefix(),
+		i18n.T(ct.Lang, "%s chooses %s ...", api.AtUserString(ct.GetChosen()), choice),
+	)
 
 	msg := ct.NewMessageUpdate(gameText, nil)
-	ct.Bot.Send(msg)
-	time.Sleep(time.Millisecond * 500)
+	api.SendLater(ct.Bot, *msg)
 
 	heads = util.PseudoRandInt(2, false) == 1
 	result := "🐒"
@@ -190,31 +193,28 @@ func (ct *CoinToss) Toss(c *api.Context, query *botapi.CallbackQuery, heads bool
 
 	xpText := ""
 	if ct.Players[0].ID != ct.Players[1].ID {
-		var xp int64 = 100
+		xp := c.XP(model.XPGameWin)
 
 		service.
 			NewUserXPService(c.Server.DB).
-			UpdateXPs(c.User, service.XPTitleGames, xp)
+			UpdateXPs(c.User, c.Chat.ID, service.XPTitleGames, xp)
 
 		xpText = fmt.Sprintf(" +%d XP", xp)
 	}
 
-	gameText = fmt.Sprintf(`
-%s
-
-The coin lands... %s`, gameText, result)
+	gameText += "\n\n" + i18n.T(ct.Lang, "The coin lands... %s", result)
+	lands := ct.NewMessageUpdate(gameText, nil)
 
-	msg = ct.NewMessageUpdate(gameText, nil)
-	ct.Bot.Send(msg)
-	time.Sleep(time.Millisecond * 500)
+	gameText += "\n\n" + i18n.T(ct.Lang, "%s wins!", api.AtUserString(winner)) + xpText
+	wins := ct.NewMessageUpdate(gameText, nil)
 
-	gameText = fmt.Sprintf(`
-%s
-
-%s wins!%s`, gameText, api.AtUserString(winner), xpText)
-
-	msg = ct.NewMessageUpdate(gameText, nil)
-	ct.Bot.Send(msg)
+	// Revealed a step at a time, without holding up the worker
+	go func() {
+		for _, msg := range []*botapi.EditMessageTextConfig{lands, wins} {
+			time.Sleep(cointossSuspense)
+			api.SendLater(ct.Bot, *msg)
+		}
+	}()
 
 	return
 }
@@ -223,6 +223,6 @@ func (ct *CoinToss) getCmd(cmd string) string {
 	return fmt.Sprintf("%s/%s/%d", CointossPath, cmd, ct.ID)
 }
 
-func (ct *CoinToss) playerPrefix() string {
+func (ct *CoinToss) playerPrefix() render.Markup {
 	return api.AtUserString(ct.Players[0]) + " vs " + api.AtUserString(ct.Players[1])
 }
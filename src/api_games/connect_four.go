@@ -10,6 +10,9 @@ import (
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/i18n"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/render"
 	"github.com/willmroliver/plathbot/src/service"
 )
 
@@ -32,6 +35,7 @@ type ConnectFour struct {
 	Bot     *botapi.BotAPI
 	Players [2]*botapi.User
 	Turn    byte
+	Lang    string
 	Mu      sync.Mutex
 }
 
@@ -144,6 +148,7 @@ func NewConnectFour(c *api.Context, rounds int) *ConnectFour {
 		ID:          c.User.ID,
 		Bot:         c.Bot,
 		Players:     [2]*botapi.User{c.User, nil},
+		Lang:        c.Lang(),
 	}
 }
 
@@ -153,15 +158,11 @@ func (g *ConnectFour) RequestGame(q *botapi.CallbackQuery) (err error) {
 	}
 
 	msg := g.NewMessageUpdate(
-		api.AtUserString(g.Players[0])+" wants to play 🟣🟠🟣🟠",
-		api.InlineKeyboard([]map[string]string{{"Play!": g.getCmd("accept")}}),
+		i18n.T(g.Lang, "%s wants to play 🟣🟠🟣🟠", api.AtUserString(g.Players[0])),
+		api.InlineKeyboard([]map[string]string{{i18n.T(g.Lang, "Play!"): g.getCmd("accept")}}),
 	)
 
-	if _, err = g.Bot.Send(msg); err != nil {
-		log.Printf("Error in RequestGame(): %q", err.Error())
-		return
-	}
-
+	err = api.SendUpdate(g.Bot, msg)
 	return
 }
 
@@ -246,12 +247,6 @@ func (g *ConnectFour) DoMove(col int, c *api.Context, q *botapi.CallbackQuery) (
 		g.movesKeyboard(),
 	)
 
-	moveMux.Lock()
-	defer func() {
-		time.Sleep(time.Millisecond * 400)
-		moveMux.Unlock()
-	}()
-
 	if err = api.SendUpdate(g.Bot, m); err != nil {
 		g.Height[col]--
 		g.Turn = 1 - g.Turn
@@ -273,12 +268,12 @@ func (g *ConnectFour) SendWinner(c *api.Context, q *botapi.CallbackQuery, turn i
 
 	s := service.NewUserXPService(c.Server.DB)
 
-	winner := "Draw 🥴"
+	winner := i18n.T(g.Lang, "Draw 🥴")
 
 	if turn != -1 {
-		xp := int64(100)
-		winner = fmt.Sprintf("\n%s wins! %s +%d XP", api.AtUserString(g.Players[turn]), Colours[turn], xp)
-		s.UpdateXPs(g.Players[turn], service.XPTitleGames, xp)
+		xp := c.XP(model.XPGameWin)
+		winner = "\n" + i18n.T(g.Lang, "%s wins! %s +%d XP", api.AtUserString(g.Players[turn]), Colours[turn], xp)
+		s.UpdateXPs(g.Players[turn], c.Chat.ID, service.XPTitleGames, xp)
 	}
 
 	text.WriteString(winner)
@@ -301,7 +296,7 @@ func (g *ConnectFour) getCmd(cmd string) string {
 	return fmt.Sprintf("%s/%s/%d", ConnectFourPath, cmd, g.ID)
 }
 
-func (g *ConnectFour) playerPrefix() string {
+func (g *ConnectFour) playerPrefix() render.Markup {
 	return api.AtString("(P1) "+api.DisplayName(g.Players[0]), g.Players[0].ID) +
 		" vs " +
 		api.AtString(api.DisplayName(g.Players[1])+" (P2)", g.Players[1].ID)
@@ -309,7 +304,7 @@ func (g *ConnectFour) playerPrefix() string {
 
 func (g *ConnectFour) menuBuilder() *strings.Builder {
 	text := &strings.Builder{}
-	text.WriteString(ConnectFourTitle + "\n" + g.playerPrefix())
+	text.WriteString(render.HTML.Escape(i18n.T(g.Lang, ConnectFourTitle)) + "\n" + string(g.playerPrefix()))
 
 	for i := range 6 {
 		text.WriteString("\n\n")
@@ -340,16 +335,16 @@ func (g *ConnectFour) movesKeyboard() *botapi.InlineKeyboardMarkup {
 
 	for i := range moves {
 		if g.Height[i] == 6 {
-			moves[i] = botapi.NewInlineKeyboardButtonData("✅", g.getCmd("ignore"))
+			moves[i] = api.KeyboardButton("✅", g.getCmd("ignore"))
 		} else {
-			moves[i] = botapi.NewInlineKeyboardButtonData("⬆️", g.getCmd(fmt.Sprintf("%d", i)))
+			moves[i] = api.KeyboardButton("⬆️", g.getCmd(fmt.Sprintf("%d", i)))
 		}
 	}
 
 	mu := botapi.NewInlineKeyboardMarkup(
 		moves[:],
 		[]botapi.InlineKeyboardButton{
-			botapi.NewInlineKeyboardButtonData(pl+" "+Colours[g.Turn], g.getCmd("ignore")),
+			api.KeyboardButton(pl+" "+Colours[g.Turn], g.getCmd("ignore")),
 		},
 	)
 
@@ -0,0 +1,85 @@
+package games_test
+
+import (
+	"strings"
+	"testing"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/apitest"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/service"
+
+	games "github.com/willmroliver/plathbot/src/api_games"
+)
+
+func TestConnectFour(t *testing.T) {
+	h := apitest.New(t)
+	group := apitest.Group(-100)
+	p1, p2 := apitest.User(10, "alice"), apitest.User(20, "bob")
+
+	h.SendText(group, p1, "/games")
+
+	menu := h.Last("sendMessage")
+	if menu == nil {
+		t.Fatalf("/games - Expected a message to be sent")
+	}
+
+	id := h.Message(group.ID, 1).MessageID
+	h.PressButton(p1, group.ID, id, games.ConnectFourTitle)
+
+	if text := h.Message(group.ID, id).Text; !strings.Contains(text, "wants to play") {
+		t.Fatalf("ConnectFourQuery() - Expected a game request; Got %q", text)
+	}
+
+	// Players can't accept their own game
+	h.PressButton(p1, group.ID, id, "Play!")
+
+	if text := h.Message(group.ID, id).Text; !strings.Contains(text, "wants to play") {
+		t.Errorf("AcceptGame() - Expected the request to stand; Got %q", text)
+	}
+
+	h.PressButton(p2, group.ID, id, "Play!")
+
+	if text := h.Message(group.ID, id).Text; !strings.Contains(text, "(P1)") || !strings.Contains(text, "(P2)") {
+		t.Fatalf("AcceptGame() - Expected the board; Got %q", text)
+	}
+
+	move := func(p *botapi.User, col int) {
+		m := h.Message(group.ID, id)
+		if m.ReplyMarkup == nil {
+			t.Fatalf("DoMove() - Expected the moves keyboard; Got %q", m.Text)
+		}
+
+		// The first row holds a button per column
+		h.Press(p, m, *m.ReplyMarkup.InlineKeyboard[0][col].CallbackData)
+	}
+
+	// Out of turn moves are ignored
+	board := h.Message(group.ID, id).Text
+	move(p2, 3)
+
+	if text := h.Message(group.ID, id).Text; text != board {
+		t.Errorf("DoMove() - Expected P2's move out of turn to be ignored; Got %q", text)
+	}
+
+	for range 3 {
+		move(p1, 0)
+		move(p2, 1)
+	}
+
+	move(p1, 0)
+
+	text := h.Message(group.ID, id).Text
+	if !strings.Contains(text, "wins!") || !strings.Contains(text, "🟢") {
+		t.Fatalf("DoMove() - Expected P1 to win; Got %q", text)
+	}
+
+	if h.Message(group.ID, id).ReplyMarkup != nil {
+		t.Errorf("SendWinner() - Expected the moves keyboard to be removed")
+	}
+
+	user := service.NewUserXPService(h.DB).UserRepo.Get(p1)
+	if xp := user.UserXPMap[model.ScoreKey{Name: service.XPTitleGames, ChatID: group.ID}]; xp == nil || xp.XP != 100 {
+		t.Errorf("SendWinner() - Expected the winner to get 100 XP; Got %+v", xp)
+	}
+}
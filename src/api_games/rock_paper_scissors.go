@@ -10,6 +10,9 @@ import (
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/i18n"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/render"
 	"github.com/willmroliver/plathbot/src/service"
 )
 
@@ -32,6 +35,7 @@ type RockPaperScissors struct {
 	Moves       [][2]Move
 	Bot         *botapi.BotAPI
 	Players     [2]*botapi.User
+	Lang        string
 	Mu          sync.Mutex
 }
 
@@ -104,13 +108,7 @@ func RockPaperScissorsQuery(c *api.Context, query *botapi.CallbackQuery, cmd *ap
 			return
 		}
 
-		// Mostly in case of a rate-limit issue.
-		for i := 0; i < 40; i++ {
-			time.Sleep(time.Millisecond * 750)
-			if game.SendRound(c, query) == nil {
-				break
-			}
-		}
+		game.SendRound(c, query)
 	default:
 		break
 	}
@@ -125,6 +123,7 @@ func NewRockPaperScissors(c *api.Context, rounds int) *RockPaperScissors {
 		Moves:       make([][2]Move, rounds),
 		Bot:         c.Bot,
 		Players:     [2]*botapi.User{c.User, nil},
+		Lang:        c.Lang(),
 	}
 }
 
@@ -134,15 +133,11 @@ func (g *RockPaperScissors) RequestGame(q *botapi.CallbackQuery) (err error) {
 	}
 
 	msg := g.NewMessageUpdate(
-		api.AtUserString(g.Players[0])+" wants to play 🪨 📜 ✂️",
-		api.InlineKeyboard([]map[string]string{{"Play!": g.getCmd("accept")}}),
+		i18n.T(g.Lang, "%s wants to play 🪨 📜 ✂️", api.AtUserString(g.Players[0])),
+		api.InlineKeyboard([]map[string]string{{i18n.T(g.Lang, "Play!"): g.getCmd("accept")}}),
 	)
 
-	if _, err = g.Bot.Send(msg); err != nil {
-		log.Printf("Error in RequestToss(): %q", err.Error())
-		return
-	}
-
+	err = api.SendUpdate(g.Bot, msg)
 	return
 }
 
@@ -208,9 +203,6 @@ func (g *RockPaperScissors) DoMove(move Move, i int, q *botapi.CallbackQuery) (d
 			p2 = "✅"
 		}
 
-		moveMux.Lock()
-		defer moveMux.Unlock()
-
 		m := g.NewMessageUpdate(
 			g.menuBuilder().String(),
 			g.movesKeyboard(p1, p2),
@@ -219,9 +211,6 @@ func (g *RockPaperScissors) DoMove(move Move, i int, q *botapi.CallbackQuery) (d
 		if err = api.SendUpdate(g.Bot, m); err == nil {
 			g.Moves[j][i] = move
 		}
-
-		// Protecting against rate-limiting.
-		time.Sleep(time.Millisecond * 400)
 	}
 
 	done = g.Moves[j][0] != "" && g.Moves[j][1] != ""
@@ -244,15 +233,15 @@ func (g *RockPaperScissors) SendWinner(c *api.Context, q *botapi.CallbackQuery)
 
 	s := service.NewUserXPService(c.Server.DB)
 
-	winner := "Draw 🥴"
+	winner := i18n.T(g.Lang, "Draw 🥴")
 	if p1 > p2 {
-		xp := 100 * int64(p1-p2)
-		winner = fmt.Sprintf("%s wins! +%d XP", api.AtUserString(g.Players[0]), xp)
-		s.UpdateXPs(g.Players[0], service.XPTitleGames, xp)
+		xp := c.XP(model.XPGameWin) * int64(p1-p2)
+		winner = i18n.T(g.Lang, "%s wins! +%d XP", api.AtUserString(g.Players[0]), xp)
+		s.UpdateXPs(g.Players[0], c.Chat.ID, service.XPTitleGames, xp)
 	} else if p1 < p2 {
-		xp := 100 * int64(p2-p1)
-		winner = fmt.Sprintf("%s wins! +%d XP", api.AtUserString(g.Players[1]), xp)
-		s.UpdateXPs(g.Players[1], service.XPTitleGames, xp)
+		xp := c.XP(model.XPGameWin) * int64(p2-p1)
+		winner = i18n.T(g.Lang, "%s wins! +%d XP", api.AtUserString(g.Players[1]), xp)
+		s.UpdateXPs(g.Players[1], c.Chat.ID, service.XPTitleGames, xp)
 	}
 
 	text.WriteString(winner)
@@ -281,7 +270,7 @@ func (g *RockPaperScissors) getCmd(cmd string) string {
 	return fmt.Sprintf("%s/%s/%d", RockPaperScissorsPath, cmd, g.ID)
 }
 
-func (g *RockPaperScissors) playerPrefix() string {
+func (g *RockPaperScissors) playerPrefix() render.Markup {
 	return api.AtString("(P1) "+api.DisplayName(g.Players[0]), g.Players[0].ID) +
 		" vs " +
 		api.AtString(api.DisplayName(g.Players[1])+" (P2)", g.Players[1].ID)
@@ -295,7 +284,7 @@ func (g *RockPaperScissors) menuBuilder() *strings.Builder {
 	}
 
 	text := &strings.Builder{}
-	text.WriteString(RockPaperScissorsTitle + "\n" + g.playerPrefix() + "\n\n")
+	text.WriteString(render.HTML.Escape(i18n.T(g.Lang, RockPaperScissorsTitle)) + "\n" + string(g.playerPrefix()) + "\n\n")
 
 	for i := 0; i < g.Round-1; i++ {
 		cmp := g.Moves[i][0].Compare(g.Moves[i][1])
@@ -308,16 +297,16 @@ func (g *RockPaperScissors) menuBuilder() *strings.Builder {
 func (g *RockPaperScissors) movesKeyboard(p1, p2 string) *botapi.InlineKeyboardMarkup {
 	mu := botapi.NewInlineKeyboardMarkup(
 		[]botapi.InlineKeyboardButton{
-			botapi.NewInlineKeyboardButtonData(p1+" "+string(MoveRock), g.getCmd(string(MoveRock))+"/0"),
-			botapi.NewInlineKeyboardButtonData(string(MoveRock)+" "+p2, g.getCmd(string(MoveRock))+"/1"),
+			api.KeyboardButton(p1+" "+string(MoveRock), g.getCmd(string(MoveRock))+"/0"),
+			api.KeyboardButton(string(MoveRock)+" "+p2, g.getCmd(string(MoveRock))+"/1"),
 		},
 		[]botapi.InlineKeyboardButton{
-			botapi.NewInlineKeyboardButtonData(p1+" "+string(MovePaper), g.getCmd(string(MovePaper))+"/0"),
-			botapi.NewInlineKeyboardButtonData(string(MovePaper)+" "+p2, g.getCmd(string(MovePaper))+"/1"),
+			api.KeyboardButton(p1+" "+string(MovePaper), g.getCmd(string(MovePaper))+"/0"),
+			api.KeyboardButton(string(MovePaper)+" "+p2, g.getCmd(string(MovePaper))+"/1"),
 		},
 		[]botapi.InlineKeyboardButton{
-			botapi.NewInlineKeyboardButtonData(p1+" "+string(MoveScissors), g.getCmd(string(MoveScissors))+"/0"),
-			botapi.NewInlineKeyboardButtonData(string(MoveScissors)+" "+p2, g.getCmd(string(MoveScissors))+"/1"),
+			api.KeyboardButton(p1+" "+string(MoveScissors), g.getCmd(string(MoveScissors))+"/0"),
+			api.KeyboardButton(string(MoveScissors)+" "+p2, g.getCmd(string(MoveScissors))+"/1"),
 		},
 	)
 
@@ -1,10 +1,7 @@
-//go:build pfp
-// +build pfp
-
 package pfp
 
 import (
-	"time"
+	"strings"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/willmroliver/plathbot/src/api"
@@ -13,26 +10,23 @@ import (
 )
 
 func init() {
-	api.RegisterCommandAction("/pfp", API)
-}
-
-func API(c *api.Context, m *botapi.Message, args ...string) {
-	if len(args) == 0 {
-		random(c, m, args...)
-		return
-	}
-
-	actions := map[string]api.CommandAction{
-		"":       random,
-		"add":    add,
-		"list":   list,
-		"get":    get,
-		"delete": delete,
-	}
-
-	if action, ok := actions[args[0]]; ok {
-		action(c, m, args[1:]...)
-	}
+	api.RegisterModule(&api.Module{
+		Name: "pfp",
+		Setup: func(s *api.Server) {
+			s.RegisterCommandAction("/pfp", random, api.Describe("A random platypus PFP"))
+			s.RegisterCommandAction("/pfp add", add,
+				api.Describe("Add a PFP, sending the image after"),
+				api.Params(api.ArgRest("name").Opt()),
+			)
+			s.RegisterCommandAction("/pfp list", list, api.Describe("List every PFP"))
+			s.RegisterCommandAction("/pfp get", get, api.Params(api.ArgWord("id")))
+			s.RegisterCommandAction("/pfp delete", delete,
+				api.RequireRole(api.RoleModerator),
+				api.Params(api.ArgWord("id")),
+			)
+			s.RegisterInlineAction("pfp", inlinePFP, api.InlineShared())
+		},
+	})
 }
 
 func random(c *api.Context, m *botapi.Message, args ...string) {
@@ -43,37 +37,49 @@ func random(c *api.Context, m *botapi.Message, args ...string) {
 	}
 }
 
-func add(c *api.Context, m *botapi.Message, args ...string) {
-	api.SendBasic(c.Bot, c.Chat.ID, "Send a name & image you'd like to add to /pfp")
-
-	var title string
-	var photo *botapi.PhotoSize
-
-	hook := api.NewMessageHook(func(s *api.Server, m *botapi.Message, a any) (done bool) {
-		title = m.Text
-
-		if len(m.Photo) != 0 {
-			photo = &m.Photo[0]
-			title = m.Caption
-		}
-
-		if done = title != "" && photo != nil; done {
-			r := repo.NewFileRepo(c.Server.DB)
-			if r.Save(photo, "/pfp/"+title) != nil {
-				api.SendBasic(c.Bot, c.Chat.ID, "Image added to /pfp.")
-			} else {
-				api.SendBasic(c.Bot, c.Chat.ID, "Oops, something went wrong.")
-			}
-		} else if title != "" {
-			api.SendBasic(c.Bot, c.Chat.ID, "Perfect, now send an image.")
+// addPFP asks for a name, unless given as an argument or a photo caption, then an image.
+var addPFP = &api.Conversation{
+	Name: "pfp_add",
+	Steps: []*api.Step{
+		{
+			Name:   "name",
+			Prompt: "Send a name & image you'd like to add to /pfp",
+			Parse: func(c *api.Context, cs *api.ConversationState, m *botapi.Message) (any, error) {
+				if len(m.Photo) != 0 && m.Caption != "" {
+					photo, _ := api.ParsePhoto(c, cs, m)
+					cs.Set("photo", photo)
+
+					return m.Caption, nil
+				}
+
+				return api.ParseText(c, cs, m)
+			},
+		},
+		{
+			Name:   "photo",
+			Prompt: "Perfect, now send an image.",
+			Parse:  api.ParsePhoto,
+		},
+	},
+	OnDone: func(c *api.Context, cs *api.ConversationState) {
+		photo := cs.Get("photo").(*botapi.PhotoSize)
+
+		if repo.NewFileRepo(c.Server.DB).Save(photo, "/pfp/"+cs.String("name")) != nil {
+			api.SendBasic(c.Bot, cs.ChatID, cs.T("Image added to /pfp."))
 		} else {
-			api.SendBasic(c.Bot, c.Chat.ID, "Great, now send a name for the image.")
+			api.SendBasic(c.Bot, cs.ChatID, cs.T("Oops, something went wrong."))
 		}
+	},
+}
 
-		return
-	}, c.User.ID, time.Minute*5)
+func add(c *api.Context, m *botapi.Message, args ...string) {
+	values := map[string]any{}
+
+	if title := c.Args.String("name"); title != "" {
+		values["name"] = title
+	}
 
-	c.Server.RegisterUserHook(c.User.ID, hook)
+	addPFP.StartWith(c, nil, values)
 }
 
 func list(c *api.Context, m *botapi.Message, args ...string) {
@@ -83,21 +89,21 @@ func list(c *api.Context, m *botapi.Message, args ...string) {
 	if files == nil {
 		return
 	} else if len(files) == 0 {
-		api.SendBasic(c.Bot, c.Chat.ID, "No PFPs found :(")
+		api.SendBasic(c.Bot, c.Chat.ID, c.T("No PFPs found :("))
 		return
 	}
 
-	msg := botapi.NewMessage(c.Chat.ID, "🎨 PFPs 📸")
+	msg := botapi.NewMessage(c.Chat.ID, c.N(len(files), "🎨 %d PFP 📸", "🎨 %d PFPs 📸", len(files)))
 
 	var mu [][]botapi.InlineKeyboardButton
 
-	if c.IsAdmin() && m.Chat.Type == "private" && false {
+	if c.HasRole(api.RoleModerator) && m.Chat.Type == "private" && false {
 		mu = make([][]botapi.InlineKeyboardButton, len(files))
 
 		for i, f := range files {
 			mu[i] = []botapi.InlineKeyboardButton{
-				botapi.NewInlineKeyboardButtonData(f.Name+" 👀", "cmd|/pfp get "+f.FileUniqueID),
-				botapi.NewInlineKeyboardButtonData("🗑️", "cmd|/pfp delete "+f.FileUniqueID),
+				api.KeyboardButton(f.Name+" 👀", "cmd|/pfp get "+f.FileUniqueID),
+				api.KeyboardButton("🗑️", "cmd|/pfp delete "+f.FileUniqueID),
 			}
 		}
 	} else {
@@ -105,7 +111,7 @@ func list(c *api.Context, m *botapi.Message, args ...string) {
 		row := make([]botapi.InlineKeyboardButton, 0, 3)
 
 		for i, f := range files {
-			row = append(row, botapi.NewInlineKeyboardButtonData(f.Name[5:]+" 👀", "cmd|/pfp get "+f.FileUniqueID))
+			row = append(row, api.KeyboardButton(f.Name[5:]+" 👀", "cmd|/pfp get "+f.FileUniqueID))
 
 			if i%3 == 2 || i+1 == len(files) {
 				mu[i/3] = row
@@ -119,24 +125,36 @@ func list(c *api.Context, m *botapi.Message, args ...string) {
 }
 
 func get(c *api.Context, m *botapi.Message, args ...string) {
-	if len(args) == 0 {
-		return
-	}
-
 	file := &model.File{}
 
-	if repo.NewFileRepo(c.Server.DB).GetBy(file, "file_unique_id", args[0]) == nil {
+	if repo.NewFileRepo(c.Server.DB).GetBy(file, "file_unique_id", c.Args.String("id")) == nil {
 		m := botapi.NewPhoto(c.Chat.ID, botapi.FileID(file.FileID))
 		api.SendConfig(c.Bot, m)
 	}
 }
 
 func delete(c *api.Context, m *botapi.Message, args ...string) {
-	if !c.IsAdmin() || len(args) == 0 {
-		return
-	}
+	repo.NewFileRepo(c.Server.DB).DeleteBy(&model.File{}, "file_unique_id", c.Args.String("id"))
+
+	api.SendBasic(c.Bot, c.Chat.ID, c.T("✅ File deleted"))
+}
+
+// inlinePFP answers "pfp [name]" with the PFPs whose names contain it.
+func inlinePFP(c *api.Context, q *botapi.InlineQuery, args ...string) []any {
+	search := strings.ToLower(strings.Join(args, " "))
+	results := []any{}
 
-	repo.NewFileRepo(c.Server.DB).DeleteBy(&model.File{}, "file_unique_id", args[0])
+	for _, f := range repo.NewFileRepo(c.Server.DB).List("/pfp/", "name", 0, 1000, "Photo") {
+		name := f.Name[5:]
+		if !strings.Contains(strings.ToLower(name), search) {
+			continue
+		}
+
+		photo := botapi.NewInlineQueryResultCachedPhoto(f.FileUniqueID, f.FileID)
+		photo.Title = name
+
+		results = append(results, photo)
+	}
 
-	api.SendBasic(c.Bot, c.Chat.ID, "✅ File deleted")
+	return results
 }
@@ -1,6 +1,3 @@
-//go:build reddit && account
-// +build reddit,account
-
 package account
 
 import (
@@ -11,7 +8,6 @@ import (
 	"net/url"
 	"os"
 	"strings"
-	"sync"
 	"time"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -19,19 +15,18 @@ import (
 	"github.com/willmroliver/plathbot/src/api"
 	account "github.com/willmroliver/plathbot/src/api_account"
 	reddit "github.com/willmroliver/plathbot/src/api_reddit"
+	"github.com/willmroliver/plathbot/src/i18n"
 	"github.com/willmroliver/plathbot/src/model"
 	"github.com/willmroliver/plathbot/src/repo"
-	"github.com/willmroliver/plathbot/src/util"
 	"gorm.io/gorm"
 )
 
-var (
-	Path                     = account.Path + "/" + reddit.Path
-	redditsOpen, confirmOpen = sync.Map{}, sync.Map{}
-)
+var Path = account.Path + "/" + reddit.Path
 
 func init() {
-	account.Extensions.ExtendAPI(reddit.Title, reddit.Path, API().Select)
+	api.RegisterIntegration(reddit.Path, account.Path, func(s *api.Server) {
+		s.FindCallbackAPI(account.Path).Extend(reddit.Title, reddit.Path, API().Select)
+	})
 }
 
 func API() *api.CallbackAPI {
@@ -51,8 +46,8 @@ func API() *api.CallbackAPI {
 					}
 				},
 				"confirm": func(c *api.Context, cq *botapi.CallbackQuery, cc *api.CallbackCmd) {
-					if ch, ok := confirmOpen.Load(c.User.ID); ok {
-						ch.(chan struct{}) <- struct{}{}
+					if cs := c.Server.Conversation(c.Chat.ID, c.User.ID); cs != nil && cs.Is("confirm") {
+						cs.Answer(c, true)
 					}
 				},
 				"remove": func(c *api.Context, cq *botapi.CallbackQuery, cc *api.CallbackCmd) {
@@ -85,22 +80,8 @@ func NewReddit(db *gorm.DB, query *botapi.CallbackQuery) *Reddit {
 	}
 }
 
-func OpenReddit(db *gorm.DB, query *botapi.CallbackQuery) (r *Reddit) {
-	redditsOpen.Range(func(key any, value any) bool {
-		if value.(*api.Interaction[any]).Age() > time.Minute*5 {
-			redditsOpen.Delete(key)
-		}
-
-		return true
-	})
-
-	if data, exists := redditsOpen.Load(query.From.ID); exists {
-		r = data.(*Reddit)
-	} else {
-		r = NewReddit(db, query)
-	}
-
-	return
+func OpenReddit(db *gorm.DB, query *botapi.CallbackQuery) *Reddit {
+	return NewReddit(db, query)
 }
 
 func (r *Reddit) User() *model.User {
@@ -110,129 +91,113 @@ func (r *Reddit) User() *model.User {
 func (r *Reddit) View(c *api.Context, query *botapi.CallbackQuery) {
 	text := r.User().RedditUsername
 	if text == "" {
-		text = "Not linked"
+		text = c.T("Not linked")
 	}
 
 	api.SendBasic(c.Bot, query.Message.Chat.ID, text)
 }
 
-func (r *Reddit) Update(c *api.Context, query *botapi.CallbackQuery) {
-	r.Mutate("update", query.Message)
-
-	api.SendConfig(c.Bot, r.NewMessage("Okay! Send me the username of the reddit account you'd like to link.", nil))
-
-	hook := api.NewMessageHook(func(s *api.Server, m *botapi.Message, data any) (done bool) {
-		done = true
-		re := data.(*Reddit)
-
-		if !(r.Is("update") && util.TryLockFor(fmt.Sprintf("%p reddit", data), time.Minute*5)) {
-			return
-		}
-
-		// Generate a verification token
-		bytes := make([]byte, 16)
-		if _, err := rand.Read(bytes); err != nil {
-			log.Printf("Error generating reddit link token: %q", bytes)
-			return
-		}
+// linkAccount asks for a reddit username, then has the user message us a token from that
+// account and hit Confirm. The *Reddit is the conversation's Data.
+var linkAccount = &api.Conversation{
+	Name: "reddit_link",
+	Steps: []*api.Step{
+		{
+			Name:   "username",
+			Prompt: "Okay! Send me the username of the reddit account you'd like to link.",
+			Parse: func(c *api.Context, cs *api.ConversationState, m *botapi.Message) (any, error) {
+				return strings.TrimPrefix(strings.TrimSpace(m.Text), "u/"), nil
+			},
+		},
+		{
+			Name: "confirm",
+			Ask: func(c *api.Context, cs *api.ConversationState) {
+				// Generate a verification token
+				bytes := make([]byte, 16)
+				if _, err := rand.Read(bytes); err != nil {
+					log.Printf("Error generating reddit link token: %q", err.Error())
+					api.SendConfig(c.Bot, cs.NewMessage(cs.T("Something went wrong."), nil))
+					c.Server.CancelConversation(cs.ChatID, cs.UserID)
+					return
+				}
 
-		token := hex.EncodeToString(bytes)
+				token := hex.EncodeToString(bytes)
+				cs.Set("token", token)
 
-		// Send the verification token & post
-		api.SendConfig(s.Bot, re.NewMessage(token, nil))
-		api.SendConfig(s.Bot, re.NewMessage(`
+				// Send the verification token & post
+				api.SendConfig(c.Bot, cs.NewMessage(token, nil))
+				api.SendConfig(c.Bot, cs.NewMessage(cs.T(`
 🔗 Account Link Request
 
-1️⃣ Hit the *Verify* button below
+1️⃣ Hit the <b>Verify</b> button below
 
 2️⃣ Send the verification token.
 
-3️⃣ Come back here and hit *Confirm* to verify.
-			`,
-			api.InlineKeyboard([]map[string]string{{
-				"Verify": api.KeyboardLink(fmt.Sprintf(
-					"https://www.reddit.com/message/compose/?to=%s&subject=Verify&message=%s",
-					url.QueryEscape(os.Getenv("GO_REDDIT_CLIENT_USERNAME")),
-					url.QueryEscape(token),
-				)),
-			}, {
-				"Confirm": Path + "/confirm",
-			}}),
-		))
-
-		tryConfirm := func() bool {
-			// Poll the post for the verification token
-			type Payload struct {
-				Username, Token string
-				Success         bool
-			}
-
-			payload := &Payload{Username: strings.TrimPrefix(m.Text, "u/"), Token: string(token)}
-
-			reddit.PollInbox(time.Second, 0, func(ms []*goreddit.Message, messages []*goreddit.Message, p any) bool {
-				payload = p.(*Payload)
-
-				for _, m := range append(ms, messages...) {
-					if m.Text == payload.Token && m.Author == payload.Username {
-						payload.Success = true
-						return true
+3️⃣ Come back here and hit <b>Confirm</b> to verify.
+			`),
+					c.InlineKeyboard([]map[string]string{{
+						"Verify": api.KeyboardLink(fmt.Sprintf(
+							"https://www.reddit.com/message/compose/?to=%s&subject=Verify&message=%s",
+							url.QueryEscape(os.Getenv("GO_REDDIT_CLIENT_USERNAME")),
+							url.QueryEscape(token),
+						)),
+					}, {
+						"Confirm": Path + "/confirm",
+					}}),
+				))
+			},
+			Parse: func(c *api.Context, cs *api.ConversationState, m *botapi.Message) (any, error) {
+				return nil, i18n.Errorf("Hit Confirm once you've sent the token, or /cancel.")
+			},
+			Validate: func(cs *api.ConversationState, value any) error {
+				username, token, found := cs.String("username"), cs.String("token"), false
+
+				// Poll the inbox for the verification token
+				reddit.PollInbox(time.Second, 0, func(ms []*goreddit.Message, messages []*goreddit.Message, p any) bool {
+					for _, m := range append(ms, messages...) {
+						if m.Text == token && m.Author == username {
+							found = true
+							return true
+						}
 					}
-				}
-
-				return false
-			}, payload)
 
-			// If the verification token was found, link the account
-			if payload.Success {
-				user := c.GetUser()
-				user.RedditUsername = payload.Username
+					return false
+				}, nil)
 
-				if err := c.UserRepo.Save(user); err != nil {
-					api.SendConfig(s.Bot, re.NewMessage("Something went wrong.", nil))
-					return true
-				}
-
-				msg := botapi.NewMessage(c.Chat.ID, fmt.Sprintf("✅ Linked to %q", user.RedditUsername))
-				msg.ReplyMarkup = api.InlineKeyboard([]map[string]string{{reddit.Title: Path}}, fmt.Sprintf("user=%d", re.user.ID))
-
-				api.SendConfig(s.Bot, msg)
-
-				return true
-			} else {
-				api.SendConfig(s.Bot, re.NewMessage(`
-Verification failed. 
+				if !found {
+					return i18n.Errorf(`Verification failed.
 
 Check for spelling errors in the username passed.
 
-You may also be shadow-banned, check here: https://www.reddit.com/appeals
-				`, nil))
-				return false
-			}
-		}
+You may also be shadow-banned, check here: https://www.reddit.com/appeals`)
+				}
 
-		ch := make(chan struct{}, 1)
-		confirmOpen.Store(c.User.ID, ch)
+				return nil
+			},
+		},
+	},
+	OnDone: func(c *api.Context, cs *api.ConversationState) {
+		// The verification token was found, so link the account
+		re := cs.Data.(*Reddit)
+		user := c.GetUser()
+		user.RedditUsername = cs.String("username")
+
+		if err := c.UserRepo.Save(user); err != nil {
+			api.SendConfig(c.Bot, re.NewMessage(cs.T("Something went wrong."), nil))
+			return
+		}
 
-		awaiting := true
+		msg := botapi.NewMessage(cs.ChatID, cs.T("✅ Linked to %q", user.RedditUsername))
+		msg.ReplyMarkup = c.InlineKeyboard([]map[string]string{{reddit.Title: Path}}, fmt.Sprintf("user=%d", re.user.ID))
 
-		for awaiting {
-			select {
-			case <-ch:
-				if tryConfirm() {
-					confirmOpen.Delete(c.User.ID)
-					return
-				}
-				continue
-			case <-time.After(time.Minute * 5):
-				confirmOpen.Delete(c.User.ID)
-				return
-			}
-		}
+		api.SendConfig(c.Bot, msg)
+	},
+}
 
-		return
-	}, r, time.Minute*5)
+func (r *Reddit) Update(c *api.Context, query *botapi.CallbackQuery) {
+	r.Mutate("update", query.Message)
 
-	c.Server.RegisterChatHook(query.Message.Chat.ID, hook)
+	linkAccount.Start(c, r)
 }
 
 func (r *Reddit) Remove(c *api.Context, query *botapi.CallbackQuery) {
@@ -240,12 +205,12 @@ func (r *Reddit) Remove(c *api.Context, query *botapi.CallbackQuery) {
 	user.RedditUsername = ""
 
 	if err := c.UserRepo.Save(user); err != nil {
-		api.SendBasic(c.Server.Bot, c.Chat.ID, "Unexpected error unlinking account.")
+		api.SendBasic(c.Server.Bot, c.Chat.ID, c.T("Unexpected error unlinking account."))
 		return
 	}
 
 	api.SendUpdate(c.Bot, r.NewMessageUpdate(
-		"✅ Deleted",
-		api.InlineKeyboard([]map[string]string{{reddit.Title: reddit.Path}}, fmt.Sprintf("user=%d", r.user.ID)),
+		c.T("✅ Deleted"),
+		c.InlineKeyboard([]map[string]string{{reddit.Title: reddit.Path}}, fmt.Sprintf("user=%d", r.user.ID)),
 	))
 }
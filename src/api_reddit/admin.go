@@ -1,17 +1,14 @@
-//go:build reddit
-// +build reddit
-
 package reddit
 
 import (
 	"fmt"
-	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/i18n"
 	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/render"
 	"github.com/willmroliver/plathbot/src/repo"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -23,8 +20,6 @@ const (
 	AdminPath  = Path + "/admin"
 )
 
-var open = sync.Map{}
-
 func AdminAPI() *api.CallbackAPI {
 	add, view, remove := "add", "view", "remove"
 
@@ -32,19 +27,20 @@ func AdminAPI() *api.CallbackAPI {
 		AdminTitle,
 		AdminPath,
 		&api.CallbackConfig{
+			Description: "Manage tracked Reddit posts",
 			Actions: map[string]api.CallbackAction{
 				add: func(c *api.Context, cq *botapi.CallbackQuery, cc *api.CallbackCmd) {
-					if a := OpenAdmin(c, cq, cc); a != nil {
+					if a := OpenAdmin(c, cq); a != nil {
 						a.Update(c, cq)
 					}
 				},
 				view: func(c *api.Context, cq *botapi.CallbackQuery, cc *api.CallbackCmd) {
-					if a := OpenAdmin(c, cq, cc); a != nil {
+					if a := OpenAdmin(c, cq); a != nil {
 						a.View(c, cq)
 					}
 				},
 				remove: func(c *api.Context, cq *botapi.CallbackQuery, cc *api.CallbackCmd) {
-					if a := OpenAdmin(c, cq, cc); a != nil {
+					if a := OpenAdmin(c, cq); a != nil {
 						a.Remove(c, cq, cc)
 					}
 				},
@@ -55,7 +51,8 @@ func AdminAPI() *api.CallbackAPI {
 				{"🔚 Stop Tracking": remove},
 				api.KeyboardNavRow(".."),
 			},
-			PublicOnly: true,
+			PublicOnly:  true,
+			RequireRole: api.RoleAdmin,
 		},
 	)
 }
@@ -74,132 +71,113 @@ func NewAdmin(db *gorm.DB, q *botapi.CallbackQuery) *Admin {
 	}
 }
 
-func OpenAdmin(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) (admin *Admin) {
-	if u, ok := cc.Tags["user"]; ok {
-		if id, _ := strconv.ParseInt(u, 10, 64); id != q.From.ID {
-			return
-		}
-	}
-
-	if !c.IsAdmin() {
-		return
-	}
-
-	open.Range(func(key any, value any) bool {
-		if value.(*api.Interaction[any]).Age() > time.Minute*5 {
-			open.Delete(key)
-		}
-
-		return true
-	})
-
-	if data, exists := open.Load(q.From.ID); exists {
-		admin = data.(*Admin)
-	} else {
-		admin = NewAdmin(c.Server.DB, q)
-	}
-
-	return
+// OpenAdmin wraps the menu message pressed in an admin session, for View and Remove to
+// edit, or for Update to carry through the trackPost conversation as its Data.
+func OpenAdmin(c *api.Context, q *botapi.CallbackQuery) *Admin {
+	return NewAdmin(c.Server.DB, q)
 }
 
 func (a *Admin) View(c *api.Context, query *botapi.CallbackQuery) {
-	mu := api.InlineKeyboard([]map[string]string{
-		api.KeyboardNavRow(AdminPath),
-	}, fmt.Sprintf("user=%d", a.user.ID))
+	mu := adminNav(c.Lang(), a.user.ID)
 
 	posts := a.repo.All()
 	if posts == nil {
-		api.SendUpdate(c.Bot, a.NewMessageUpdate("Error fetching posts.", mu))
+		api.SendUpdate(c.Bot, a.NewMessageUpdate(c.T("Error fetching posts."), mu))
 		return
 	}
 
 	if len(posts) == 0 {
-		api.SendUpdate(c.Bot, a.NewMessageUpdate("No posts being tracked.", mu))
+		api.SendUpdate(c.Bot, a.NewMessageUpdate(c.T("No posts being tracked."), mu))
 		return
 	}
 
 	now := time.Now()
-	text := "👀 Active Posts\n\n"
+	text := c.T("👀 Active Posts") + "\n\n"
 
 	for _, post := range posts {
-		text += fmt.Sprintf("%s - %s\n", post.Title, post.ExpiresAt.Sub(now).String())
+		text += string(render.HTML.Sprintf("%s - %s\n", post.Title, post.ExpiresAt.Sub(now)))
 	}
 
 	api.SendUpdate(c.Bot, a.NewMessageUpdate(text, mu))
 }
 
-func (a *Admin) Update(c *api.Context, query *botapi.CallbackQuery) {
-	api.SendUpdate(c.Bot, a.NewMessageUpdate(`
+// trackPost asks for a post, then how long to track it for. The *Admin is the conversation's Data.
+var trackPost = &api.Conversation{
+	Name: "reddit_post",
+	Steps: []*api.Step{
+		{
+			Name: "post",
+			Ask: func(c *api.Context, cs *api.ConversationState) {
+				api.SendUpdate(c.Bot, cs.Data.(*Admin).NewMessageUpdate(cs.T(`
 Okay, send the post URL OR post ID you'd like to start tracking. ID can be found in the URL, E.g:
 
-/r/SolanaMemeCoins/comments/1hetkr8/plath_holding_strong/ -> '1hetkr8'
-	`, nil))
-
-	hook := api.NewMessageHook(func(s *api.Server, m *botapi.Message, data any) (done bool) {
-		postID := m.Text
-
-		if i := strings.Index(postID, "/comments/"); i > -1 {
-			start := i + len("/comments/")
-			if j := strings.Index(postID[start:], "/"); j > -1 {
-				postID = postID[start : start+j]
-			}
-		}
-
-		ad, post := data.(*Admin), GetPost(postID)
+/r/SolanaMemeCoins/comments/1hetkr8/plath_holding_strong/ -&gt; '1hetkr8'
+	`), nil))
+			},
+			Parse: func(c *api.Context, cs *api.ConversationState, m *botapi.Message) (any, error) {
+				postID := strings.TrimSpace(m.Text)
 
-		if post == nil {
-			api.SendBasic(c.Bot, c.Chat.ID, "Invalid post ID.")
-		}
+				if i := strings.Index(postID, "/comments/"); i > -1 {
+					start := i + len("/comments/")
+					if j := strings.Index(postID[start:], "/"); j > -1 {
+						postID = postID[start : start+j]
+					}
+				}
 
-		r := model.NewRedditPost(post)
+				if post := GetPost(postID); post != nil {
+					return model.NewRedditPost(post), nil
+				}
 
-		api.SendBasic(c.Bot, c.Chat.ID, `
+				return nil, i18n.Errorf("Invalid post ID.")
+			},
+		},
+		{
+			Name: "duration",
+			Prompt: `
 How long do you want to track this post for? E.g:
 
 '24h'
 '1h30m'
 '3h 15m 30 s'
-		`)
-
-		hook, ch := api.GetDurationHook(c.Chat.ID, time.Minute*5)
-		s.RegisterUserHook(c.User.ID, hook)
-
-		text := "✅ Post added"
-
-		select {
-		case dur := <-ch:
-			r.ExpiresAt = time.Now().Add(dur)
-
-			if ad.repo.Save(r) != nil {
-				text = "Error saving post."
-			}
-		case <-time.After(time.Minute * 5):
-			text = "Post tracking cancelled."
+		`,
+			Parse: api.ParseDuration,
+		},
+	},
+	OnDone: func(c *api.Context, cs *api.ConversationState) {
+		a, r := cs.Data.(*Admin), cs.Get("post").(*model.RedditPost)
+		r.ExpiresAt = time.Now().Add(cs.Get("duration").(time.Duration))
+
+		text := cs.T("✅ Post added")
+		if a.repo.Save(r) != nil {
+			text = cs.T("Error saving post.")
 		}
 
-		mu := api.InlineKeyboard([]map[string]string{
-			api.KeyboardNavRow(AdminPath),
-		}, fmt.Sprintf("user=%d", ad.user.ID))
-
-		api.SendConfig(c.Bot, a.NewMessage(text, mu))
+		api.SendConfig(c.Bot, a.NewMessage(text, adminNav(cs.Lang, a.user.ID)))
+	},
+	OnCancel: func(s *api.Server, cs *api.ConversationState, err error) {
+		a := cs.Data.(*Admin)
+		api.SendConfig(s.Bot, a.NewMessage(cs.T("Post tracking cancelled."), adminNav(cs.Lang, a.user.ID)))
+	},
+}
 
-		done = true
-		return
-	}, a, time.Minute*5)
+func adminNav(lang string, userID int64) *botapi.InlineKeyboardMarkup {
+	return api.InlineKeyboard(api.TranslateOptions(lang, []map[string]string{
+		api.KeyboardNavRow(AdminPath),
+	}), fmt.Sprintf("user=%d", userID))
+}
 
-	c.Server.RegisterUserHook(c.User.ID, hook)
+func (a *Admin) Update(c *api.Context, query *botapi.CallbackQuery) {
+	trackPost.Start(c, a)
 }
 
 func (a *Admin) Remove(c *api.Context, query *botapi.CallbackQuery, cc *api.CallbackCmd) {
-	mu := api.InlineKeyboard([]map[string]string{
-		api.KeyboardNavRow(AdminPath),
-	}, fmt.Sprintf("user=%d", c.User.ID))
+	mu := adminNav(c.Lang(), c.User.ID)
 
 	if postID := cc.Get(); postID != "" {
 		if err := a.repo.Delete(postID); err != nil {
-			api.SendUpdate(c.Bot, a.NewMessageUpdate("Error removing post.", mu))
+			api.SendUpdate(c.Bot, a.NewMessageUpdate(c.T("Error removing post."), mu))
 		} else {
-			api.SendUpdate(c.Bot, a.NewMessageUpdate("✅ Post removed", mu))
+			api.SendUpdate(c.Bot, a.NewMessageUpdate(c.T("✅ Post removed"), mu))
 		}
 
 		return
@@ -215,6 +193,6 @@ func (a *Admin) Remove(c *api.Context, query *botapi.CallbackQuery, cc *api.Call
 		opts[len(posts)] = api.KeyboardNavRow(AdminPath)
 	}
 
-	mu = api.InlineKeyboard(opts, fmt.Sprintf("user=%d", c.User.ID))
-	api.SendUpdate(c.Bot, a.NewMessageUpdate("Select a post to remove", mu))
+	mu = c.InlineKeyboard(opts, fmt.Sprintf("user=%d", c.User.ID))
+	api.SendUpdate(c.Bot, a.NewMessageUpdate(c.T("Select a post to remove"), mu))
 }
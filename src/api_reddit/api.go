@@ -1,6 +1,3 @@
-//go:build reddit
-// +build reddit
-
 package reddit
 
 import (
@@ -26,10 +23,13 @@ func init() {
 	db.MigrateModel(&model.RedditPost{})
 	db.MigrateModel(&model.RedditPostComment{})
 
-	api.RegisterCallbackAPI(Path, API)
-
-	api.BeforeListen(func(s *api.Server) {
-		TrackPosts(s.DB, time.Minute*2)
+	api.RegisterModule(&api.Module{
+		Name: "reddit",
+		Setup: func(s *api.Server) {
+			s.RegisterCallbackAPI(API())
+			api.RegisterJobHandler(s.Scheduler, "reddit_track", trackPosts)
+			s.Scheduler.Schedule("reddit tracker", "reddit_track", api.Every(time.Minute*2), nil)
+		},
 	})
 }
 
@@ -40,6 +40,7 @@ func API() *api.CallbackAPI {
 		Title,
 		Path,
 		&api.CallbackConfig{
+			Description: "Reddit raid links",
 			Actions: map[string]api.CallbackAction{
 				admin: adminAPI.Select,
 				view:  allPosts,
@@ -61,7 +62,7 @@ func allPosts(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
 		return
 	}
 
-	text := "‼‼‼ *Raid Links* ‼‼‼\nTop shillers _will be rewarded_ 🤑"
+	text := c.T("‼‼‼ <b>Raid Links</b> ‼‼‼\nTop shillers <i>will be rewarded</i> 🤑")
 	kb := make([]map[string]string, len(posts))
 
 	for i, p := range posts {
@@ -69,7 +70,7 @@ func allPosts(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
 	}
 
 	m := botapi.NewEditMessageTextAndMarkup(c.Chat.ID, c.Message.MessageID, text, *api.InlineKeyboard(kb))
-	m.ParseMode = botapi.ModeMarkdown
+	m.ParseMode = botapi.ModeHTML
 
 	api.SendConfig(c.Bot, m)
 }
@@ -1,14 +1,14 @@
-//go:build reddit
-// +build reddit
-
 package reddit
 
 import (
+	"context"
 	"log"
 	"sync"
 	"time"
 
 	goreddit "github.com/vartanbeno/go-reddit/v2/reddit"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/metrics"
 	"github.com/willmroliver/plathbot/src/model"
 	"github.com/willmroliver/plathbot/src/repo"
 	"github.com/willmroliver/plathbot/src/service"
@@ -16,79 +16,76 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-func TrackPosts(db *gorm.DB, freq time.Duration) func() {
-	run := true
-	cancel := func() {
-		run = false
-	}
+var trackCycle = metrics.NewHistogram("plathbot_reddit_track_cycle_seconds", "Time taken polling tracked posts for comments.", []float64{1, 5, 10, 30, 60, 120, 300})
 
-	userRepo := repo.NewUserRepo(db)
-	redditService := service.NewRedditService(db)
+// trackPosts polls tracked posts for comments, as a scheduled job.
+func trackPosts(_ context.Context, s *api.Server, _ struct{}) error {
+	start := time.Now()
+	defer trackCycle.Since(start)
 
-	go func() {
-		for run {
-			time.Sleep(freq)
+	trackComments(s.DB, repo.NewUserRepo(s.DB), service.NewRedditService(s.DB))
+	return nil
+}
 
-			posts := redditService.All()
-			if len(posts) == 0 {
-				continue
-			}
+// trackComments saves the comments tracked users have left on tracked posts.
+func trackComments(db *gorm.DB, userRepo *repo.UserRepo, redditService *service.RedditService) {
+	posts := redditService.All()
+	if len(posts) == 0 {
+		return
+	}
 
-			users := userRepo.AllRedditUsernames()
-			if len(users) == 0 {
-				continue
-			}
+	users := userRepo.AllRedditUsernames()
+	if len(users) == 0 {
+		return
+	}
 
-			puMap := make(map[string]map[string]struct{}, len(users))
+	puMap := make(map[string]map[string]struct{}, len(users))
 
-			for _, post := range posts {
-				puMap[post.PostID] = make(map[string]struct{}, len(users))
-				for _, u := range users {
-					puMap[post.PostID][u] = struct{}{}
-				}
-			}
+	for _, post := range posts {
+		puMap[post.PostID] = make(map[string]struct{}, len(users))
+		for _, u := range users {
+			puMap[post.PostID][u] = struct{}{}
+		}
+	}
 
-			ch, wg := make(chan *model.RedditPostComment, len(posts)*len(users)), sync.WaitGroup{}
-			wg.Add(len(posts))
+	ch, wg := make(chan *model.RedditPostComment, len(posts)*len(users)), sync.WaitGroup{}
+	wg.Add(len(posts))
 
-			for postID, users := range puMap {
-				go PollComments(postID, 0, -time.Second, func(p *goreddit.PostAndComments, _ any) (done bool) {
-					done = true
+	for postID, users := range puMap {
+		go func() {
+			defer wg.Done()
 
-					for _, c := range p.Comments {
-						if _, ok := users[c.Author]; ok {
-							ch <- model.NewRedditPostComment(p.Post.ID, c)
-						}
+			PollComments(postID, 0, -time.Second, func(p *goreddit.PostAndComments, _ any) bool {
+				for _, c := range p.Comments {
+					if _, ok := users[c.Author]; ok {
+						ch <- model.NewRedditPostComment(p.Post.ID, c)
 					}
+				}
 
-					wg.Done()
-					return
-				}, nil)
-
-				time.Sleep(time.Millisecond * 100)
-			}
+				return true
+			}, nil)
+		}()
 
-			wg.Wait()
+		time.Sleep(time.Millisecond * 100)
+	}
 
-			data := make([]*model.RedditPostComment, len(ch))
-			for i := 0; i < len(data); i++ {
-				data[i] = <-ch
-			}
+	wg.Wait()
 
-			if len(data) == 0 {
-				continue
-			}
+	data := make([]*model.RedditPostComment, len(ch))
+	for i := 0; i < len(data); i++ {
+		data[i] = <-ch
+	}
 
-			err := db.Clauses(clause.OnConflict{
-				Columns:   []clause.Column{{Name: "post_id"}, {Name: "username"}},
-				DoUpdates: clause.AssignmentColumns([]string{"comment"}),
-			}).Create(&data).Error
+	if len(data) == 0 {
+		return
+	}
 
-			if err != nil {
-				log.Printf("Error saving comments: %q", err.Error())
-			}
-		}
-	}()
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "username"}},
+		DoUpdates: clause.AssignmentColumns([]string{"comment"}),
+	}).Create(&data).Error
 
-	return cancel
+	if err != nil {
+		log.Printf("Error saving comments: %q", err.Error())
+	}
 }
@@ -1,6 +1,3 @@
-//go:build reddit
-// +build reddit
-
 package reddit_test
 
 import (
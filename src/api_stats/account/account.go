@@ -1,6 +1,3 @@
-//go:build stats && account
-// +build stats,account
-
 package account
 
 import (
@@ -15,7 +12,9 @@ import (
 )
 
 func init() {
-	account.Extensions.ExtendAPI(stats.Title, stats.Path, API)
+	api.RegisterIntegration(stats.Path, account.Path, func(s *api.Server) {
+		s.FindCallbackAPI(account.Path).Extend(stats.Title, stats.Path, API)
+	})
 }
 
 func API(c *api.Context, query *botapi.CallbackQuery, cmd *api.CallbackCmd) {
@@ -23,21 +22,23 @@ func API(c *api.Context, query *botapi.CallbackQuery, cmd *api.CallbackCmd) {
 	user := c.GetUser()
 
 	data := make([]string, 4*(len(titles)+2))
-	data[0] = stats.Title
-	data[1] = "Week"
-	data[2] = "Month"
-	data[3] = "All"
+	data[0] = c.T(stats.Title)
+	data[1] = c.T("Week")
+	data[2] = c.T("Month")
+	data[3] = c.T("All")
 
 	i := 8
 
 	for _, title := range titles {
-		if j := strings.LastIndex(title, " "); i != -1 {
-			data[i] = title[:j]
+		name := c.T(title)
+
+		if j := strings.LastIndex(name, " "); i != -1 {
+			data[i] = name[:j]
 		} else {
-			data[i] = title
+			data[i] = name
 		}
 
-		xp := user.UserXPMap[title]
+		xp := user.TotalXP(title)
 
 		for range 3 {
 			data[i+1] = strconv.FormatInt(xp.WeekXP, 10)
@@ -54,7 +55,7 @@ func API(c *api.Context, query *botapi.CallbackQuery, cmd *api.CallbackCmd) {
 		api.MarkdownV2Cols(data, 4),
 	)
 
-	msg.ReplyMarkup = api.InlineKeyboard([]map[string]string{api.KeyboardNavRow(account.Path)})
+	msg.ReplyMarkup = c.InlineKeyboard([]map[string]string{api.KeyboardNavRow(account.Path)})
 	msg.ParseMode = botapi.ModeMarkdownV2
 
 	api.SendUpdate(c.Bot, &msg)
@@ -1,6 +1,3 @@
-//go:build stats
-// +build stats
-
 package stats
 
 import (
@@ -14,12 +11,16 @@ const (
 	Path  = "stats"
 )
 
-var (
-	Extensions api.CallbackExtensions
-)
-
 func init() {
-	api.RegisterCallbackAPI(Path, API)
+	api.RegisterModule(&api.Module{
+		Name: "stats",
+		Setup: func(s *api.Server) {
+			s.RegisterCallbackAPI(API())
+			s.RegisterInlineAction("stats", inlineStats)
+			api.RegisterJobHandler(s.Scheduler, "stats_champions", postChampions)
+			scheduleChampions(s)
+		},
+	})
 }
 
 func API() *api.CallbackAPI {
@@ -27,6 +28,10 @@ func API() *api.CallbackAPI {
 		Title,
 		Path,
 		&api.CallbackConfig{
+			Description: "XP leaderboards, and past champions",
+			Actions: map[string]api.CallbackAction{
+				ChampionsPath: champions,
+			},
 			DynamicActions: func(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) (actions map[string]api.CallbackAction) {
 				actions = make(map[string]api.CallbackAction)
 
@@ -46,12 +51,13 @@ func API() *api.CallbackAPI {
 						options[i] = map[string]string{title: title}
 					}
 
+					options[len(options)-2] = map[string]string{ChampionsTitle: ChampionsPath}
 					options[len(options)-1] = api.KeyboardNavRow("..")
 
 					return
 				}
 
-				return []map[string]string{api.KeyboardNavRow("..")}
+				return []map[string]string{{ChampionsTitle: ChampionsPath}, api.KeyboardNavRow("..")}
 			},
 		},
 	)
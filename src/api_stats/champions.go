@@ -0,0 +1,241 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/i18n"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/render"
+	"github.com/willmroliver/plathbot/src/repo"
+	"github.com/willmroliver/plathbot/src/service"
+)
+
+const (
+	ChampionsTitle = "🏆 Champions"
+	ChampionsPath  = "history"
+
+	// championsPodium is how many places on each XP leaderboard are posted.
+	championsPodium = 3
+	// championsListed is how many past weeks or months can be looked back on.
+	championsListed = 12
+)
+
+var (
+	// championJobs post champions as each period ends. Its scores are kept through the resets
+	// until the next ends, so a late run loses none.
+	championJobs = []struct {
+		name, spec string
+		period     model.Period
+	}{
+		{"weekly champions", "0 0 * * 1", model.PeriodWeek},
+		{"monthly champions", "0 0 1 * *", model.PeriodMonth},
+	}
+
+	medals = []string{"🥇", "🥈", "🥉"}
+)
+
+// scheduleChampions schedules the jobs which save and post each period's champions.
+func scheduleChampions(s *api.Server) {
+	for _, job := range championJobs {
+		schedule, err := api.Cron(job.spec)
+		if err == nil {
+			err = s.Scheduler.Schedule(job.name, "stats_champions", schedule, job.period)
+		}
+
+		if err != nil {
+			log.Printf("Stats: error scheduling %s: %q", job.name, err.Error())
+		}
+	}
+}
+
+// postChampions saves the final standings for the period just ended, then posts them to
+// each chat which has opted in.
+func postChampions(_ context.Context, s *api.Server, period model.Period) error {
+	from := period.Previous(time.Now())
+
+	chats, err := service.NewStandingService(s.DB).Snapshot(period, from)
+	if err != nil {
+		return err
+	}
+
+	r := repo.NewStandingRepo(s.DB)
+	errs := []error{}
+
+	for _, chatID := range chats {
+		if settings := s.ChatSettings(chatID); chatID == model.GlobalChatID || !settings.Champions || !settings.Enabled(Path) {
+			continue
+		}
+
+		standings := r.Get(chatID, period, from)
+		if len(standings) == 0 {
+			continue
+		}
+
+		lang := s.Lang(&botapi.Chat{ID: chatID, Type: "supergroup"}, nil)
+
+		msg := botapi.NewMessage(chatID, championsText(lang, period, from, standings, championsPodium))
+		msg.ParseMode = botapi.ModeHTML
+
+		if _, err := api.SendLong(s.Bot, msg); err != nil {
+			errs = append(errs, fmt.Errorf("chat %d: %w", chatID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// periodLabel names the week or month beginning at from.
+func periodLabel(lang string, period model.Period, from time.Time) string {
+	if period == model.PeriodMonth {
+		return from.Format("January 2006")
+	}
+
+	year, week := from.ISOWeek()
+	return i18n.T(lang, "Week %d, %d", week, year)
+}
+
+// championsText formats standings as HTML, showing up to places on each XP leaderboard,
+// then the top user for each emoji.
+func championsText(lang string, period model.Period, from time.Time, standings []*model.Standing, places int) string {
+	var title string
+	if period == model.PeriodMonth {
+		title = i18n.T(lang, "🏆 %s champions", from.Format("January 2006"))
+	} else {
+		_, week := from.ISOWeek()
+		title = i18n.T(lang, "🏆 Week %d champions", week)
+	}
+
+	text := &strings.Builder{}
+	text.WriteString(string(render.HTML.Bold(title)))
+
+	board := ""
+
+	for _, st := range standings {
+		if st.Kind != model.StandingXP || st.Rank > places {
+			continue
+		}
+
+		if st.Board != board {
+			board = st.Board
+			text.WriteString("\n\n" + string(render.HTML.Bold(i18n.T(lang, board))))
+		}
+
+		place := fmt.Sprintf("%d.", st.Rank)
+		if st.Rank <= len(medals) {
+			place = medals[st.Rank-1]
+		}
+
+		text.WriteString("\n" + string(render.HTML.Sprintf("%s %s - %d", place, standingName(st), st.Score)))
+	}
+
+	reacts := false
+
+	for _, st := range standings {
+		if st.Kind != model.StandingReact {
+			continue
+		}
+
+		if !reacts {
+			reacts = true
+			text.WriteString("\n\n" + string(render.HTML.Bold(i18n.T(lang, "Reactions"))))
+		}
+
+		text.WriteString("\n" + string(render.HTML.Sprintf("%s %s - %d", st.Board, standingName(st), st.Score)))
+	}
+
+	return text.String()
+}
+
+func standingName(st *model.Standing) any {
+	if st.User != nil {
+		return st.User.AtString()
+	}
+
+	return st.UserID
+}
+
+// champions looks back on past champions: choosing a period lists the weeks or months
+// saved, by index, and choosing one of those shows its standings. Paths ending in
+// scoresGlobal show every chat's combined.
+func champions(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
+	path := Path + "/" + ChampionsPath
+
+	period := model.Period(cc.Get())
+	if period != model.PeriodWeek && period != model.PeriodMonth {
+		kb := []map[string]string{
+			{"📰 Weekly": path + "/" + string(model.PeriodWeek)},
+			{"📆 Monthly": path + "/" + string(model.PeriodMonth)},
+			api.KeyboardNavRow(Path),
+		}
+
+		sendChampions(c, render.HTML.Escape(c.T(ChampionsTitle)+"\n\n"+c.T("Choose which champions to look back on.")), kb)
+		return
+	}
+
+	path += "/" + string(period)
+
+	arg := cc.Next().Get()
+	i, err := strconv.Atoi(arg)
+
+	global := arg == scoresGlobal
+	if err == nil {
+		global = cc.Next().Get() == scoresGlobal
+	}
+
+	scope := ""
+	if global {
+		scope = "/" + scoresGlobal
+	}
+
+	chatID := c.ScoresChatID(global)
+	periods := repo.NewStandingRepo(c.Server.DB).Periods(chatID, period, championsListed)
+
+	if err == nil && i >= 0 && i < len(periods) {
+		standings := repo.NewStandingRepo(c.Server.DB).Get(chatID, period, periods[i])
+		text := championsText(c.Lang(), period, periods[i], standings, service.StandingsTop)
+
+		sendChampions(c, text, []map[string]string{api.KeyboardNavRow(path + scope)})
+		return
+	}
+
+	kb := make([]map[string]string, 0, len(periods)+2)
+
+	for i, from := range periods {
+		kb = append(kb, map[string]string{
+			periodLabel(c.Lang(), period, from): fmt.Sprintf("%s/%d%s", path, i, scope),
+		})
+	}
+
+	if toggle := c.ScopeToggle(global, path, path+"/"+scoresGlobal); toggle != nil {
+		kb = append(kb, toggle)
+	}
+
+	kb = append(kb, api.KeyboardNavRow(Path+"/"+ChampionsPath))
+
+	text := c.T("Choose a week or month to look back on.")
+	if len(periods) == 0 {
+		text = c.T("No past champions yet.")
+	}
+
+	sendChampions(c, render.HTML.Escape(c.T(ChampionsTitle)+"\n\n"+text), kb)
+}
+
+func sendChampions(c *api.Context, text string, kb []map[string]string) {
+	msg := botapi.NewEditMessageTextAndMarkup(
+		c.Chat.ID,
+		c.Message.MessageID,
+		text,
+		*c.InlineKeyboard(kb, fmt.Sprintf("user=%d", c.User.ID)),
+	)
+	msg.ParseMode = botapi.ModeHTML
+
+	api.SendUpdate(c.Bot, &msg)
+}
@@ -0,0 +1,58 @@
+package stats
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/repo"
+)
+
+var periods = []string{"all", "month", "week"}
+
+// inlineStats answers "stats [title words...] [all|month|week]" with the combined
+// leaderboards of every chat, for titles containing the words.
+func inlineStats(c *api.Context, q *botapi.InlineQuery, args ...string) []any {
+	want := periods
+	if n := len(args); n > 0 && slices.Contains(periods, strings.ToLower(args[n-1])) {
+		want = []string{strings.ToLower(args[n-1])}
+		args = args[:n-1]
+	}
+
+	search := strings.ToLower(strings.Join(args, " "))
+
+	titles := repo.NewUserXPRepo(c.Server.DB).Titles()
+	slices.Sort(titles)
+
+	results := []any{}
+
+	for i, title := range titles {
+		if !strings.Contains(strings.ToLower(title), search) && !strings.Contains(strings.ToLower(c.T(title)), search) {
+			continue
+		}
+
+		t := XPTitle(title)
+
+		for _, period := range want {
+			label, data, get := t.board(c, model.GlobalChatID, period)
+			text, top := t.table(c, label, data, get)
+			if top == "" {
+				top = c.T("No scores yet")
+			}
+
+			article := botapi.NewInlineQueryResultArticleHTML(
+				fmt.Sprintf("%d-%s", i, period),
+				c.T(title)+" - "+c.T(label),
+				text,
+			)
+			article.Description = top
+
+			results = append(results, article)
+		}
+	}
+
+	return results
+}
@@ -1,6 +1,3 @@
-//go:build stats
-// +build stats
-
 package stats
 
 import (
@@ -11,6 +8,7 @@ import (
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/willmroliver/plathbot/src/api"
 	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/render"
 	"github.com/willmroliver/plathbot/src/repo"
 	"github.com/willmroliver/plathbot/src/util"
 )
@@ -45,87 +43,94 @@ func UserXPAPI(title string) *api.CallbackAPI {
 
 type XPTitle string
 
+// scoresGlobal is appended to a leaderboard's path to show every chat's scores combined.
+const scoresGlobal = "global"
+
 func (t XPTitle) getAll(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
-	r := repo.NewUserXPRepo(c.Server.DB)
-	t.sendTable(
-		c,
-		"⏳ All-Time",
-		r.TopXPs(string(t), "xp DESC", 0, 15, ""),
-		func(xp *model.UserXP) int64 {
-			return xp.XP
-		},
-	)
+	t.sendTable(c, "all", cc.Get() == scoresGlobal)
 }
 
 func (t XPTitle) getMonthly(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
-	now := time.Now()
-	from := util.FirstOfMonth(&now)
-
-	r := repo.NewUserXPRepo(c.Server.DB)
-	t.sendTable(
-		c, "📆 Monthly",
-		r.TopXPs(string(t), "month_xp DESC", 0, 15, "month_from >= ?", from),
-		func(xp *model.UserXP) int64 {
-			return xp.MonthXP
-		},
-	)
+	t.sendTable(c, "month", cc.Get() == scoresGlobal)
 }
 
 func (t XPTitle) getWeekly(c *api.Context, q *botapi.CallbackQuery, cc *api.CallbackCmd) {
-	now := time.Now()
-	from := util.LastMonday(&now)
+	t.sendTable(c, "week", cc.Get() == scoresGlobal)
+}
 
+// board returns the top scores in the chat for the period, "all", "month" or "week", with
+// its label and how to read each score.
+func (t XPTitle) board(c *api.Context, chatID int64, period string) (label string, data []*model.UserXP, get func(*model.UserXP) int64) {
 	r := repo.NewUserXPRepo(c.Server.DB)
-	t.sendTable(
-		c,
-		"📰 Weekly",
-		r.TopXPs(string(t), "week_xp DESC", 0, 15, "week_from >= ?", from),
-		func(xp *model.UserXP) int64 {
-			return xp.WeekXP
-		},
-	)
-}
+	now := time.Now()
 
-func (t XPTitle) sendTable(c *api.Context, title string, data []*model.UserXP, get func(*model.UserXP) int64) {
-	if len(data) == 0 {
-		return
+	switch period {
+	case "month":
+		return "📆 Monthly",
+			r.TopXPs(chatID, string(t), "month_xp DESC", 0, 15, "month_from >= ?", util.FirstOfMonth(&now)),
+			func(xp *model.UserXP) int64 {
+				return xp.MonthXP
+			}
+	case "week":
+		return "📰 Weekly",
+			r.TopXPs(chatID, string(t), "week_xp DESC", 0, 15, "week_from >= ?", util.LastMonday(&now)),
+			func(xp *model.UserXP) int64 {
+				return xp.WeekXP
+			}
 	}
 
-	text := &strings.Builder{}
-	text.WriteString(title + " - " + data[0].Title + "\n\n")
+	return "⏳ All-Time",
+		r.TopXPs(chatID, string(t), "xp DESC", 0, 15, ""),
+		func(xp *model.UserXP) int64 {
+			return xp.XP
+		}
+}
+
+// table formats the board as HTML, along with its top entry as plain text, if any.
+func (t XPTitle) table(c *api.Context, label string, data []*model.UserXP, get func(*model.UserXP) int64) (text, top string) {
+	rows := make([]string, len(data))
 
 	for i, xp := range data {
-		uname := fmt.Sprintf("%d", xp.UserID)
+		var uname any = xp.UserID
+		name := fmt.Sprintf("%d", xp.UserID)
+
 		if xp.User != nil {
-			uname = xp.User.AtString()
+			uname, name = xp.User.AtString(), xp.User.DisplayName()
 		}
 
 		if i == 0 {
-			text.WriteString(fmt.Sprintf(
-				"👑 %s - %d\n",
-				uname,
-				get(xp),
-			))
-			continue
+			rows[i] = string(render.HTML.Sprintf("👑 %s - %d", uname, get(xp)))
+			top = fmt.Sprintf("👑 %s - %d", name, get(xp))
+		} else {
+			rows[i] = string(render.HTML.Sprintf("%d. %s - %d", i+1, uname, get(xp)))
 		}
+	}
+
+	text = render.HTML.Escape(c.T(label)+" - "+c.T(string(t))) + "\n\n" + strings.Join(rows, "\n")
+	return
+}
+
+func (t XPTitle) sendTable(c *api.Context, period string, global bool) {
+	path := XpPath + "/" + string(t)
+
+	label, data, get := t.board(c, c.ScoresChatID(global), period)
+	text, _ := t.table(c, label, data, get)
 
-		text.WriteString(fmt.Sprintf(
-			"%d. %s - %d\n",
-			i+1,
-			uname,
-			get(xp),
-		))
+	kb := []map[string]string{}
+	if toggle := c.ScopeToggle(global, path+"/"+period, path+"/"+period+"/"+scoresGlobal); toggle != nil {
+		kb = append(kb, toggle)
 	}
 
 	msg := botapi.NewEditMessageTextAndMarkup(
 		c.Chat.ID,
 		c.Message.MessageID,
-		text.String(),
-		*api.InlineKeyboard([]map[string]string{
-			api.KeyboardNavRow(XpPath + "/" + string(t)),
-		}, fmt.Sprintf("user=%d", c.User.ID)),
+		text,
+		*c.InlineKeyboard(
+			append(kb, api.KeyboardNavRow(path)),
+			fmt.Sprintf("user=%d", c.User.ID),
+		),
 	)
-	msg.ParseMode = "Markdown"
+	msg.ParseMode = botapi.ModeHTML
 
 	api.SendUpdate(c.Bot, &msg)
 }
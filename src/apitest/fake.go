@@ -0,0 +1,366 @@
+// Package apitest runs api.Server against a fake Telegram Bot API, so that whole flows
+// can be tested without a network, a bot token or a database left lying around.
+package apitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Token is the bot token the fake accepts.
+const Token = "123456:apitest"
+
+// Request is a call made to the fake Bot API.
+type Request struct {
+	Method string
+	Params url.Values
+}
+
+// ChatID returns the chat_id the request was addressed to, or 0.
+func (r *Request) ChatID() int64 {
+	id, _ := strconv.ParseInt(r.Params.Get("chat_id"), 10, 64)
+	return id
+}
+
+// Text returns the text of a sent or edited message.
+func (r *Request) Text() string {
+	return r.Params.Get("text")
+}
+
+// Markup returns the request's inline keyboard, or nil.
+func (r *Request) Markup() *botapi.InlineKeyboardMarkup {
+	raw := r.Params.Get("reply_markup")
+	if raw == "" {
+		return nil
+	}
+
+	mu := &botapi.InlineKeyboardMarkup{}
+	if json.Unmarshal([]byte(raw), mu) != nil {
+		return nil
+	}
+
+	return mu
+}
+
+// Button returns the callback data of the first button whose text contains label.
+func (r *Request) Button(label string) (data string, ok bool) {
+	return Button(r.Markup(), label)
+}
+
+// Button returns the callback data of the keyboard's first button whose text contains label.
+func Button(mu *botapi.InlineKeyboardMarkup, label string) (data string, ok bool) {
+	if mu == nil {
+		return
+	}
+
+	for _, row := range mu.InlineKeyboard {
+		for _, b := range row {
+			if strings.Contains(b.Text, label) && b.CallbackData != nil {
+				return *b.CallbackData, true
+			}
+		}
+	}
+
+	return
+}
+
+// FakeBotAPI emulates the Bot API endpoints the bot uses, recording every request.
+//
+// Messages sent are kept so that edits apply to them, and are given IDs in order.
+// Chats with negative IDs are treated as supergroups, the rest as private chats.
+type FakeBotAPI struct {
+	Self botapi.User
+
+	server   *httptest.Server
+	mu       sync.Mutex
+	requests []*Request
+	messages map[int64]map[int]*botapi.Message
+	members  map[int64]map[int64]string
+	updates  []botapi.Update
+	pushed   chan struct{}
+	hooks    map[string]func(*Request)
+	nextID   int
+}
+
+func NewFakeBotAPI() *FakeBotAPI {
+	f := &FakeBotAPI{
+		Self:     botapi.User{ID: 1, IsBot: true, FirstName: "Plath", UserName: "plathbot"},
+		messages: map[int64]map[int]*botapi.Message{},
+		members:  map[int64]map[int64]string{},
+		pushed:   make(chan struct{}, 1),
+		hooks:    map[string]func(*Request){},
+		nextID:   1,
+	}
+
+	f.server = httptest.NewServer(f)
+	return f
+}
+
+// Endpoint is the API endpoint to give botapi, or BOT_API_ENDPOINT.
+func (f *FakeBotAPI) Endpoint() string {
+	return f.server.URL + "/bot%s/%s"
+}
+
+func (f *FakeBotAPI) Close() {
+	f.server.Close()
+}
+
+// Bot returns a client talking to the fake.
+func (f *FakeBotAPI) Bot() *botapi.BotAPI {
+	bot, err := botapi.NewBotAPIWithAPIEndpoint(Token, f.Endpoint())
+	if err != nil {
+		panic(fmt.Sprintf("apitest: error creating bot: %q", err.Error()))
+	}
+
+	return bot
+}
+
+// SetMember sets a user's status in a chat, as returned by getChatMember and, for
+// "creator" and "administrator", getChatAdministrators.
+func (f *FakeBotAPI) SetMember(chatID, userID int64, status string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.members[chatID] == nil {
+		f.members[chatID] = map[int64]string{}
+	}
+
+	f.members[chatID][userID] = status
+}
+
+// OnRequest calls fn on each request for the method, before the fake answers it.
+func (f *FakeBotAPI) OnRequest(method string, fn func(*Request)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.hooks[method] = fn
+}
+
+// Push queues updates for getUpdates.
+func (f *FakeBotAPI) Push(updates ...botapi.Update) {
+	f.mu.Lock()
+	f.updates = append(f.updates, updates...)
+	f.mu.Unlock()
+
+	select {
+	case f.pushed <- struct{}{}:
+	default:
+	}
+}
+
+// Requests returns the requests made so far, only those for the given methods if any.
+func (f *FakeBotAPI) Requests(methods ...string) (reqs []*Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, r := range f.requests {
+		if len(methods) == 0 || slices.Contains(methods, r.Method) {
+			reqs = append(reqs, r)
+		}
+	}
+
+	return
+}
+
+// Last returns the latest request for any of the given methods, or nil.
+func (f *FakeBotAPI) Last(methods ...string) *Request {
+	if reqs := f.Requests(methods...); len(reqs) != 0 {
+		return reqs[len(reqs)-1]
+	}
+
+	return nil
+}
+
+// Reset forgets the requests recorded so far.
+func (f *FakeBotAPI) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = nil
+}
+
+// Message returns a copy of the current state of a message the bot sent, or nil.
+func (f *FakeBotAPI) Message(chatID int64, messageID int) *botapi.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if m := f.messages[chatID][messageID]; m != nil {
+		copied := *m
+		return &copied
+	}
+
+	return nil
+}
+
+func (f *FakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] != "bot"+Token {
+		reply(w, nil, &botapi.APIResponse{ErrorCode: 401, Description: "Unauthorized"})
+		return
+	}
+
+	if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+		reply(w, nil, &botapi.APIResponse{ErrorCode: 400, Description: err.Error()})
+		return
+	}
+
+	req := &Request{Method: parts[1], Params: r.Form}
+
+	if req.Method == "getUpdates" {
+		reply(w, f.getUpdates(req), nil)
+		return
+	}
+
+	f.mu.Lock()
+	hook := f.hooks[req.Method]
+	f.mu.Unlock()
+
+	if hook != nil {
+		hook(req)
+	}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	result, fail := f.handle(req)
+	f.mu.Unlock()
+
+	reply(w, result, fail)
+}
+
+// handle answers a request. It's called with f.mu held.
+func (f *FakeBotAPI) handle(req *Request) (any, *botapi.APIResponse) {
+	switch req.Method {
+	case "getMe":
+		return f.Self, nil
+	case "sendMessage", "sendPhoto", "sendAnimation", "sendDocument", "sendSticker":
+		return f.send(req), nil
+	case "editMessageText", "editMessageReplyMarkup", "editMessageCaption":
+		if req.Params.Get("inline_message_id") != "" {
+			return true, nil
+		}
+
+		m := f.messages[req.ChatID()][atoi(req.Params.Get("message_id"))]
+		if m == nil {
+			return nil, &botapi.APIResponse{ErrorCode: 400, Description: "Bad Request: message to edit not found"}
+		}
+
+		if req.Method == "editMessageText" {
+			m.Text = req.Text()
+		}
+
+		m.ReplyMarkup = req.Markup()
+		return m, nil
+	case "deleteMessage":
+		delete(f.messages[req.ChatID()], atoi(req.Params.Get("message_id")))
+		return true, nil
+	case "getChatMember":
+		userID, _ := strconv.ParseInt(req.Params.Get("user_id"), 10, 64)
+		return f.member(req.ChatID(), userID), nil
+	case "getChatAdministrators":
+		admins := []botapi.ChatMember{}
+
+		for userID, status := range f.members[req.ChatID()] {
+			if status == "creator" || status == "administrator" {
+				admins = append(admins, f.member(req.ChatID(), userID))
+			}
+		}
+
+		return admins, nil
+	default:
+		// answerCallbackQuery, answerInlineQuery, setMyCommands, deleteWebhook and the like
+		return true, nil
+	}
+}
+
+func (f *FakeBotAPI) send(req *Request) *botapi.Message {
+	chatID := req.ChatID()
+
+	chat := &botapi.Chat{ID: chatID, Type: "private"}
+	if chatID < 0 {
+		chat.Type = "supergroup"
+	}
+
+	m := &botapi.Message{
+		MessageID:   f.nextID,
+		From:        &f.Self,
+		Chat:        chat,
+		Date:        int(time.Now().Unix()),
+		Text:        req.Text(),
+		Caption:     req.Params.Get("caption"),
+		ReplyMarkup: req.Markup(),
+	}
+
+	f.nextID++
+
+	if f.messages[chatID] == nil {
+		f.messages[chatID] = map[int]*botapi.Message{}
+	}
+
+	f.messages[chatID][m.MessageID] = m
+	return m
+}
+
+func (f *FakeBotAPI) member(chatID, userID int64) botapi.ChatMember {
+	status := f.members[chatID][userID]
+	if status == "" {
+		status = "member"
+	}
+
+	return botapi.ChatMember{User: &botapi.User{ID: userID}, Status: status}
+}
+
+// getUpdates long polls for updates from offset, waiting up to a second for any to be pushed.
+func (f *FakeBotAPI) getUpdates(req *Request) []botapi.Update {
+	offset := atoi(req.Params.Get("offset"))
+	deadline := time.After(time.Second)
+
+	for {
+		f.mu.Lock()
+		updates := []botapi.Update{}
+
+		for _, u := range f.updates {
+			if u.UpdateID >= offset {
+				updates = append(updates, u)
+			}
+		}
+		f.mu.Unlock()
+
+		if len(updates) != 0 {
+			return updates
+		}
+
+		select {
+		case <-f.pushed:
+		case <-deadline:
+			return updates
+		}
+	}
+}
+
+func reply(w http.ResponseWriter, result any, fail *botapi.APIResponse) {
+	resp := &botapi.APIResponse{Ok: true}
+
+	if fail != nil {
+		resp = fail
+	} else if raw, err := json.Marshal(result); err == nil {
+		resp.Result = raw
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,121 @@
+package apitest
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/api"
+	"github.com/willmroliver/plathbot/src/db"
+	"gorm.io/gorm"
+)
+
+// Harness is an api.Server wired to a FakeBotAPI and a throwaway database. Updates sent
+// through it are handled synchronously, and everything the handlers queued is delivered
+// to the fake before Send returns.
+type Harness struct {
+	*FakeBotAPI
+	Server *api.Server
+	DB     *gorm.DB
+
+	t        testing.TB
+	updateID int
+}
+
+// New starts a fake Bot API and a Server talking to it. Both are torn down when the test ends.
+func New(t testing.TB) *Harness {
+	t.Helper()
+
+	fake := NewFakeBotAPI()
+	t.Cleanup(fake.Close)
+
+	t.Setenv("BOT_TOKEN", Token)
+	t.Setenv("BOT_API_ENDPOINT", fake.Endpoint())
+
+	conn := DB(t)
+	s := api.NewServer(conn)
+
+	// The fake doesn't rate limit, and tests shouldn't wait on Telegram's budgets
+	api.OutboxFor(s.Bot).SetRateLimited(false)
+
+	return &Harness{FakeBotAPI: fake, Server: s, DB: conn, t: t}
+}
+
+// DB opens a migrated database in a temporary directory, closed when the test ends.
+func DB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	conn, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("apitest.DB() - Unexpected error: %q", err.Error())
+	}
+
+	t.Cleanup(func() { db.Close(conn) })
+
+	if err = db.Migrate(conn); err != nil {
+		t.Fatalf("apitest.DB() - Unexpected error migrating: %q", err.Error())
+	}
+
+	return conn
+}
+
+// Send handles an update, numbering it after the last, and waits for everything it sent.
+func (h *Harness) Send(update botapi.Update) {
+	h.updateID++
+	update.UpdateID = h.updateID
+
+	h.Server.Handle(update)
+	api.OutboxFor(h.Server.Bot).Flush()
+}
+
+// SendText sends a text message from the user in the chat.
+func (h *Harness) SendText(chat *botapi.Chat, from *botapi.User, text string) *botapi.Message {
+	m := &botapi.Message{MessageID: 1000 + h.updateID, From: from, Chat: chat, Text: text}
+	h.Send(botapi.Update{Message: m})
+
+	return m
+}
+
+// Press presses a button with the given callback data on a message the bot sent.
+func (h *Harness) Press(from *botapi.User, m *botapi.Message, data string) {
+	h.Send(botapi.Update{CallbackQuery: &botapi.CallbackQuery{
+		ID:      strconv.Itoa(h.updateID + 1),
+		From:    from,
+		Message: m,
+		Data:    data,
+	}})
+}
+
+// PressButton finds the message's button whose text contains label and presses it,
+// failing the test if there isn't one.
+func (h *Harness) PressButton(from *botapi.User, chatID int64, messageID int, label string) {
+	h.t.Helper()
+
+	m := h.Message(chatID, messageID)
+	if m == nil {
+		h.t.Fatalf("PressButton() - No message %d in chat %d", messageID, chatID)
+	}
+
+	data, ok := Button(m.ReplyMarkup, label)
+	if !ok {
+		h.t.Fatalf("PressButton() - No button %q on message %d: %q", label, messageID, m.Text)
+	}
+
+	h.Press(from, m, data)
+}
+
+// User returns a user with the given ID and username.
+func User(id int64, username string) *botapi.User {
+	return &botapi.User{ID: id, FirstName: username, UserName: username}
+}
+
+// Group returns a supergroup chat. IDs should be negative, as Telegram's are.
+func Group(id int64) *botapi.Chat {
+	return &botapi.Chat{ID: id, Type: "supergroup", Title: "Group " + strconv.FormatInt(-id, 10)}
+}
+
+// Private returns the private chat between the bot and the user.
+func Private(u *botapi.User) *botapi.Chat {
+	return &botapi.Chat{ID: u.ID, Type: "private", UserName: u.UserName}
+}
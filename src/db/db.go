@@ -23,6 +23,29 @@ func Open(name string) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if err = instrument(db); err != nil {
+		return nil, err
+	}
+
 	pool[name] = db
 	return db, nil
 }
+
+// Close closes the connection and removes it from the pool.
+func Close(db *gorm.DB) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for name, conn := range pool {
+		if conn == db {
+			delete(pool, name)
+		}
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.Close()
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"github.com/willmroliver/plathbot/src/metrics"
+	"gorm.io/gorm"
+)
+
+const queryStartKey = "metrics:start"
+
+var queryDuration = metrics.NewHistogram("plathbot_db_query_duration_seconds", "Time taken running database queries, by operation.", metrics.DefBuckets, "op")
+
+// instrument times every query run on db, from before the first of gorm's callbacks to
+// after the last.
+func instrument(db *gorm.DB) error {
+	start := func(tx *gorm.DB) {
+		tx.InstanceSet(queryStartKey, time.Now())
+	}
+
+	observe := func(op string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			if t, ok := tx.InstanceGet(queryStartKey); ok {
+				queryDuration.Since(t.(time.Time), op)
+			}
+		}
+	}
+
+	cb := db.Callback()
+
+	return errors.Join(
+		cb.Create().Before("*").Register("metrics:before_create", start),
+		cb.Create().After("*").Register("metrics:after_create", observe("create")),
+		cb.Query().Before("*").Register("metrics:before_query", start),
+		cb.Query().After("*").Register("metrics:after_query", observe("query")),
+		cb.Update().Before("*").Register("metrics:before_update", start),
+		cb.Update().After("*").Register("metrics:after_update", observe("update")),
+		cb.Delete().Before("*").Register("metrics:before_delete", start),
+		cb.Delete().After("*").Register("metrics:after_delete", observe("delete")),
+		cb.Row().Before("*").Register("metrics:before_row", start),
+		cb.Row().After("*").Register("metrics:after_row", observe("row")),
+		cb.Raw().Before("*").Register("metrics:before_raw", start),
+		cb.Raw().After("*").Register("metrics:after_raw", observe("raw")),
+	)
+}
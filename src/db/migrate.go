@@ -14,6 +14,11 @@ var tables = []any{
 	&model.UserXP{},
 	&model.React{},
 	&model.ReactCount{},
+	&model.UserRole{},
+	&model.MessageHook{},
+	&model.ChatSettings{},
+	&model.ScheduledJob{},
+	&model.Standing{},
 }
 
 func MigrateModel(table any) {
@@ -21,6 +26,13 @@ func MigrateModel(table any) {
 }
 
 func Migrate(db *gorm.DB) (err error) {
+	for _, migrate := range migrations {
+		if err = migrate(db); err != nil {
+			log.Printf("Error migrating: %q", err.Error())
+			return
+		}
+	}
+
 	for _, table := range tables {
 		if err = db.AutoMigrate(table); err != nil {
 			log.Printf("Error migrating %s: %q", reflect.TypeOf(table).Elem().Name(), err.Error())
@@ -0,0 +1,44 @@
+package db_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/willmroliver/plathbot/src/db"
+	"github.com/willmroliver/plathbot/src/model"
+)
+
+func TestMigrateScopesScoresByChat(t *testing.T) {
+	conn, err := db.Open(filepath.Join(t.TempDir(), "scores.db"))
+	if err != nil {
+		t.Fatalf("Open() - Unexpected error: %q", err.Error())
+	}
+	defer db.Close(conn)
+
+	// user_xps as it was before scores were kept per chat
+	conn.Exec(`CREATE TABLE user_xps (
+		title varchar(50), user_id integer, xp integer, week_xp integer, month_xp integer,
+		week_from date, month_from date, PRIMARY KEY (title, user_id)
+	)`)
+	conn.Exec(`INSERT INTO user_xps VALUES ('💕 Engage XP', 7, 120, 20, 40, '2024-01-01', '2024-01-01')`)
+
+	if err = db.Migrate(conn); err != nil {
+		t.Fatalf("Migrate() - Unexpected error: %q", err.Error())
+	}
+
+	xps := []*model.UserXP{}
+	conn.Find(&xps)
+
+	if len(xps) != 1 || xps[0].UserID != 7 || xps[0].XP != 120 || xps[0].ChatID != model.GlobalChatID {
+		t.Fatalf("Migrate() - Expected the row kept under the global chat; Got %+v", xps)
+	}
+
+	// Now chat_id is part of the key, a user can hold the same title in another chat
+	if err = conn.Create(model.NewUserXP("💕 Engage XP", 7, -100)).Error; err != nil {
+		t.Errorf("Create() - Unexpected error: %q", err.Error())
+	}
+
+	if err = db.Migrate(conn); err != nil {
+		t.Errorf("Migrate() - Expected to be safe to run again; Got %q", err.Error())
+	}
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/willmroliver/plathbot/src/model"
+	"gorm.io/gorm"
+)
+
+// migrations run before AutoMigrate, for changes it can't make itself. Each checks
+// whether it's needed, so they're safe to run on every start.
+var migrations = []func(*gorm.DB) error{
+	scopeScoresByChat,
+}
+
+// scopeScoresByChat adds chat_id to the primary keys of user_xps and react_counts. SQLite
+// can't alter a primary key, so the tables are rebuilt, keeping existing rows under
+// model.GlobalChatID.
+func scopeScoresByChat(db *gorm.DB) error {
+	tables := []struct {
+		model   any
+		name    string
+		columns string
+	}{
+		{&model.UserXP{}, "user_xps", "title, user_id, xp, week_xp, month_xp, week_from, month_from"},
+		{&model.ReactCount{}, "react_counts", "emoji, user_id, count, week_count, month_count, week_from, month_from"},
+	}
+
+	for _, t := range tables {
+		m := db.Migrator()
+		if !m.HasTable(t.name) || m.HasColumn(t.model, "ChatID") {
+			continue
+		}
+
+		log.Printf("Migrating %s to per-chat scores...", t.name)
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			old := t.name + "_unscoped"
+
+			if err := tx.Migrator().RenameTable(t.name, old); err != nil {
+				return err
+			}
+
+			if err := tx.AutoMigrate(t.model); err != nil {
+				return err
+			}
+
+			insert := fmt.Sprintf("INSERT INTO %s (%s, chat_id) SELECT %s, ? FROM %s", t.name, t.columns, t.columns, old)
+			if err := tx.Exec(insert, model.GlobalChatID).Error; err != nil {
+				return err
+			}
+
+			return tx.Migrator().DropTable(old)
+		})
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.name, err)
+		}
+	}
+
+	return nil
+}
@@ -26,7 +26,7 @@ func NewLRUCache[K comparable, V any](lim int) *LRUCache[K, V] {
 func (c *LRUCache[K, V]) Save(k K, v V) {
 	if n, ok := c.data[k]; ok {
 		n.Detach()
-		c.ll.Unshift(&KVPair[K, V]{k, v})
+		c.data[k] = c.ll.Unshift(&KVPair[K, V]{k, v})
 		return
 	}
 
@@ -41,7 +41,7 @@ func (c *LRUCache[K, V]) Save(k K, v V) {
 func (c *LRUCache[K, V]) Load(k K) (val V, ok bool) {
 	if node, ok := c.data[k]; ok {
 		node.Detach()
-		c.ll.Unshift(node.Val)
+		c.data[k] = c.ll.Unshift(node.Val)
 		return node.Val.val, ok
 	}
 
@@ -52,3 +52,27 @@ func TestLRUCache(t *testing.T) {
 		}
 	}
 }
+
+func TestLRUCacheResave(t *testing.T) {
+	cache := ds.NewLRUCache[int, string](2)
+
+	cache.Save(1, "one")
+	cache.Save(1, "uno")
+	cache.Save(2, "two")
+
+	for range 2 {
+		if val, ok := cache.Load(1); !ok || val != "uno" {
+			t.Errorf("Load() - Expected ok, %s; Got %v, %s", "uno", ok, val)
+		}
+	}
+
+	cache.Save(3, "three")
+
+	if val, ok := cache.Load(2); ok {
+		t.Errorf("Load() - Expected !ok; Got %v, %s", ok, val)
+	}
+
+	if val, ok := cache.Load(1); !ok || val != "uno" {
+		t.Errorf("Load() - Expected ok, %s; Got %v, %s", "uno", ok, val)
+	}
+}
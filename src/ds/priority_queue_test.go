@@ -1,6 +1,8 @@
 package ds_test
 
 import (
+	"math/rand"
+	"slices"
 	"testing"
 
 	"github.com/willmroliver/plathbot/src/ds"
@@ -53,3 +55,39 @@ func TestPriorityQueue(t *testing.T) {
 		}
 	}
 }
+
+func TestPriorityQueueOrder(t *testing.T) {
+	pq := ds.NewPriorityQueue[int](func(a, b int) int {
+		return b - a
+	})
+
+	// Min-heap of mixed pushes & pops, checked against a sorted copy
+	r := rand.New(rand.NewSource(1))
+	var want []int
+
+	for range 1000 {
+		if r.Intn(3) == 0 && pq.Len() > 0 {
+			if m, n := pq.Pop(), want[0]; m != n {
+				t.Fatalf("Min Heap did not return min value. Expected %d, Got %d", n, m)
+			}
+
+			want = want[1:]
+			continue
+		}
+
+		n := r.Intn(100)
+		pq.Push(n)
+		want = append(want, n)
+		slices.Sort(want)
+	}
+
+	for _, n := range want {
+		if m := pq.Pop(); m != n {
+			t.Errorf("Min Heap did not return min value. Expected %d, Got %d", n, m)
+		}
+	}
+
+	if pq.Len() != 0 {
+		t.Errorf("Expected an empty queue, Got %d", pq.Len())
+	}
+}
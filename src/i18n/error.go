@@ -0,0 +1,37 @@
+package i18n
+
+import "errors"
+
+// Localizer is implemented by errors, and other values, which can describe themselves
+// in a given language.
+type Localizer interface {
+	Localize(lang string) string
+}
+
+// Error is an error whose message can be translated. Error() gives the English text.
+type Error struct {
+	Text string
+	Args []any
+}
+
+// Errorf returns an Error, formatting text with args once translated.
+func Errorf(text string, args ...any) error {
+	return &Error{text, args}
+}
+
+func (e *Error) Error() string {
+	return format(e.Text, e.Args)
+}
+
+func (e *Error) Localize(lang string) string {
+	return T(lang, e.Text, e.Args...)
+}
+
+// ErrorText translates err into lang if it is, or wraps, a Localizer, else gives err.Error().
+func ErrorText(lang string, err error) string {
+	if l := Localizer(nil); errors.As(err, &l) {
+		return l.Localize(lang)
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,230 @@
+// Package i18n translates user-facing text using message catalogues embedded from locales/.
+//
+// Messages are keyed by their English source text, so code reads as it always has and
+// anything missing from a catalogue falls back to English. Text is interpolated with fmt
+// verbs, which translations can reorder using explicit indexes, e.g. "%[2]s ... %[1]s".
+//
+// A catalogue is a JSON file named for its language:
+//
+//	{
+//		"name": "Español",
+//		"messages": {
+//			"Something went wrong.": "Algo salió mal.",
+//			"%d game": {"one": "%d partida", "other": "%d partidas"}
+//		}
+//	}
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Default is the language of the source text.
+const Default = "en"
+
+//go:embed locales/*.json
+var locales embed.FS
+
+var (
+	mu         sync.RWMutex
+	catalogues = map[string]*Catalogue{}
+)
+
+func init() {
+	if err := Load(locales, "locales"); err != nil {
+		log.Panicf("i18n: error loading catalogues: %q", err.Error())
+	}
+}
+
+// Message is a translation, by plural category. Plain strings are held as "other".
+type Message map[string]string
+
+func (m *Message) UnmarshalJSON(b []byte) error {
+	var text string
+	if err := json.Unmarshal(b, &text); err == nil {
+		*m = Message{"other": text}
+		return nil
+	}
+
+	forms := map[string]string{}
+	if err := json.Unmarshal(b, &forms); err != nil {
+		return err
+	}
+
+	if forms["other"] == "" {
+		return fmt.Errorf("plural message %v has no \"other\" form", forms)
+	}
+
+	*m = forms
+	return nil
+}
+
+// Catalogue holds the translations for a language.
+type Catalogue struct {
+	Lang     string             `json:"-"`
+	Name     string             `json:"name"`
+	Messages map[string]Message `json:"messages"`
+}
+
+// Load reads every .json catalogue in dir, replacing any already loaded for the same language.
+func Load(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		b, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		c := &Catalogue{}
+		if err = json.Unmarshal(b, c); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		c.Lang = strings.TrimSuffix(path.Base(file), ".json")
+		Register(c)
+	}
+
+	return nil
+}
+
+// Register adds or replaces the catalogue for c.Lang. Keys are trimmed of surrounding
+// whitespace, as text is when it's looked up.
+func Register(c *Catalogue) {
+	messages := make(map[string]Message, len(c.Messages))
+	for text, m := range c.Messages {
+		messages[strings.TrimSpace(text)] = m
+	}
+
+	c.Messages = messages
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	catalogues[c.Lang] = c
+}
+
+// Languages returns the loaded catalogues, ordered by language code.
+func Languages() []*Catalogue {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	langs := make([]*Catalogue, 0, len(catalogues))
+	for _, c := range catalogues {
+		langs = append(langs, c)
+	}
+
+	slices.SortFunc(langs, func(a, b *Catalogue) int {
+		return strings.Compare(a.Lang, b.Lang)
+	})
+
+	return langs
+}
+
+// Match returns the loaded language best matching an IETF code like "es-MX", or "" if none.
+func Match(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(code, "_", "-"))
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for code != "" {
+		if _, ok := catalogues[code]; ok {
+			return code
+		}
+
+		i := strings.LastIndex(code, "-")
+		if i == -1 {
+			break
+		}
+
+		code = code[:i]
+	}
+
+	return ""
+}
+
+// Name returns a language's name, in that language.
+func Name(lang string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if c, ok := catalogues[lang]; ok && c.Name != "" {
+		return c.Name
+	}
+
+	return lang
+}
+
+// T translates text into lang, then formats it with args, if any.
+func T(lang, text string, args ...any) string {
+	if m := lookup(lang, text); m != nil {
+		text = pad(text, m["other"])
+	}
+
+	return format(text, args)
+}
+
+// N translates the plural form of a message for n, then formats it with args. The key
+// is the English singular, one, and other is the English plural. Callers pass n in args
+// themselves if it's to be shown.
+func N(lang string, n int, one, other string, args ...any) string {
+	text := other
+	if n == 1 {
+		text = one
+	}
+
+	if m := lookup(lang, one); m != nil {
+		form, ok := m[Plural(lang, n)]
+		if !ok {
+			form = m["other"]
+		}
+
+		text = pad(text, form)
+	}
+
+	return format(text, args)
+}
+
+// lookup finds text's translation, ignoring surrounding whitespace, such as the
+// indentation left in raw string literals.
+func lookup(lang, text string) Message {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if c, ok := catalogues[lang]; ok {
+		return c.Messages[strings.TrimSpace(text)]
+	}
+
+	return nil
+}
+
+// pad gives a translation the whitespace surrounding its source text.
+func pad(text, tr string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return tr
+	}
+
+	i := strings.Index(text, trimmed)
+	return text[:i] + strings.TrimSpace(tr) + text[i+len(trimmed):]
+}
+
+func format(text string, args []any) string {
+	if len(args) == 0 {
+		return text
+	}
+
+	return fmt.Sprintf(text, args...)
+}
@@ -0,0 +1,80 @@
+package i18n_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/willmroliver/plathbot/src/i18n"
+)
+
+func TestTranslate(t *testing.T) {
+	if got := i18n.T("es", "✅ Linked to %q", "plathfan"); got != `✅ Vinculada a "plathfan"` {
+		t.Errorf("T() - Expected Spanish; Got %q", got)
+	}
+
+	if got := i18n.T("es", "Not in any catalogue %d", 3); got != "Not in any catalogue 3" {
+		t.Errorf("T() - Expected English fallback; Got %q", got)
+	}
+
+	// Keys are matched trimmed, and translations keep the source's surrounding whitespace
+	if got := i18n.T("es", "\n\t\t✅ Linked to %q\n\t", "plathfan"); got != "\n\t\t✅ Vinculada a \"plathfan\"\n\t" {
+		t.Errorf("T() - Expected Spanish for indented text; Got %q", got)
+	}
+
+	if got := i18n.T("xx", "Week"); got != "Week" {
+		t.Errorf("T() - Expected English for unknown language; Got %q", got)
+	}
+
+	for n, want := range map[int]string{1: "🎨 1 foto de perfil 📸", 4: "🎨 4 fotos de perfil 📸"} {
+		if got := i18n.N("es", n, "🎨 %d PFP 📸", "🎨 %d PFPs 📸", n); got != want {
+			t.Errorf("N() - Expected %q; Got %q", want, got)
+		}
+	}
+
+	if got := i18n.N("en", 0, "%d PFP", "%d PFPs", 0); got != "0 PFPs" {
+		t.Errorf("N() - Expected English plural; Got %q", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for code, want := range map[string]string{"es": "es", "es-MX": "es", "en_GB": "en", "xx": "", "": ""} {
+		if got := i18n.Match(code); got != want {
+			t.Errorf("Match(%q) - Expected %q; Got %q", code, want, got)
+		}
+	}
+}
+
+func TestErrorText(t *testing.T) {
+	err := fmt.Errorf("parsing: %w", i18n.Errorf("Invalid post ID."))
+
+	if got := i18n.ErrorText("es", err); got != "ID de publicación no válido." {
+		t.Errorf("ErrorText() - Expected Spanish; Got %q", got)
+	}
+
+	if got := err.Error(); got != "parsing: Invalid post ID." {
+		t.Errorf("Error() - Expected English; Got %q", got)
+	}
+}
+
+// Translations must take the same arguments as their source text.
+func TestCatalogueVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*[a-zA-Z]`)
+
+	for _, c := range i18n.Languages() {
+		for text, m := range c.Messages {
+			if text != strings.TrimSpace(text) {
+				t.Errorf("%s %q - Expected the key to be trimmed", c.Lang, text)
+			}
+
+			want := len(verbs.FindAllString(text, -1))
+
+			for form, tr := range m {
+				if got := len(verbs.FindAllString(tr, -1)); got != want {
+					t.Errorf("%s %q (%s) - Expected %d verbs; Got %d", c.Lang, text, form, want, got)
+				}
+			}
+		}
+	}
+}
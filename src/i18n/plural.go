@@ -0,0 +1,78 @@
+package i18n
+
+// PluralRule returns the CLDR plural category of n: "one", "few", "many" or "other".
+type PluralRule func(n int) string
+
+var (
+	pluralOne = func(n int) string {
+		if n == 1 {
+			return "one"
+		}
+
+		return "other"
+	}
+
+	pluralZeroOne = func(n int) string {
+		if n == 0 || n == 1 {
+			return "one"
+		}
+
+		return "other"
+	}
+
+	pluralSlavic = func(n int) string {
+		switch n10, n100 := n%10, n%100; {
+		case n10 == 1 && n100 != 11:
+			return "one"
+		case n10 >= 2 && n10 <= 4 && (n100 < 12 || n100 > 14):
+			return "few"
+		default:
+			return "many"
+		}
+	}
+
+	pluralNone = func(n int) string {
+		return "other"
+	}
+
+	pluralRules = map[string]PluralRule{
+		"en": pluralOne,
+		"es": pluralOne,
+		"de": pluralOne,
+		"it": pluralOne,
+		"nl": pluralOne,
+		"fr": pluralZeroOne,
+		"pt": pluralZeroOne,
+		"ru": pluralSlavic,
+		"uk": pluralSlavic,
+		"id": pluralNone,
+		"ja": pluralNone,
+		"ko": pluralNone,
+		"zh": pluralNone,
+	}
+)
+
+// SetPluralRule sets the rule for a language, for catalogues added with Register.
+func SetPluralRule(lang string, rule PluralRule) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	pluralRules[lang] = rule
+}
+
+// Plural returns the plural category of n in lang. Languages without a rule use English's.
+func Plural(lang string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	mu.RLock()
+	rule, ok := pluralRules[lang]
+	mu.RUnlock()
+
+	if !ok {
+		rule = pluralOne
+	}
+
+	return rule(n)
+}
@@ -1,6 +0,0 @@
-//go:build account
-// +build account
-
-package include
-
-import _ "github.com/willmroliver/plathbot/src/api_account"
@@ -1,6 +0,0 @@
-//go:build emoji
-// +build emoji
-
-package include
-
-import _ "github.com/willmroliver/plathbot/src/api_emoji"
@@ -1,6 +0,0 @@
-//go:build emoji && stats
-// +build emoji,stats
-
-package include
-
-import _ "github.com/willmroliver/plathbot/src/api_emoji/stats"
@@ -1,6 +0,0 @@
-//go:build games
-// +build games
-
-package include
-
-import _ "github.com/willmroliver/plathbot/src/api_games"
@@ -0,0 +1,15 @@
+// Package include compiles in every module, each registering itself with api.RegisterModule.
+// Which are enabled is decided at startup, by the MODULES setting.
+package include
+
+import (
+	_ "github.com/willmroliver/plathbot/src/api_account"
+	_ "github.com/willmroliver/plathbot/src/api_emoji"
+	_ "github.com/willmroliver/plathbot/src/api_emoji/stats"
+	_ "github.com/willmroliver/plathbot/src/api_games"
+	_ "github.com/willmroliver/plathbot/src/api_pfp"
+	_ "github.com/willmroliver/plathbot/src/api_reddit"
+	_ "github.com/willmroliver/plathbot/src/api_reddit/account"
+	_ "github.com/willmroliver/plathbot/src/api_stats"
+	_ "github.com/willmroliver/plathbot/src/api_stats/account"
+)
@@ -1,6 +0,0 @@
-//go:build pfp
-// +build pfp
-
-package include
-
-import _ "github.com/willmroliver/plathbot/src/api_pfp"
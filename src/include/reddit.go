@@ -1,6 +0,0 @@
-//go:build reddit
-// +build reddit
-
-package include
-
-import _ "github.com/willmroliver/plathbot/src/api_reddit"
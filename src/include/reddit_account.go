@@ -1,6 +0,0 @@
-//go:build reddit && account
-// +build reddit,account
-
-package include
-
-import _ "github.com/willmroliver/plathbot/src/api_reddit/account"
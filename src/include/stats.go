@@ -1,6 +0,0 @@
-//go:build stats
-// +build stats
-
-package include
-
-import _ "github.com/willmroliver/plathbot/src/api_stats"
@@ -1,6 +0,0 @@
-//go:build stats && account
-// +build stats,account
-
-package include
-
-import _ "github.com/willmroliver/plathbot/src/api_stats/account"
@@ -0,0 +1,12 @@
+package metrics
+
+// Unregister removes metrics registered by a test, so that it can register them again
+// when run more than once.
+func Unregister(names ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, name := range names {
+		delete(families, name)
+	}
+}
@@ -0,0 +1,279 @@
+// Package metrics keeps counters, gauges and histograms in memory, and serves them in
+// the Prometheus text format. It covers what the bot needs without a client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets suit latencies in seconds, from a cached lookup up to a slow Telegram call.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	families = map[string]*family{}
+	mutex    = sync.Mutex{}
+)
+
+type kind string
+
+const (
+	kindCounter   kind = "counter"
+	kindGauge     kind = "gauge"
+	kindHistogram kind = "histogram"
+)
+
+// family is a metric and its series, one for each combination of label values seen.
+type family struct {
+	name, help string
+	kind       kind
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	values []string
+	value  float64
+	fn     func() float64
+
+	// Histograms only, counts holding one per bucket, not cumulative
+	counts []uint64
+	count  uint64
+}
+
+func register(name, help string, k kind, labels []string, buckets []float64) *family {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, ok := families[name]; ok {
+		log.Panicf("Metrics: %s is already registered", name)
+	}
+
+	f := &family{name: name, help: help, kind: k, labels: labels, buckets: buckets, series: map[string]*series{}}
+	families[name] = f
+
+	return f
+}
+
+// get returns the series for the label values, creating it if it's new. f.mu must be held.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		log.Panicf("Metrics: %s takes %d label values; Got %d", f.name, len(f.labels), len(values))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: slices.Clone(values)}
+		if f.kind == kindHistogram {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// Counter is a total which only goes up, such as updates handled.
+type Counter struct {
+	f *family
+}
+
+// NewCounter registers a counter, with a value for each combination of the labels.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{register(name, help, kindCounter, labels, nil)}
+}
+
+// Inc adds one to the series with the label values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which mustn't be negative, to the series with the label values.
+func (c *Counter) Add(v float64, values ...string) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+
+	c.f.get(values).value += v
+}
+
+// Gauge is a value which goes up and down, such as games in progress.
+type Gauge struct {
+	f *family
+}
+
+// NewGauge registers a gauge, with a value for each combination of the labels.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{register(name, help, kindGauge, labels, nil)}
+}
+
+// Set sets the series with the label values to v.
+func (g *Gauge) Set(v float64, values ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	s := g.f.get(values)
+	s.value, s.fn = v, nil
+}
+
+// Func has the series with the label values read from fn whenever metrics are collected,
+// for values already tracked elsewhere.
+func (g *Gauge) Func(fn func() float64, values ...string) {
+	g.f.mu.Lock()
+	defer g.f.mu.Unlock()
+
+	g.f.get(values).fn = fn
+}
+
+// Histogram counts observations, such as latencies, into buckets.
+type Histogram struct {
+	f *family
+}
+
+// NewHistogram registers a histogram with the given upper bounds, which must be sorted,
+// and a series for each combination of the labels.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{register(name, help, kindHistogram, labels, buckets)}
+}
+
+// Observe adds v to the series with the label values.
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+
+	s := h.f.get(values)
+	s.value += v
+	s.count++
+
+	if i, _ := slices.BinarySearch(h.f.buckets, v); i < len(s.counts) {
+		s.counts[i]++
+	}
+}
+
+// Since observes the seconds elapsed since start.
+func (h *Histogram) Since(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+// Write writes every metric in the Prometheus text format, ordered by name then labels.
+func Write(w io.Writer) error {
+	mutex.Lock()
+	all := slices.SortedFunc(maps.Values(families), func(a, b *family) int {
+		return strings.Compare(a.name, b.name)
+	})
+	mutex.Unlock()
+
+	b := &strings.Builder{}
+
+	for _, f := range all {
+		f.write(b)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the metrics, for Prometheus to scrape.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		if err := Write(w); err != nil {
+			log.Printf("Metrics: Error writing metrics: %q", err.Error())
+		}
+	})
+}
+
+func (f *family) write(b *strings.Builder) {
+	f.mu.Lock()
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	all := make([]series, len(keys))
+	for i, key := range keys {
+		all[i] = *f.series[key]
+		all[i].counts = slices.Clone(all[i].counts)
+	}
+	f.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+
+	for _, s := range all {
+		if s.fn != nil {
+			s.value = s.fn()
+		}
+
+		if f.kind != kindHistogram {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, f.labelString(s.values, "", ""), formatFloat(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, le := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelString(s.values, "le", formatFloat(le)), cumulative)
+		}
+
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, f.labelString(s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, f.labelString(s.values, "", ""), formatFloat(s.value))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, f.labelString(s.values, "", ""), s.count)
+	}
+}
+
+// labelString formats the labels as {name="value",...}, with an extra label if one is named.
+func (f *family) labelString(values []string, extra, extraValue string) string {
+	if len(values) == 0 && extra == "" {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(values)+1)
+	for i, v := range values {
+		pairs = append(pairs, f.labels[i]+`="`+escapeLabel(v)+`"`)
+	}
+
+	if extra != "" {
+		pairs = append(pairs, extra+`="`+extraValue+`"`)
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
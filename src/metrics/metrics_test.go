@@ -0,0 +1,56 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/willmroliver/plathbot/src/metrics"
+)
+
+func TestWrite(t *testing.T) {
+	t.Cleanup(func() { metrics.Unregister("test_updates_total", "test_games_active", "test_duration_seconds") })
+
+	counter := metrics.NewCounter("test_updates_total", "Updates handled", "type")
+	counter.Inc("message")
+	counter.Add(2, "callback_query")
+	counter.Inc(`say "hi"`)
+
+	gauge := metrics.NewGauge("test_games_active", "Games in progress", "game")
+	gauge.Set(3, "cointoss")
+	gauge.Func(func() float64 { return 5 }, "connect4")
+
+	histogram := metrics.NewHistogram("test_duration_seconds", "How long it took", []float64{0.1, 1})
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+	histogram.Observe(2)
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Handler() - Expected the Prometheus text format; Got %q", ct)
+	}
+
+	expected := `# HELP test_duration_seconds How long it took
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{le="0.1"} 1
+test_duration_seconds_bucket{le="1"} 2
+test_duration_seconds_bucket{le="+Inf"} 3
+test_duration_seconds_sum 2.55
+test_duration_seconds_count 3
+# HELP test_games_active Games in progress
+# TYPE test_games_active gauge
+test_games_active{game="cointoss"} 3
+test_games_active{game="connect4"} 5
+# HELP test_updates_total Updates handled
+# TYPE test_updates_total counter
+test_updates_total{type="callback_query"} 2
+test_updates_total{type="message"} 1
+test_updates_total{type="say \"hi\""} 1
+`
+
+	if got := w.Body.String(); got != expected {
+		t.Errorf("Write() - Expected:\n%s\nGot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// XPKind is something a user earns XP for, which chats can reward differently.
+type XPKind string
+
+const (
+	XPMessage  XPKind = "message"
+	XPReaction XPKind = "reaction"
+	XPGameWin  XPKind = "game"
+)
+
+// DefaultXP is the XP each kind earns in chats which haven't overridden it.
+var DefaultXP = map[XPKind]int64{
+	XPMessage:  10,
+	XPReaction: 10,
+	XPGameWin:  100,
+}
+
+// ChatSettings holds a chat's preferences. Chats without a row use the defaults.
+type ChatSettings struct {
+	ChatID   int64  `json:"chat_id" gorm:"primaryKey;autoIncrement:false"`
+	Language string `json:"language" gorm:"size:16"`
+	// Disabled lists the modules turned off in the chat, comma-separated. Modules are
+	// menus, by path, and commands, by name.
+	Disabled string `json:"disabled" gorm:"type:text"`
+	// Cooldown overrides how long public menus and commands wait before they can be used again.
+	Cooldown   *time.Duration `json:"cooldown" gorm:"default:null"`
+	MessageXP  *int64         `json:"message_xp" gorm:"default:null"`
+	ReactionXP *int64         `json:"reaction_xp" gorm:"default:null"`
+	GameXP     *int64         `json:"game_xp" gorm:"default:null"`
+	// Champions opts the chat in to posts naming the week's and month's top scorers.
+	Champions bool      `json:"champions"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Enabled reports whether a module is on in the chat.
+func (s *ChatSettings) Enabled(module string) bool {
+	return !slices.Contains(s.disabled(), module)
+}
+
+// SetEnabled turns a module on or off in the chat.
+func (s *ChatSettings) SetEnabled(module string, on bool) {
+	disabled := slices.DeleteFunc(s.disabled(), func(m string) bool {
+		return m == module
+	})
+
+	if !on {
+		disabled = append(disabled, module)
+	}
+
+	slices.Sort(disabled)
+	s.Disabled = strings.Join(disabled, ",")
+}
+
+func (s *ChatSettings) disabled() []string {
+	if s.Disabled == "" {
+		return nil
+	}
+
+	return strings.Split(s.Disabled, ",")
+}
+
+// CooldownOr returns the chat's cooldown, or def if it hasn't set one.
+func (s *ChatSettings) CooldownOr(def time.Duration) time.Duration {
+	if s.Cooldown != nil {
+		return *s.Cooldown
+	}
+
+	return def
+}
+
+// XP returns what kind earns in the chat.
+func (s *ChatSettings) XP(kind XPKind) int64 {
+	if s.OverridesXP(kind) {
+		return **s.xp(kind)
+	}
+
+	return DefaultXP[kind]
+}
+
+// OverridesXP reports whether the chat has set its own XP for kind.
+func (s *ChatSettings) OverridesXP(kind XPKind) bool {
+	xp := s.xp(kind)
+	return xp != nil && *xp != nil
+}
+
+// SetXP overrides what kind earns in the chat, or goes back to the default if xp is nil.
+func (s *ChatSettings) SetXP(kind XPKind, xp *int64) {
+	if field := s.xp(kind); field != nil {
+		*field = xp
+	}
+}
+
+func (s *ChatSettings) xp(kind XPKind) **int64 {
+	switch kind {
+	case XPMessage:
+		return &s.MessageXP
+	case XPReaction:
+		return &s.ReactionXP
+	case XPGameWin:
+		return &s.GameXP
+	}
+
+	return nil
+}
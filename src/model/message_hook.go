@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// MessageHook is a pending message hook saved so it can outlive a restart. Handler names
+// the registered handler it's resumed with, and is empty for hooks which can't be resumed.
+type MessageHook struct {
+	Scope     int       `json:"scope" gorm:"primaryKey;autoIncrement:false"`
+	OwnerID   int64     `json:"owner_id" gorm:"primaryKey;autoIncrement:false"`
+	Namespace string    `json:"namespace" gorm:"primaryKey;size:32"`
+	Handler   string    `json:"handler" gorm:"size:64"`
+	ChatID    int64     `json:"chat_id"`
+	ReplyTo   int       `json:"reply_to"`
+	Data      []byte    `json:"data"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -9,20 +9,28 @@ import (
 type ReactCount struct {
 	Emoji      string    `json:"emoji" gorm:"primaryKey;type:char(4)"`
 	UserID     int64     `json:"user_id" gorm:"primaryKey"`
+	ChatID     int64     `json:"chat_id" gorm:"primaryKey;autoIncrement:false;default:0"`
 	User       *User     `json:"user" gorm:"foreignKey:UserID;references:ID"`
 	Count      int       `json:"count"`
 	WeekCount  int       `json:"week_count"`
 	MonthCount int       `json:"month_count"`
 	WeekFrom   time.Time `json:"week_from" gorm:"type:date"`
 	MonthFrom  time.Time `json:"month_from" gorm:"type:date"`
+	// The last week's and month's counts are kept when they reset, until their standings
+	// are saved.
+	PrevWeekCount  int        `json:"prev_week_count"`
+	PrevMonthCount int        `json:"prev_month_count"`
+	PrevWeekFrom   *time.Time `json:"prev_week_from" gorm:"type:date;default:null"`
+	PrevMonthFrom  *time.Time `json:"prev_month_from" gorm:"type:date;default:null"`
 }
 
-func NewReactCount(emoji string, userID int64) *ReactCount {
+func NewReactCount(emoji string, userID, chatID int64) *ReactCount {
 	now := time.Now()
 
 	return &ReactCount{
 		Emoji:     emoji,
 		UserID:    userID,
+		ChatID:    chatID,
 		WeekFrom:  util.LastMonday(&now),
 		MonthFrom: util.FirstOfMonth(&now),
 	}
@@ -1,6 +1,3 @@
-//go:build reddit
-// +build reddit
-
 package model
 
 import "github.com/vartanbeno/go-reddit/v2/reddit"
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// ScheduledJob is a job run by the scheduler, saved so that it outlives a restart along
+// with whether it's paused and how its last run went. Handler names the registered handler
+// it runs, and Schedule when, e.g. "@every 2m0s" or a cron expression.
+type ScheduledJob struct {
+	Name     string        `json:"name" gorm:"primaryKey;size:64"`
+	Handler  string        `json:"handler" gorm:"size:64"`
+	Schedule string        `json:"schedule" gorm:"size:128"`
+	Jitter   time.Duration `json:"jitter"`
+	Overlap  bool          `json:"overlap"`
+	Data     []byte        `json:"-"`
+	Paused   bool          `json:"paused"`
+	NextRun  time.Time     `json:"next_run"`
+
+	LastRun      time.Time     `json:"last_run"`
+	LastDuration time.Duration `json:"last_duration"`
+	// LastError is empty if the last run succeeded.
+	LastError string `json:"last_error" gorm:"type:text"`
+	Runs      int    `json:"runs"`
+	Failures  int    `json:"failures"`
+	Skips     int    `json:"skips"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
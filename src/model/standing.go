@@ -0,0 +1,63 @@
+package model
+
+import (
+	"time"
+
+	"github.com/willmroliver/plathbot/src/util"
+)
+
+// Period is a stretch of time weekly and monthly scores are counted over. Weeks begin on
+// Monday, and both begin at midnight, local time.
+type Period string
+
+const (
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+)
+
+// Start returns when the period containing t began.
+func (p Period) Start(t time.Time) time.Time {
+	if p == PeriodMonth {
+		return util.FirstOfMonth(&t)
+	}
+
+	return util.LastMonday(&t)
+}
+
+// End returns when the period beginning at from ends.
+func (p Period) End(from time.Time) time.Time {
+	if p == PeriodMonth {
+		return from.AddDate(0, 1, 0)
+	}
+
+	return from.AddDate(0, 0, 7)
+}
+
+// Previous returns when the period before the one containing t began.
+func (p Period) Previous(t time.Time) time.Time {
+	return p.Start(p.Start(t).AddDate(0, 0, -1))
+}
+
+// StandingKind is what a standing ranks users by.
+type StandingKind string
+
+const (
+	// StandingXP ranks XP earned, with Board naming the XP title.
+	StandingXP StandingKind = "xp"
+	// StandingReact ranks reactions received, with Board naming the emoji.
+	StandingReact StandingKind = "react"
+)
+
+// Standing is a user's final place on a leaderboard for a week or month, saved as the
+// period ends so that it outlives the scores being reset.
+type Standing struct {
+	Period Period       `json:"period" gorm:"primaryKey;size:8"`
+	From   time.Time    `json:"from" gorm:"primaryKey;type:date"`
+	ChatID int64        `json:"chat_id" gorm:"primaryKey;autoIncrement:false"`
+	Kind   StandingKind `json:"kind" gorm:"primaryKey;size:8"`
+	Board  string       `json:"board" gorm:"primaryKey;size:50"`
+	Rank   int          `json:"rank" gorm:"primaryKey;autoIncrement:false"`
+	UserID int64        `json:"user_id"`
+	User   *User        `json:"user" gorm:"foreignKey:UserID;references:ID"`
+	Score  int64        `json:"score"`
+}
@@ -2,9 +2,9 @@ package model
 
 import (
 	"fmt"
-	"log"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/render"
 	"gorm.io/gorm"
 )
 
@@ -16,11 +16,18 @@ type User struct {
 	Username       string       `json:"username" gorm:"size:100;unique"`
 	PublicWallet   string       `json:"public_wallet" gorm:"size:100"`
 	RedditUsername string       `json:"reddit_username" gorm:"type:varchar(50);default:null;unique"`
+	Language       string       `json:"language" gorm:"size:16"`
 
-	ReactCounts []*ReactCount          `json:"react_counts"`
-	ReactMap    map[string]*ReactCount `json:"-" gorm:"-"`
-	UserXPs     []*UserXP              `json:"user_xps"`
-	UserXPMap   map[string]*UserXP     `json:"xp_map" gorm:"-"`
+	ReactCounts []*ReactCount            `json:"react_counts"`
+	ReactMap    map[ScoreKey]*ReactCount `json:"-" gorm:"-"`
+	UserXPs     []*UserXP                `json:"user_xps"`
+	UserXPMap   map[ScoreKey]*UserXP     `json:"-" gorm:"-"`
+}
+
+// ScoreKey identifies one of a user's scores in a chat, by XP title or react emoji.
+type ScoreKey struct {
+	Name   string
+	ChatID int64
 }
 
 func NewUser(user *botapi.User) *User {
@@ -28,8 +35,8 @@ func NewUser(user *botapi.User) *User {
 		ID:           user.ID,
 		TelegramUser: user,
 		FirstName:    user.FirstName,
-		ReactMap:     make(map[string]*ReactCount),
-		UserXPMap:    make(map[string]*UserXP),
+		ReactMap:     make(map[ScoreKey]*ReactCount),
+		UserXPMap:    make(map[ScoreKey]*UserXP),
 	}
 
 	u.Username = u.GetUsername()
@@ -37,22 +44,6 @@ func NewUser(user *botapi.User) *User {
 	return u
 }
 
-func (u *User) IsAdmin(bot *botapi.BotAPI, chatID int64) bool {
-	c, err := bot.GetChatMember(botapi.GetChatMemberConfig{
-		ChatConfigWithUser: botapi.ChatConfigWithUser{
-			ChatID: chatID,
-			UserID: u.ID,
-		},
-	})
-
-	if err != nil {
-		log.Printf("Error getting chat config: %q", err.Error())
-		return false
-	}
-
-	return c.IsAdministrator() || c.IsCreator()
-}
-
 func (u *User) GetUsername() string {
 	if u.Username != "" {
 		return u.Username
@@ -77,6 +68,29 @@ func (u *User) DisplayName() (text string) {
 	return
 }
 
-func (u *User) AtString() string {
-	return fmt.Sprintf("[%s](tg://user?id=%d)", u.DisplayName(), u.ID)
+// AtString mentions the user by name, formatted as HTML.
+func (u *User) AtString() render.Markup {
+	return render.HTML.Mention(u.DisplayName(), u.ID)
+}
+
+// TotalXP sums the user's XP for title over every chat, counting only this week's and
+// this month's toward WeekXP and MonthXP.
+func (u *User) TotalXP(title string) *UserXP {
+	total := NewUserXP(title, u.ID, GlobalChatID)
+
+	for key, xp := range u.UserXPMap {
+		if key.Name == title {
+			total.XP += xp.XP
+
+			if xp.WeekFrom.Equal(total.WeekFrom) {
+				total.WeekXP += xp.WeekXP
+			}
+
+			if xp.MonthFrom.Equal(total.MonthFrom) {
+				total.MonthXP += xp.MonthXP
+			}
+		}
+	}
+
+	return total
 }
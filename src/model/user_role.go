@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// UserRole grants a role to a user within a chat, or in every chat when ChatID is 0.
+type UserRole struct {
+	UserID    int64     `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	ChatID    int64     `json:"chat_id" gorm:"primaryKey;autoIncrement:false"`
+	Role      string    `json:"role" gorm:"primaryKey;size:32"`
+	GrantedBy int64     `json:"granted_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -6,23 +6,34 @@ import (
 	"github.com/willmroliver/plathbot/src/util"
 )
 
+// GlobalChatID is the chat for scores earned outside any one chat, and those from before
+// scores were kept per chat. Leaderboards use it to combine every chat's scores.
+const GlobalChatID int64 = 0
+
 type UserXP struct {
 	Title     string    `json:"title" gorm:"primaryKey;size:50"`
 	UserID    int64     `json:"user_id" gorm:"primaryKey"`
+	ChatID    int64     `json:"chat_id" gorm:"primaryKey;autoIncrement:false;default:0"`
 	User      *User     `json:"user" gorm:"foreignKey:UserID;references:ID"`
 	XP        int64     `json:"xp"`
 	WeekXP    int64     `json:"week_xp"`
 	MonthXP   int64     `json:"month_xp"`
 	WeekFrom  time.Time `json:"week_from" gorm:"type:date"`
 	MonthFrom time.Time `json:"month_from" gorm:"type:date"`
+	// The last week's and month's XP are kept when they reset, until their standings are saved.
+	PrevWeekXP    int64      `json:"prev_week_xp"`
+	PrevMonthXP   int64      `json:"prev_month_xp"`
+	PrevWeekFrom  *time.Time `json:"prev_week_from" gorm:"type:date;default:null"`
+	PrevMonthFrom *time.Time `json:"prev_month_from" gorm:"type:date;default:null"`
 }
 
-func NewUserXP(title string, userID int64) *UserXP {
+func NewUserXP(title string, userID, chatID int64) *UserXP {
 	now := time.Now()
 
 	return &UserXP{
 		Title:     title,
 		UserID:    userID,
+		ChatID:    chatID,
 		WeekFrom:  util.LastMonday(&now),
 		MonthFrom: util.FirstOfMonth(&now),
 	}
@@ -0,0 +1,125 @@
+// Package render builds formatted text for Telegram, escaping whatever goes into it so
+// that names, titles and other user input can't break a message's formatting.
+package render
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Mode is a Telegram parse mode. Its value is the parse mode's name, so it can be set as a
+// message's ParseMode.
+type Mode string
+
+const (
+	HTML       Mode = "HTML"
+	MarkdownV2 Mode = "MarkdownV2"
+)
+
+// Markup is text already formatted for a mode, which is written as is rather than escaped.
+type Markup string
+
+var (
+	htmlText = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	htmlAttr = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+	markdownText = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+		"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+		"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+	markdownCode = strings.NewReplacer(`\`, `\\`, "`", "\\`")
+	markdownURL  = strings.NewReplacer(`\`, `\\`, ")", `\)`)
+)
+
+// Escape escapes plain text for the mode.
+func (m Mode) Escape(s string) string {
+	if m == HTML {
+		return htmlText.Replace(s)
+	}
+
+	return markdownText.Replace(s)
+}
+
+// Text formats v as plain text, escaping it unless it's already Markup.
+func (m Mode) Text(v any) Markup {
+	if mu, ok := v.(Markup); ok {
+		return mu
+	}
+
+	return Markup(m.Escape(fmt.Sprint(v)))
+}
+
+func (m Mode) Bold(v any) Markup {
+	if m == HTML {
+		return "<b>" + m.Text(v) + "</b>"
+	}
+
+	return "*" + m.Text(v) + "*"
+}
+
+func (m Mode) Italic(v any) Markup {
+	if m == HTML {
+		return "<i>" + m.Text(v) + "</i>"
+	}
+
+	return "_" + m.Text(v) + "_"
+}
+
+// Code formats s as inline code.
+func (m Mode) Code(s string) Markup {
+	if m == HTML {
+		return Markup("<code>" + htmlText.Replace(s) + "</code>")
+	}
+
+	return Markup("`" + markdownCode.Replace(s) + "`")
+}
+
+// Pre formats s as a block of preformatted text.
+func (m Mode) Pre(s string) Markup {
+	if m == HTML {
+		return Markup("<pre>" + htmlText.Replace(s) + "</pre>")
+	}
+
+	return Markup("```\n" + markdownCode.Replace(s) + "\n```")
+}
+
+// Link formats text as a link to url.
+func (m Mode) Link(text any, url string) Markup {
+	if m == HTML {
+		return Markup(`<a href="`+htmlAttr.Replace(url)+`">`) + m.Text(text) + "</a>"
+	}
+
+	return "[" + m.Text(text) + Markup("]("+markdownURL.Replace(url)+")")
+}
+
+// Mention links name to the user, notifying them if they're in the chat.
+func (m Mode) Mention(name string, id int64) Markup {
+	return m.Link(name, fmt.Sprintf("tg://user?id=%d", id))
+}
+
+// Sprintf formats args into format, which is Markup for the mode, like a translated
+// template. Args are escaped after formatting, unless they're Markup.
+func (m Mode) Sprintf(format string, args ...any) Markup {
+	escaped := make([]any, len(args))
+	for i, arg := range args {
+		escaped[i] = escaper{m, arg}
+	}
+
+	return Markup(fmt.Sprintf(format, escaped...))
+}
+
+type escaper struct {
+	mode Mode
+	v    any
+}
+
+func (e escaper) Format(f fmt.State, verb rune) {
+	s := fmt.Sprintf(fmt.FormatString(f, verb), e.v)
+	if _, ok := e.v.(Markup); !ok {
+		s = e.mode.Escape(s)
+	}
+
+	io.WriteString(f, s)
+}
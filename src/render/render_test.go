@@ -0,0 +1,72 @@
+package render_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/willmroliver/plathbot/src/render"
+)
+
+func TestEscape(t *testing.T) {
+	cases := []struct {
+		got, want render.Markup
+	}{
+		{render.HTML.Mention("<b>Tom & Jerry</b>", 1), `<a href="tg://user?id=1">&lt;b&gt;Tom &amp; Jerry&lt;/b&gt;</a>`},
+		{render.MarkdownV2.Mention("snake_case*", 2), `[snake\_case\*](tg://user?id=2)`},
+		{render.HTML.Bold(render.HTML.Italic("a<b")), "<b><i>a&lt;b</i></b>"},
+		{render.MarkdownV2.Code("a`b_c"), "`a\\`b_c`"},
+		{render.MarkdownV2.Link("x", "https://e.com/a_(b)"), `[x](https://e.com/a_(b\))`},
+		{render.HTML.Link("x", `https://e.com/?a=1&b="2"`), `<a href="https://e.com/?a=1&amp;b=&quot;2&quot;">x</a>`},
+		{render.HTML.Sprintf("<b>%s</b> said %q, +%d", render.HTML.Mention("A", 3), "1 < 2", 5), `<b><a href="tg://user?id=3">A</a></b> said "1 &lt; 2", +5`},
+		{render.MarkdownV2.Sprintf(`%[1]s \- %[1]s\!`, "v1.0"), `v1\.0 \- v1\.0\!`},
+	}
+
+	for i, c := range cases {
+		if c.got != c.want {
+			t.Errorf("Case %d - Expected %q; Got %q", i, c.want, c.got)
+		}
+	}
+}
+
+func TestSplit(t *testing.T) {
+	text := "<b>" + strings.Repeat("word ", 10) + "\n" + strings.Repeat("line\n", 5) + "</b>"
+
+	parts := render.HTML.Split(text, 30)
+	if len(parts) < 2 {
+		t.Fatalf("Split() - Expected several parts; Got %q", parts)
+	}
+
+	joined := ""
+	for _, part := range parts {
+		if n := len(part); n > 30 {
+			t.Errorf("Split() - Expected parts no longer than 30; Got %d in %q", n, part)
+		}
+
+		if !strings.HasPrefix(part, "<b>") || !strings.HasSuffix(part, "</b>") {
+			t.Errorf("Split() - Expected the open tag to be carried over; Got %q", part)
+		}
+
+		joined += strings.TrimSuffix(strings.TrimPrefix(part, "<b>"), "</b>") + " "
+	}
+
+	if got := strings.Join(strings.Fields(joined), " "); got != strings.Join(strings.Fields(text[3:len(text)-4]), " ") {
+		t.Errorf("Split() - Expected the words to be kept; Got %q", got)
+	}
+
+	for _, part := range render.HTML.Split("a &amp; b &amp; c &amp; d", 8) {
+		if strings.Contains(part, "&") && !strings.Contains(part, "&amp;") {
+			t.Errorf("Split() - Expected entities to be kept whole; Got %q", part)
+		}
+	}
+
+	parts = render.MarkdownV2.Split("```\n"+strings.Repeat("row\n", 10)+"```", 24)
+	for _, part := range parts {
+		if !strings.HasPrefix(part, "```\n") || !strings.HasSuffix(part, "\n```") {
+			t.Errorf("Split() - Expected each part to be a code block; Got %q", part)
+		}
+	}
+
+	if parts := render.HTML.Split("short", render.MaxLength); len(parts) != 1 || parts[0] != "short" {
+		t.Errorf("Split() - Expected short text as is; Got %q", parts)
+	}
+}
@@ -0,0 +1,164 @@
+package render
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// MaxLength is the most characters Telegram accepts in a message.
+const MaxLength = 4096
+
+// entity is formatting open at some point in the text, and how to close and reopen it.
+type entity struct {
+	open, close string
+}
+
+// Split splits text formatted for the mode into parts no longer than limit, counted as
+// Telegram does in UTF-16 code units. Parts end at a line break where possible, then at
+// a space. Formatting open across a split is closed at the end of the part and reopened
+// at the start of the next: HTML tags, and code blocks in MarkdownV2.
+//
+// Lengths include the markup, so parts may be shorter than they need to be, never longer.
+func (m Mode) Split(text string, limit int) []string {
+	if limit <= 0 || size(text) <= limit {
+		return []string{text}
+	}
+
+	tokens := m.tokens(text)
+
+	// open[i] is the formatting open before tokens[i]
+	open := make([][]entity, len(tokens)+1)
+	for i, tok := range tokens {
+		open[i+1] = m.update(open[i], tok)
+	}
+
+	var parts []string
+
+	start, prefix := 0, ""
+
+	for start < len(tokens) {
+		n := size(prefix)
+		end, cut := start, -1
+
+		for ; end < len(tokens); end++ {
+			if n+size(tokens[end])+size(closing(open[end+1])) > limit {
+				break
+			}
+
+			n += size(tokens[end])
+
+			if tokens[end] == "\n" {
+				cut = end
+			} else if tokens[end] == " " && (cut == -1 || tokens[cut] == " ") {
+				cut = end
+			}
+		}
+
+		next := end
+		if end == len(tokens) {
+			cut = -1
+		} else if cut > start {
+			// Drop the line break or space being split at
+			end, next = cut, cut+1
+		} else if end == start {
+			// A single token can't fit, so it's sent over the limit rather than broken up
+			end, next = start+1, start+1
+		}
+
+		part := &strings.Builder{}
+		part.WriteString(prefix)
+		for _, tok := range tokens[start:end] {
+			part.WriteString(tok)
+		}
+		part.WriteString(closing(open[end]))
+
+		parts = append(parts, part.String())
+
+		start, prefix = next, opening(open[next])
+	}
+
+	return parts
+}
+
+// tokens breaks text into the pieces it may be split between, keeping HTML tags and
+// entities, and MarkdownV2 escapes and code fences, whole.
+func (m Mode) tokens(text string) (tokens []string) {
+	for len(text) > 0 {
+		n := 0
+
+		switch {
+		case m == HTML && text[0] == '<':
+			n = strings.IndexByte(text, '>') + 1
+		case m == HTML && text[0] == '&':
+			if i := strings.IndexByte(text, ';'); i != -1 && !strings.ContainsAny(text[:i], " \n<&") {
+				n = i + 1
+			}
+		case m == MarkdownV2 && text[0] == '\\' && len(text) > 1:
+			_, size := utf8.DecodeRuneInString(text[1:])
+			n = 1 + size
+		case m == MarkdownV2 && strings.HasPrefix(text, "```"):
+			// A fence runs to the end of its line, taking in the code block's language
+			if n = strings.IndexByte(text, '\n'); n == -1 {
+				n = len(text)
+			}
+		}
+
+		if n <= 0 {
+			_, n = utf8.DecodeRuneInString(text)
+		}
+
+		tokens = append(tokens, text[:n])
+		text = text[n:]
+	}
+
+	return
+}
+
+// update returns the formatting open after tok, given that open before it.
+func (m Mode) update(open []entity, tok string) []entity {
+	switch {
+	case m == HTML && strings.HasPrefix(tok, "</"):
+		if len(open) > 0 {
+			return open[:len(open)-1]
+		}
+	case m == HTML && strings.HasPrefix(tok, "<") && len(tok) > 2:
+		name, _, _ := strings.Cut(strings.Trim(tok, "<>"), " ")
+		return append(open[:len(open):len(open)], entity{tok, "</" + name + ">"})
+	case m == MarkdownV2 && strings.HasPrefix(tok, "```"):
+		if len(open) > 0 {
+			return nil
+		}
+
+		return []entity{{tok + "\n", "\n```"}}
+	}
+
+	return open
+}
+
+func opening(open []entity) string {
+	s := ""
+	for _, e := range open {
+		s += e.open
+	}
+
+	return s
+}
+
+func closing(open []entity) string {
+	s := ""
+	for i := len(open) - 1; i >= 0; i-- {
+		s += open[i].close
+	}
+
+	return s
+}
+
+// size is the length of s as Telegram counts it, in UTF-16 code units.
+func size(s string) (n int) {
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+
+	return
+}
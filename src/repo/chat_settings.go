@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"errors"
+	"log"
+
+	"github.com/willmroliver/plathbot/src/ds"
+	"github.com/willmroliver/plathbot/src/model"
+	"gorm.io/gorm"
+)
+
+// ChatSettingsRepo caches the settings it reads and saves, so should be kept and shared,
+// e.g. by the server, rather than made for each use.
+type ChatSettingsRepo struct {
+	*Repo
+	cache *ds.LRUCache[int64, *model.ChatSettings]
+}
+
+func NewChatSettingsRepo(db *gorm.DB) *ChatSettingsRepo {
+	return &ChatSettingsRepo{
+		NewRepo(db),
+		ds.NewLRUCache[int64, *model.ChatSettings](100),
+	}
+}
+
+// Get returns a chat's settings, or the defaults if none are saved. The result is shared
+// and cached, so copy it before making changes to pass to Save.
+func (r *ChatSettingsRepo) Get(chatID int64) *model.ChatSettings {
+	r.cache.Lock()
+	defer r.cache.Unlock()
+
+	if settings, ok := r.cache.Load(chatID); ok {
+		return settings
+	}
+
+	settings := &model.ChatSettings{ChatID: chatID}
+
+	if err := r.db.First(settings, chatID).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Error reading chat %d settings: %q", chatID, err.Error())
+		return settings
+	}
+
+	r.cache.Save(chatID, settings)
+	return settings
+}
+
+func (r *ChatSettingsRepo) Save(settings *model.ChatSettings) (err error) {
+	r.cache.Lock()
+	defer r.cache.Unlock()
+
+	if err = r.Repo.Save(settings); err == nil {
+		r.cache.Save(settings.ChatID, settings)
+	}
+
+	return
+}
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"log"
+
+	"github.com/willmroliver/plathbot/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MessageHookRepo struct {
+	*Repo
+}
+
+func NewMessageHookRepo(db *gorm.DB) *MessageHookRepo {
+	return &MessageHookRepo{
+		NewRepo(db),
+	}
+}
+
+// All returns every saved hook, expired or not.
+func (r *MessageHookRepo) All() (hooks []*model.MessageHook) {
+	hooks = []*model.MessageHook{}
+
+	if err := r.db.Find(&hooks).Error; err != nil {
+		log.Printf("MessageHookRepo All() error: %q", err.Error())
+		return nil
+	}
+
+	return
+}
+
+// Put saves a hook, replacing any with the same scope, owner and namespace.
+func (r *MessageHookRepo) Put(hook *model.MessageHook) (err error) {
+	// Save would treat the zero Scope of chat hooks as unset, so upsert explicitly
+	err = r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(hook).Error
+
+	if err != nil {
+		log.Printf("MessageHookRepo Put() error: %q", err.Error())
+	}
+
+	return
+}
+
+func (r *MessageHookRepo) Remove(scope int, ownerID int64, namespace string) (err error) {
+	err = r.db.
+		Where("scope = ? AND owner_id = ? AND namespace = ?", scope, ownerID, namespace).
+		Delete(&model.MessageHook{}).
+		Error
+
+	if err != nil {
+		log.Printf("MessageHookRepo Remove() error: %q", err.Error())
+	}
+
+	return
+}
@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/willmroliver/plathbot/src/model"
@@ -26,11 +27,15 @@ func (r *ReactCountRepo) ShiftCount(react *model.ReactCount, count int) (err err
 	now := time.Now()
 
 	if monday := util.LastMonday(&now); react.WeekFrom.Compare(monday) != 0 {
+		prev := react.WeekFrom
+		react.PrevWeekCount, react.PrevWeekFrom = react.WeekCount, &prev
 		react.WeekCount = 0
 		react.WeekFrom = monday
 	}
 
 	if first := util.FirstOfMonth(&now); react.MonthFrom.Compare(first) != 0 {
+		prev := react.MonthFrom
+		react.PrevMonthCount, react.PrevMonthFrom = react.MonthCount, &prev
 		react.MonthCount = 0
 		react.MonthFrom = first
 	}
@@ -68,54 +73,44 @@ func (r *ReactCountRepo) List(emoji, order string, offset, lim int) (counts []*m
 	return
 }
 
-// TopCounts returns the all-time highest count & user for each tracked emoji
-func (r *ReactCountRepo) TopCounts() (c []*model.ReactCount) {
+// TopCounts returns the all-time highest count & user for each tracked emoji in a chat.
+// For model.GlobalChatID, each user's counts are summed over every chat.
+func (r *ReactCountRepo) TopCounts(chatID int64) (c []*model.ReactCount) {
 	c = make([]*model.ReactCount, 0)
 
 	if err := r.db.Raw(`
-		WITH top_counts AS (
+		WITH counts AS (
+			SELECT
+				emoji,
+				user_id,
+				SUM(count) AS count
+			FROM react_counts
+			WHERE (@chat = @global OR chat_id = @chat)
+			GROUP BY emoji, user_id
+		), top_counts AS (
 			SELECT 
 				emoji,
 				user_id,
 				count,
 				ROW_NUMBER() OVER (PARTITION BY emoji ORDER BY count DESC, user_id ASC) as rn
-			FROM react_counts
+			FROM counts
 		)
-		SELECT emoji, user_id, count
+		SELECT emoji, user_id, @chat AS chat_id, count
 		FROM top_counts
 		WHERE rn = 1
-	`).Preload("User").Find(&c).Error; err != nil {
+	`, chatArgs(chatID, nil)).Preload("User").Find(&c).Error; err != nil {
 		return nil
 	}
 
 	return
 }
 
-// TopMonthly returns the all-time highest count & user for each tracked emoji
+// TopMonthly returns the highest count & user this month for each tracked emoji in a chat,
+// summed over every chat for model.GlobalChatID.
 //
 // The `Count` field is populated with the MonthCount value to support code-homogeneity
-func (r *ReactCountRepo) TopMonthly() (c []*model.ReactCount) {
-	c = make([]*model.ReactCount, 0)
-
-	now := time.Now()
-	from := util.FirstOfMonth(&now)
-
-	if err := r.db.Raw(`
-		WITH top_counts AS (
-			SELECT 
-				emoji,
-				user_id,
-				month_count AS count,
-				ROW_NUMBER() OVER (PARTITION BY emoji ORDER BY month_count DESC, user_id ASC) as rn
-			FROM react_counts
-			WHERE month_from >= ?
-		)
-		SELECT emoji, user_id, count
-		FROM top_counts
-		WHERE rn = 1
-	`, from).Preload("User").Find(&c).Error; err != nil {
-		return nil
-	}
+func (r *ReactCountRepo) TopMonthly(chatID int64) (c []*model.ReactCount) {
+	c = r.TopPeriod(chatID, model.PeriodMonth, model.PeriodMonth.Start(time.Now()))
 
 	for _, count := range c {
 		if count != nil {
@@ -126,37 +121,93 @@ func (r *ReactCountRepo) TopMonthly() (c []*model.ReactCount) {
 	return
 }
 
-// TopWeekly returns the all-time highest count & user for each tracked emoji
+// TopWeekly returns the highest count & user this week for each tracked emoji in a chat,
+// summed over every chat for model.GlobalChatID.
 //
 // The `Count` field is populated with the WeekCount value to support code-homogeneity
-func (r *ReactCountRepo) TopWeekly() (c []*model.ReactCount) {
+func (r *ReactCountRepo) TopWeekly(chatID int64) (c []*model.ReactCount) {
+	c = r.TopPeriod(chatID, model.PeriodWeek, model.PeriodWeek.Start(time.Now()))
+
+	for _, count := range c {
+		if count != nil {
+			count.WeekCount = count.Count
+		}
+	}
+
+	return
+}
+
+// TopPeriod returns the highest count & user for each tracked emoji in a chat over the
+// week or month beginning at from, summed over every chat for model.GlobalChatID. Counts
+// reset since, as the next reaction arrived, are read from those kept by ShiftCount.
+//
+// The `Count` field is populated with the count for the period.
+func (r *ReactCountRepo) TopPeriod(chatID int64, period model.Period, from time.Time) (c []*model.ReactCount) {
 	c = make([]*model.ReactCount, 0)
 
-	now := time.Now()
-	from := util.LastMonday(&now)
+	args := chatArgs(chatID, &from)
+	args["to"] = period.End(from)
 
-	if err := r.db.Raw(`
-		WITH top_counts AS (
-			SELECT 
+	if err := r.db.Raw(fmt.Sprintf(`
+		WITH counts AS (
+			SELECT
 				emoji,
 				user_id,
-				week_count AS count,
-				ROW_NUMBER() OVER (PARTITION BY emoji ORDER BY week_count DESC, user_id ASC) as rn
+				SUM(%[1]s) AS count
 			FROM react_counts
-			WHERE week_from >= ?
+			WHERE (@chat = @global OR chat_id = @chat)
+			GROUP BY emoji, user_id
+			HAVING SUM(%[1]s) > 0
+		), top_counts AS (
+			SELECT 
+				emoji,
+				user_id,
+				count,
+				ROW_NUMBER() OVER (PARTITION BY emoji ORDER BY count DESC, user_id ASC) as rn
+			FROM counts
 		)
-		SELECT emoji, user_id, count
+		SELECT emoji, user_id, @chat AS chat_id, count
 		FROM top_counts
 		WHERE rn = 1
-	`, from).Preload("User").Find(&c).Error; err != nil {
+	`, periodScore(period, "count")), args).Preload("User").Find(&c).Error; err != nil {
 		return nil
 	}
 
-	for _, count := range c {
-		if count != nil {
-			count.WeekCount = count.Count
-		}
+	return
+}
+
+// Chats returns the chats with reactions counted in the week or month beginning at from.
+func (r *ReactCountRepo) Chats(period model.Period, from time.Time) (ids []int64) {
+	ids = make([]int64, 0)
+
+	if err := r.db.
+		Model(&model.ReactCount{}).
+		Where(periodScore(period, "count")+" > 0", map[string]any{"from": from, "to": period.End(from)}).
+		Distinct("chat_id").
+		Pluck("chat_id", &ids).Error; err != nil {
+		return nil
 	}
 
 	return
 }
+
+// chatArgs names the args for the Top queries.
+func chatArgs(chatID int64, from *time.Time) map[string]any {
+	args := map[string]any{"chat": chatID, "global": model.GlobalChatID}
+
+	if from != nil {
+		args["from"] = *from
+	}
+
+	return args
+}
+
+// periodScore is SQL for a row's score in col over the week or month between @from and
+// @to, whether it's still running or was kept when the score last reset.
+func periodScore(period model.Period, col string) string {
+	return fmt.Sprintf(`CASE
+		WHEN %[1]s_from >= @from AND %[1]s_from < @to THEN %[1]s_%[2]s
+		WHEN prev_%[1]s_from >= @from AND prev_%[1]s_from < @to THEN prev_%[1]s_%[2]s
+		ELSE 0
+	END`, period, col)
+}
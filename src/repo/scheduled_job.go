@@ -0,0 +1,48 @@
+package repo
+
+import (
+	"log"
+
+	"github.com/willmroliver/plathbot/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ScheduledJobRepo struct {
+	*Repo
+}
+
+func NewScheduledJobRepo(db *gorm.DB) *ScheduledJobRepo {
+	return &ScheduledJobRepo{
+		NewRepo(db),
+	}
+}
+
+// All returns every saved job.
+func (r *ScheduledJobRepo) All() (jobs []*model.ScheduledJob) {
+	jobs = []*model.ScheduledJob{}
+
+	if err := r.db.Find(&jobs).Error; err != nil {
+		log.Printf("ScheduledJobRepo All() error: %q", err.Error())
+		return nil
+	}
+
+	return
+}
+
+// Put saves a job, replacing any of the same name.
+func (r *ScheduledJobRepo) Put(job *model.ScheduledJob) (err error) {
+	if err = r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(job).Error; err != nil {
+		log.Printf("ScheduledJobRepo Put() error: %q", err.Error())
+	}
+
+	return
+}
+
+func (r *ScheduledJobRepo) Remove(name string) (err error) {
+	if err = r.db.Where("name = ?", name).Delete(&model.ScheduledJob{}).Error; err != nil {
+		log.Printf("ScheduledJobRepo Remove() error: %q", err.Error())
+	}
+
+	return
+}
@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"log"
+	"time"
+
+	"github.com/willmroliver/plathbot/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StandingRepo struct {
+	*Repo
+}
+
+func NewStandingRepo(db *gorm.DB) *StandingRepo {
+	return &StandingRepo{
+		NewRepo(db),
+	}
+}
+
+// Put saves standings, replacing any in the same places.
+func (r *StandingRepo) Put(standings []*model.Standing) (err error) {
+	if len(standings) == 0 {
+		return
+	}
+
+	if err = r.db.Omit("User").Clauses(clause.OnConflict{UpdateAll: true}).Create(standings).Error; err != nil {
+		log.Printf("StandingRepo Put() error: %q", err.Error())
+	}
+
+	return
+}
+
+// Taken reports whether standings were saved for the week or month beginning at from.
+func (r *StandingRepo) Taken(period model.Period, from time.Time) bool {
+	var n int64
+
+	if err := r.db.Model(&model.Standing{}).Where("period = ? AND `from` = ?", period, from).Count(&n).Error; err != nil {
+		log.Printf("StandingRepo Taken() error: %q", err.Error())
+	}
+
+	return n > 0
+}
+
+// Periods returns when the weeks or months with standings saved for a chat began, latest
+// first.
+func (r *StandingRepo) Periods(chatID int64, period model.Period, limit int) (from []time.Time) {
+	from = make([]time.Time, 0)
+
+	if err := r.db.
+		Model(&model.Standing{}).
+		Where("chat_id = ? AND period = ?", chatID, period).
+		Distinct("`from`").
+		Order("`from` DESC").
+		Limit(limit).
+		Pluck("from", &from).Error; err != nil {
+		log.Printf("StandingRepo Periods() error: %q", err.Error())
+		return nil
+	}
+
+	return
+}
+
+// Get returns a chat's standings for the week or month beginning at from, by kind and
+// board, then rank.
+func (r *StandingRepo) Get(chatID int64, period model.Period, from time.Time) (standings []*model.Standing) {
+	standings = make([]*model.Standing, 0)
+
+	if err := r.db.
+		Where("chat_id = ? AND period = ? AND `from` = ?", chatID, period, from).
+		Order("kind, board, `rank`").
+		Preload("User").
+		Find(&standings).Error; err != nil {
+		log.Printf("StandingRepo Get() error: %q", err.Error())
+		return nil
+	}
+
+	return
+}
@@ -112,20 +112,57 @@ func (r *UserRepo) UpdateWallet(u *botapi.User, addr string) (err error) {
 	return
 }
 
+// Language returns the user's preferred language, or "" if they haven't chosen one.
+// Unlike Get, users not yet saved aren't created.
+func (r *UserRepo) Language(id int64) (lang string) {
+	cache.Lock()
+	user, ok := cache.Load(id)
+	cache.Unlock()
+
+	if ok && user != nil {
+		return user.Language
+	}
+
+	langs := []string{}
+
+	if err := r.db.Model(&model.User{}).Where("id = ? AND language IS NOT NULL", id).Limit(1).Pluck("language", &langs).Error; err != nil {
+		log.Printf("Error reading user %d language: %q", id, err.Error())
+	}
+
+	if len(langs) != 0 {
+		lang = langs[0]
+	}
+
+	return
+}
+
+// UpdateLanguage sets the user's preferred language, or clears it when lang is "".
+func (r *UserRepo) UpdateLanguage(u *botapi.User, lang string) (err error) {
+	if user := r.Get(u); user != nil {
+		user.Language = lang
+
+		if err = r.Save(user); err != nil {
+			log.Printf("Error updating user %d record: %q", user.ID, err.Error())
+		}
+	}
+
+	return
+}
+
 func (r *UserRepo) AllRedditUsernames() (users []string) {
 	r.db.Model(&model.User{}).Where("reddit_username IS NOT NULL").Pluck("reddit_username", &users)
 	return
 }
 
 func initUser(u *model.User) {
-	u.ReactMap = make(map[string]*model.ReactCount)
-	u.UserXPMap = make(map[string]*model.UserXP)
+	u.ReactMap = make(map[model.ScoreKey]*model.ReactCount)
+	u.UserXPMap = make(map[model.ScoreKey]*model.UserXP)
 
 	for _, count := range u.ReactCounts {
-		u.ReactMap[count.Emoji] = count
+		u.ReactMap[model.ScoreKey{Name: count.Emoji, ChatID: count.ChatID}] = count
 	}
 
 	for _, xp := range u.UserXPs {
-		u.UserXPMap[xp.Title] = xp
+		u.UserXPMap[model.ScoreKey{Name: xp.Title, ChatID: xp.ChatID}] = xp
 	}
 }
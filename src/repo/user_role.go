@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"log"
+
+	"github.com/willmroliver/plathbot/src/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserRoleRepo struct {
+	*Repo
+}
+
+func NewUserRoleRepo(db *gorm.DB) *UserRoleRepo {
+	return &UserRoleRepo{
+		NewRepo(db),
+	}
+}
+
+// Roles returns the roles held by a user in a chat, including those granted in every chat.
+func (r *UserRoleRepo) Roles(userID, chatID int64) (roles []*model.UserRole) {
+	roles = []*model.UserRole{}
+
+	if r.AllWhere(&roles, "user_id = ? AND chat_id IN ?", userID, []int64{chatID, 0}) != nil {
+		return nil
+	}
+
+	return
+}
+
+// Chat returns every role granted in a chat, or bot-wide when chatID is 0.
+func (r *UserRoleRepo) Chat(chatID int64) (roles []*model.UserRole) {
+	roles = []*model.UserRole{}
+
+	if r.AllWhere(&roles, "chat_id = ?", chatID) != nil {
+		return nil
+	}
+
+	return
+}
+
+// Grant gives a user a role in a chat. Granting a role already held is a no-op.
+func (r *UserRoleRepo) Grant(userID, chatID int64, role string, grantedBy int64) (err error) {
+	// Save would treat the zero ChatID of a bot-wide role as unset, so insert explicitly
+	err = r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserRole{
+		UserID:    userID,
+		ChatID:    chatID,
+		Role:      role,
+		GrantedBy: grantedBy,
+	}).Error
+
+	if err != nil {
+		log.Printf("UserRoleRepo Grant() error: %q", err.Error())
+	}
+
+	return
+}
+
+func (r *UserRoleRepo) Revoke(userID, chatID int64, role string) (err error) {
+	err = r.db.
+		Where("user_id = ? AND chat_id = ? AND role = ?", userID, chatID, role).
+		Delete(&model.UserRole{}).
+		Error
+
+	if err != nil {
+		log.Printf("UserRoleRepo Revoke() error: %q", err.Error())
+	}
+
+	return
+}
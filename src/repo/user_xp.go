@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -32,11 +33,15 @@ func (r *UserXPRepo) ShiftXP(xp *model.UserXP, points int64) (err error) {
 	now := time.Now()
 
 	if monday := util.LastMonday(&now); xp.WeekFrom.Compare(monday) != 0 {
+		prev := xp.WeekFrom
+		xp.PrevWeekXP, xp.PrevWeekFrom = xp.WeekXP, &prev
 		xp.WeekXP = 0
 		xp.WeekFrom = monday
 	}
 
 	if first := util.FirstOfMonth(&now); xp.MonthFrom.Compare(first) != 0 {
+		prev := xp.MonthFrom
+		xp.PrevMonthXP, xp.PrevMonthFrom = xp.MonthXP, &prev
 		xp.MonthXP = 0
 		xp.MonthFrom = first
 	}
@@ -53,11 +58,13 @@ func (r *UserXPRepo) ShiftXP(xp *model.UserXP, points int64) (err error) {
 	xp.WeekXP = shift(xp.WeekXP, points)
 	xp.MonthXP = shift(xp.MonthXP, points)
 
-	if err = r.Save(xp); err != nil {
+	if err = r.Save(xp); err == nil {
 		xpTitlesMux.Lock()
 		defer xpTitlesMux.Unlock()
 
-		xpTitles[xp.Title] = true
+		if xpTitles != nil {
+			xpTitles[xp.Title] = true
+		}
 	}
 
 	return
@@ -110,17 +117,25 @@ func (r *UserXPRepo) List(title, order string, offset, lim int) (xps []*model.Us
 	return
 }
 
-// TopXPs returns the all-time highest count & user for each tracked title
-func (r *UserXPRepo) TopXPs(title, order string, offset, limit int, where string, args ...any) (c []*model.UserXP) {
+// TopXPs returns the users with the most XP for title in a chat. For model.GlobalChatID,
+// each user's XP is summed over every chat.
+func (r *UserXPRepo) TopXPs(chatID int64, title, order string, offset, limit int, where string, args ...any) (c []*model.UserXP) {
 	c = make([]*model.UserXP, 0)
 
 	query := r.db.
+		Model(&model.UserXP{}).
+		Select("title, user_id, ? AS chat_id, SUM(xp) AS xp, SUM(week_xp) AS week_xp, SUM(month_xp) AS month_xp", chatID).
 		Where("title = ?", title).
-		Joins("User").
+		Group("title, user_id").
+		Preload("User").
 		Order(order).
 		Offset(offset).
 		Limit(limit)
 
+	if chatID != model.GlobalChatID {
+		query.Where("chat_id = ?", chatID)
+	}
+
 	if where != "" {
 		query.Where(where, args...)
 	}
@@ -131,3 +146,44 @@ func (r *UserXPRepo) TopXPs(title, order string, offset, limit int, where string
 
 	return
 }
+
+// TopPeriod returns the users with the most XP for title in a chat over the week or month
+// beginning at from, summed over every chat for model.GlobalChatID. XP reset since, as the
+// next points arrived, is read from that kept by ShiftXP.
+//
+// The `XP` field is populated with the XP for the period.
+func (r *UserXPRepo) TopPeriod(chatID int64, title string, period model.Period, from time.Time, limit int) (c []*model.UserXP) {
+	c = make([]*model.UserXP, 0)
+
+	args := chatArgs(chatID, &from)
+	args["to"], args["title"], args["limit"] = period.End(from), title, limit
+
+	if err := r.db.Raw(fmt.Sprintf(`
+		SELECT title, user_id, @chat AS chat_id, SUM(%[1]s) AS xp
+		FROM user_xps
+		WHERE title = @title AND (@chat = @global OR chat_id = @chat)
+		GROUP BY title, user_id
+		HAVING SUM(%[1]s) > 0
+		ORDER BY xp DESC, user_id ASC
+		LIMIT @limit
+	`, periodScore(period, "xp")), args).Preload("User").Find(&c).Error; err != nil {
+		return nil
+	}
+
+	return
+}
+
+// Chats returns the chats with XP earned in the week or month beginning at from.
+func (r *UserXPRepo) Chats(period model.Period, from time.Time) (ids []int64) {
+	ids = make([]int64, 0)
+
+	if err := r.db.
+		Model(&model.UserXP{}).
+		Where(periodScore(period, "xp")+" > 0", map[string]any{"from": from, "to": period.End(from)}).
+		Distinct("chat_id").
+		Pluck("chat_id", &ids).Error; err != nil {
+		return nil
+	}
+
+	return
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"errors"
 	"fmt"
+	"maps"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/willmroliver/plathbot/src/model"
@@ -40,7 +41,9 @@ func (s *ReactService) Untrack(emoji string) (err error) {
 	}
 
 	repo.OnUserCache(func(u *model.User) bool {
-		delete(u.ReactMap, emoji)
+		maps.DeleteFunc(u.ReactMap, func(key model.ScoreKey, _ *model.ReactCount) bool {
+			return key.Name == emoji
+		})
 		return true
 	})
 
@@ -58,6 +61,11 @@ func (s *ReactService) UpdateCounts(m *botapi.Message) (err error) {
 		return
 	}
 
+	chatID := model.GlobalChatID
+	if m.Chat != nil {
+		chatID = m.Chat.ID
+	}
+
 	user := s.UserRepo.Get(m.User)
 
 	if user == nil {
@@ -75,7 +83,7 @@ func (s *ReactService) UpdateCounts(m *botapi.Message) (err error) {
 			continue
 		}
 
-		if data := user.ReactMap[react.Emoji]; data != nil && data.Count > 0 {
+		if data := user.ReactMap[model.ScoreKey{Name: react.Emoji, ChatID: chatID}]; data != nil && data.Count > 0 {
 			if err = s.CountRepo.ShiftCount(data, -1); err != nil {
 				return
 			}
@@ -92,17 +100,19 @@ func (s *ReactService) UpdateCounts(m *botapi.Message) (err error) {
 			continue
 		}
 
-		if data := user.ReactMap[react.Emoji]; data != nil {
+		key := model.ScoreKey{Name: react.Emoji, ChatID: chatID}
+
+		if data := user.ReactMap[key]; data != nil {
 			if err = s.CountRepo.ShiftCount(data, 1); err != nil {
 				return
 			}
 		} else {
-			data = model.NewReactCount(react.Emoji, user.ID)
+			data = model.NewReactCount(react.Emoji, user.ID, chatID)
 			if err = s.CountRepo.ShiftCount(data, 1); err != nil {
 				return
 			}
 
-			user.ReactMap[react.Emoji] = data
+			user.ReactMap[key] = data
 		}
 	}
 
@@ -1,11 +1,11 @@
 package service_test
 
 import (
-	"os"
 	"testing"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/willmroliver/plathbot/src/db"
+	"github.com/willmroliver/plathbot/src/apitest"
+	"github.com/willmroliver/plathbot/src/model"
 	"github.com/willmroliver/plathbot/src/service"
 )
 
@@ -19,7 +19,10 @@ func TestReactCount(t *testing.T) {
 		ID: 1,
 	}
 
+	chat := &botapi.Chat{ID: -100, Type: "supergroup"}
+
 	addReact := &botapi.Message{
+		Chat:        chat,
 		User:        tgUser,
 		OldReaction: []*botapi.ReactionType{},
 		NewReaction: []*botapi.ReactionType{
@@ -27,6 +30,7 @@ func TestReactCount(t *testing.T) {
 		},
 	}
 	changeReact := &botapi.Message{
+		Chat: chat,
 		User: tgUser,
 		OldReaction: []*botapi.ReactionType{
 			{Type: "emoji", Emoji: FireEmoji},
@@ -36,6 +40,7 @@ func TestReactCount(t *testing.T) {
 		},
 	}
 	removeReact := &botapi.Message{
+		Chat: chat,
 		User: tgUser,
 		OldReaction: []*botapi.ReactionType{
 			{Type: "emoji", Emoji: SmileEmoji},
@@ -43,8 +48,7 @@ func TestReactCount(t *testing.T) {
 		NewReaction: []*botapi.ReactionType{},
 	}
 
-	conn, _ := db.Open(os.Getenv("TEST_DB_NAME"))
-	db.Migrate(conn)
+	conn := apitest.DB(t)
 
 	service := service.NewReactService(conn)
 	user := service.UserRepo.Get(tgUser)
@@ -64,7 +68,7 @@ func TestReactCount(t *testing.T) {
 		t.Errorf("UpdateReacts() - Unexpected error: %q", err.Error())
 	}
 
-	if count, ok := user.ReactMap[FireEmoji]; !ok || count == nil {
+	if count, ok := user.ReactMap[model.ScoreKey{Name: FireEmoji, ChatID: chat.ID}]; !ok || count == nil {
 		t.Errorf("ReactMap[%s] - Expected exists; Got %v, %v", FireEmoji, ok, count)
 	}
 
@@ -73,11 +77,11 @@ func TestReactCount(t *testing.T) {
 		t.Errorf("UpdateReacts() - Unexpected error: %q", err.Error())
 	}
 
-	if count, ok := user.ReactMap[FireEmoji]; ok && count.Count > 0 {
+	if count, ok := user.ReactMap[model.ScoreKey{Name: FireEmoji, ChatID: chat.ID}]; ok && count.Count > 0 {
 		t.Errorf("ReactMap[%s] - Expected falsey; Got %v, %v", FireEmoji, ok, count)
 	}
 
-	if count, ok := user.ReactMap[SmileEmoji]; !ok || count == nil {
+	if count, ok := user.ReactMap[model.ScoreKey{Name: SmileEmoji, ChatID: chat.ID}]; !ok || count == nil {
 		t.Errorf("ReactMap[%s] - Expected exists; Got %v, %v", SmileEmoji, ok, count)
 	}
 
@@ -86,7 +90,7 @@ func TestReactCount(t *testing.T) {
 		t.Errorf("UpdateReacts() - Unexpected error: %q", err.Error())
 	}
 
-	if count, ok := user.ReactMap[SmileEmoji]; ok && count.Count > 0 {
+	if count, ok := user.ReactMap[model.ScoreKey{Name: SmileEmoji, ChatID: chat.ID}]; ok && count.Count > 0 {
 		t.Errorf("ReactMap[%s] - Expected falsey; Got %v, %v", SmileEmoji, ok, count)
 	}
 }
@@ -1,6 +1,3 @@
-//go:build reddit
-// +build reddit
-
 package service
 
 import (
@@ -45,7 +42,7 @@ func (s *RedditService) All() (posts []*model.RedditPost) {
 		i++
 	}
 
-	s.UserXPService.UpdateXPsWhere(XPTitleReddit, 1, "reddit_username IN ?", usernames)
+	s.UserXPService.UpdateXPsWhere(model.GlobalChatID, XPTitleReddit, 1, "reddit_username IN ?", usernames)
 
 	return s.RedditPostRepo.All()
 }
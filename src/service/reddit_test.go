@@ -1,10 +1,10 @@
 package service_test
 
 import (
-	"os"
 	"testing"
 	"time"
 
+	"github.com/willmroliver/plathbot/src/apitest"
 	"github.com/willmroliver/plathbot/src/db"
 	"github.com/willmroliver/plathbot/src/model"
 	"github.com/willmroliver/plathbot/src/repo"
@@ -43,11 +43,10 @@ func TestAll(t *testing.T) {
 		},
 	}
 
-	conn, _ := db.Open(os.Getenv("TEST_DB_NAME"))
-
 	db.MigrateModel(&model.RedditPost{})
 	db.MigrateModel(&model.RedditPostComment{})
-	db.Migrate(conn)
+
+	conn := apitest.DB(t)
 
 	r := repo.NewRedditPostRepo(conn)
 
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"slices"
+	"time"
+
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/repo"
+	"gorm.io/gorm"
+)
+
+// StandingsTop is how many places are saved on each XP leaderboard. Reactions save only
+// the top user for each emoji.
+const StandingsTop = 15
+
+type StandingService struct {
+	UserXPRepo    *repo.UserXPRepo
+	CountRepo     *repo.ReactCountRepo
+	StandingsRepo *repo.StandingRepo
+}
+
+func NewStandingService(db *gorm.DB) *StandingService {
+	return &StandingService{
+		UserXPRepo:    repo.NewUserXPRepo(db),
+		CountRepo:     repo.NewReactCountRepo(db),
+		StandingsRepo: repo.NewStandingRepo(db),
+	}
+}
+
+// Snapshot saves the final standings for the week or month beginning at from, in every
+// chat with scores then, and every chat's combined as model.GlobalChatID. Scores reset as
+// the next point arrives, keeping the last period's, so this may run any time before the
+// one after ends.
+//
+// It returns the chats with standings saved, or none if they were already taken.
+func (s *StandingService) Snapshot(period model.Period, from time.Time) (chats []int64, err error) {
+	if s.StandingsRepo.Taken(period, from) {
+		return nil, nil
+	}
+
+	chats = append(s.UserXPRepo.Chats(period, from), s.CountRepo.Chats(period, from)...)
+	chats = append(chats, model.GlobalChatID)
+
+	slices.Sort(chats)
+	chats = slices.Compact(chats)
+
+	standings := []*model.Standing{}
+
+	for _, chatID := range chats {
+		standings = append(standings, s.standings(chatID, period, from)...)
+	}
+
+	if err = s.StandingsRepo.Put(standings); err != nil {
+		return nil, err
+	}
+
+	return
+}
+
+func (s *StandingService) standings(chatID int64, period model.Period, from time.Time) (res []*model.Standing) {
+	place := func(kind model.StandingKind, board string, rank int, userID, score int64) *model.Standing {
+		return &model.Standing{
+			Period: period,
+			From:   from,
+			ChatID: chatID,
+			Kind:   kind,
+			Board:  board,
+			Rank:   rank,
+			UserID: userID,
+			Score:  score,
+		}
+	}
+
+	for _, title := range s.UserXPRepo.Titles() {
+		for i, xp := range s.UserXPRepo.TopPeriod(chatID, title, period, from, StandingsTop) {
+			res = append(res, place(model.StandingXP, title, i+1, xp.UserID, xp.XP))
+		}
+	}
+
+	for _, count := range s.CountRepo.TopPeriod(chatID, period, from) {
+		if count.Count > 0 {
+			res = append(res, place(model.StandingReact, count.Emoji, 1, count.UserID, int64(count.Count)))
+		}
+	}
+
+	return
+}
@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/willmroliver/plathbot/src/apitest"
+	"github.com/willmroliver/plathbot/src/model"
+	"github.com/willmroliver/plathbot/src/service"
+)
+
+func TestSnapshot(t *testing.T) {
+	const (
+		title  = "🏅 Standing XP"
+		chatID = -400
+	)
+
+	conn := apitest.DB(t)
+	s := service.NewStandingService(conn)
+	xps := service.NewUserXPService(conn)
+
+	from := model.PeriodWeek.Previous(time.Now())
+
+	for id, xp := range map[int64]int64{21: 40, 22: 70, 23: 90} {
+		xps.UserRepo.Get(&botapi.User{ID: id})
+		conn.Create(&model.UserXP{Title: title, UserID: id, ChatID: chatID, XP: xp, WeekXP: xp, WeekFrom: from, MonthFrom: from})
+	}
+
+	// User 23 has scored since last week ended, resetting their week before it's saved
+	late := &model.UserXP{Title: title, UserID: 23, ChatID: chatID}
+	conn.First(late)
+
+	if err := xps.UserXPRepo.ShiftXP(late, 500); err != nil || late.WeekXP != 500 {
+		t.Fatalf("ShiftXP() - Expected the week reset to 500; Got %d, %v", late.WeekXP, err)
+	}
+
+	conn.Create(&model.ReactCount{Emoji: FireEmoji, UserID: 21, ChatID: chatID, Count: 3, WeekCount: 3, WeekFrom: from, MonthFrom: from})
+
+	chats, err := s.Snapshot(model.PeriodWeek, from)
+	if err != nil {
+		t.Fatalf("Snapshot() - Unexpected error: %q", err.Error())
+	}
+
+	if !slices.Equal(chats, []int64{chatID, model.GlobalChatID}) {
+		t.Errorf("Snapshot() - Expected chats %d and %d; Got %v", chatID, model.GlobalChatID, chats)
+	}
+
+	periods := s.StandingsRepo.Periods(chatID, model.PeriodWeek, 10)
+	if len(periods) != 1 || !periods[0].Equal(from) {
+		t.Fatalf("Periods() - Expected [%s]; Got %v", from, periods)
+	}
+
+	want := []string{"react 🔥 1 21 3", "xp " + title + " 1 23 90", "xp " + title + " 2 22 70", "xp " + title + " 3 21 40"}
+
+	for _, id := range []int64{chatID, model.GlobalChatID} {
+		got := []string{}
+		for _, st := range s.StandingsRepo.Get(id, model.PeriodWeek, periods[0]) {
+			got = append(got, fmt.Sprintf("%s %s %d %d %d", st.Kind, st.Board, st.Rank, st.UserID, st.Score))
+
+			if st.User == nil {
+				t.Errorf("Get(%d) - Expected the user to be loaded; Got nil", id)
+			}
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("Get(%d) - Expected %q; Got %q", id, want, got)
+		}
+	}
+
+	if chats, _ := s.Snapshot(model.PeriodWeek, from); chats != nil {
+		t.Errorf("Snapshot() - Expected nothing saved twice; Got %v", chats)
+	}
+}
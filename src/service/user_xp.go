@@ -38,7 +38,8 @@ func NewUserXPService(db *gorm.DB) *UserXPService {
 	return s
 }
 
-func (s *UserXPService) UpdateXPs(user *tgbotapi.User, title string, points int64) (err error) {
+// UpdateXPs shifts the user's XP for title in a chat by points.
+func (s *UserXPService) UpdateXPs(user *tgbotapi.User, chatID int64, title string, points int64) (err error) {
 	u := s.UserRepo.Get(user)
 	if u == nil || points == 0 || title == "" {
 		err = fmt.Errorf("invalid args: (user = %v, title = %q, points = %d)", user, title, points)
@@ -46,29 +47,25 @@ func (s *UserXPService) UpdateXPs(user *tgbotapi.User, title string, points int6
 		return
 	}
 
-	xp := u.UserXPMap[title]
-	if xp == nil {
-		xp = model.NewUserXP(title, u.ID)
-		u.UserXPMap[title] = xp
-	}
-
-	err = s.UserXPRepo.ShiftXP(xp, points)
+	err = s.UserXPRepo.ShiftXP(userXP(u, chatID, title), points)
 	return
 }
 
-func (s *UserXPService) BulkUpdateXPs(users []*model.User, title string, points int64) (err error) {
+func (s *UserXPService) BulkUpdateXPs(users []*model.User, chatID int64, title string, points int64) (err error) {
+	key := model.ScoreKey{Name: title, ChatID: chatID}
+
 	for _, u := range users {
 		if err != nil {
-			s.UserXPRepo.ShiftXP(u.UserXPMap[title], points)
+			s.UserXPRepo.ShiftXP(u.UserXPMap[key], points)
 		} else {
-			err = s.UserXPRepo.ShiftXP(u.UserXPMap[title], points)
+			err = s.UserXPRepo.ShiftXP(u.UserXPMap[key], points)
 		}
 	}
 
 	return
 }
 
-func (s *UserXPService) UpdateXPsWhere(title string, points int64, clause string, conditions ...interface{}) (err error) {
+func (s *UserXPService) UpdateXPsWhere(chatID int64, title string, points int64, clause string, conditions ...interface{}) (err error) {
 	var users []*model.User
 
 	if users = s.UserRepo.AllWhere(clause, conditions...); users == nil {
@@ -76,14 +73,21 @@ func (s *UserXPService) UpdateXPsWhere(title string, points int64, clause string
 	}
 
 	for _, u := range users {
-		xp := u.UserXPMap[title]
-		if xp == nil {
-			xp = model.NewUserXP(title, u.ID)
-			u.UserXPMap[title] = xp
-		}
-
-		err = s.UserXPRepo.ShiftXP(xp, points)
+		err = s.UserXPRepo.ShiftXP(userXP(u, chatID, title), points)
 	}
 
 	return
 }
+
+// userXP returns the user's XP for title in a chat, adding it if they have none yet.
+func userXP(u *model.User, chatID int64, title string) *model.UserXP {
+	key := model.ScoreKey{Name: title, ChatID: chatID}
+
+	xp := u.UserXPMap[key]
+	if xp == nil {
+		xp = model.NewUserXP(title, u.ID, chatID)
+		u.UserXPMap[key] = xp
+	}
+
+	return xp
+}
@@ -1,12 +1,13 @@
 package service_test
 
 import (
-	"os"
+	"slices"
 	"testing"
 	"time"
 
 	botapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
-	"github.com/willmroliver/plathbot/src/db"
+	"github.com/willmroliver/plathbot/src/apitest"
+	"github.com/willmroliver/plathbot/src/model"
 	"github.com/willmroliver/plathbot/src/service"
 	"github.com/willmroliver/plathbot/src/util"
 )
@@ -16,31 +17,67 @@ func TestUserXP(t *testing.T) {
 		ID: 1,
 	}
 
-	conn, _ := db.Open(os.Getenv("TEST_DB_NAME"))
-	db.Migrate(conn)
+	const chatID = -100
+	key := model.ScoreKey{Name: service.XPTitleEngage, ChatID: chatID}
+
+	conn := apitest.DB(t)
 
 	s := service.NewUserXPService(conn)
 	user := s.UserRepo.Get(tgUser)
 	conn.Exec("DELETE FROM user_xps WHERE title = ?", service.XPTitleEngage)
 
-	if err := s.UpdateXPs(tgUser, service.XPTitleEngage, 50); err != nil {
+	if err := s.UpdateXPs(tgUser, chatID, service.XPTitleEngage, 50); err != nil {
 		t.Errorf("UpdateXPs() - Unexpected error: %q", err.Error())
 	}
 
-	if count, ok := user.UserXPMap[service.XPTitleEngage]; !ok || count == nil || count.XP != 50 {
+	if count, ok := user.UserXPMap[key]; !ok || count == nil || count.XP != 50 {
 		t.Errorf("UserXPMap[%q] - Expected exists, %d; Got %v, %v", service.XPTitleEngage, 50, ok, count)
 	}
 
 	past := time.Now().AddDate(0, -1, 0)
-	user.UserXPMap[service.XPTitleEngage].WeekFrom = util.LastMonday(&past)
+	user.UserXPMap[key].WeekFrom = util.LastMonday(&past)
 
-	if err := s.UpdateXPs(tgUser, service.XPTitleEngage, 50); err != nil {
+	if err := s.UpdateXPs(tgUser, chatID, service.XPTitleEngage, 50); err != nil {
 		t.Errorf("UpdateXPs() - Unexpected error: %q", err.Error())
 	}
 
-	if count, ok := user.UserXPMap[service.XPTitleEngage]; !ok || count == nil || count.XP != 100 {
+	if count, ok := user.UserXPMap[key]; !ok || count == nil || count.XP != 100 {
 		t.Errorf("UserXPMap[%q] - Expected exists, %d; Got %v, %v", service.XPTitleEngage, 100, ok, count)
 	} else if count.WeekXP != 50 {
 		t.Errorf("WeekXP - Expected %d; Got %d", 50, count.WeekXP)
 	}
 }
+
+func TestTopXPs(t *testing.T) {
+	const (
+		title        = "🧪 Test XP"
+		chatA, chatB = -200, -300
+	)
+
+	ann, bob := &botapi.User{ID: 11, FirstName: "Ann"}, &botapi.User{ID: 12, FirstName: "Bob"}
+
+	s := service.NewUserXPService(apitest.DB(t))
+
+	s.UpdateXPs(ann, chatA, title, 30)
+	s.UpdateXPs(ann, chatB, title, 30)
+	s.UpdateXPs(bob, chatA, title, 50)
+
+	for chatID, want := range map[int64][][2]int64{
+		chatA:              {{12, 50}, {11, 30}},
+		chatB:              {{11, 30}},
+		model.GlobalChatID: {{11, 60}, {12, 50}},
+	} {
+		got := [][2]int64{}
+		for _, xp := range s.UserXPRepo.TopXPs(chatID, title, "xp DESC", 0, 10, "") {
+			got = append(got, [2]int64{xp.UserID, xp.XP})
+		}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("TopXPs(%d) - Expected %v; Got %v", chatID, want, got)
+		}
+	}
+
+	if total := s.UserRepo.Get(ann).TotalXP(title); total.XP != 60 || total.WeekXP != 60 {
+		t.Errorf("TotalXP() - Expected 60, 60; Got %d, %d", total.XP, total.WeekXP)
+	}
+}
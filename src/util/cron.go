@@ -0,0 +1,163 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearch is how far ahead Next looks for a matching time before giving up, as for
+// "0 0 30 2 *", which never matches.
+const cronSearch = 5 * 366 * 24 * time.Hour
+
+var cronAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// Cron is a parsed cron expression: minute, hour, day of month, month and day of week.
+// Fields may be *, a number, a range such as 1-5, a step such as */15 or 1-30/2, or a
+// comma-separated list of these. Days of the week run from 0 for Sunday, and 7 is also
+// Sunday. The aliases @hourly, @daily, @weekly, @monthly and @yearly are accepted.
+type Cron struct {
+	spec                     string
+	minute, hour, dom, month []bool
+	dow                      []bool
+	domStar, dowStar         bool
+}
+
+// ParseCron parses a cron expression.
+func ParseCron(spec string) (*Cron, error) {
+	fields := strings.Fields(spec)
+	if alias, ok := cronAliases[spec]; ok {
+		fields = strings.Fields(alias)
+	}
+
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron %q: expected 5 fields, got %d", spec, len(fields))
+	}
+
+	c := &Cron{spec: spec, domStar: fields[2] == "*", dowStar: fields[4] == "*"}
+
+	var err error
+
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("cron %q: minute: %w", spec, err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("cron %q: hour: %w", spec, err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("cron %q: day of month: %w", spec, err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("cron %q: month: %w", spec, err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("cron %q: day of week: %w", spec, err)
+	}
+
+	if c.dow[7] {
+		c.dow[0] = true
+	}
+
+	return c, nil
+}
+
+func parseCronField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+
+		if i := strings.Index(part, "/"); i != -1 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad step in %q", part)
+			}
+
+			rng, step = part[:i], n
+		}
+
+		lo, hi := min, max
+
+		if rng != "*" {
+			from, to, isRange := strings.Cut(rng, "-")
+
+			var err error
+			if lo, err = strconv.Atoi(from); err != nil {
+				return nil, fmt.Errorf("bad value in %q", part)
+			}
+
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(to); err != nil {
+					return nil, fmt.Errorf("bad range in %q", part)
+				}
+			} else if step > 1 {
+				// "5/15" runs from 5 to the end, every 15
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+
+		for n := lo; n <= hi; n += step {
+			set[n] = true
+		}
+	}
+
+	return set, nil
+}
+
+// Next returns the first time after t which matches, in t's location, or the zero time
+// if there's none.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	limit := t.Add(cronSearch)
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		y, mo, d := t.Date()
+
+		switch {
+		case !c.month[mo]:
+			t = time.Date(y, mo+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchDay(t):
+			t = time.Date(y, mo, d+1, 0, 0, 0, 0, loc)
+		case !c.hour[t.Hour()]:
+			t = time.Date(y, mo, d, t.Hour()+1, 0, 0, 0, loc)
+		case !c.minute[t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// matchDay follows cron in matching either day field when both are restricted.
+func (c *Cron) matchDay(t time.Time) bool {
+	dom, dow := c.dom[t.Day()], c.dow[t.Weekday()]
+
+	switch {
+	case c.domStar:
+		return dow
+	case c.dowStar:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+func (c *Cron) String() string {
+	return c.spec
+}
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// staleAfter is how many intervals a running job may miss before it's reported stale.
+const staleAfter = 3
+
+var jobs = map[string]*Job{}
+var jobsMut = &sync.Mutex{}
+
+// Job tracks a background job which runs every so often, for the health checks. Calls on a
+// nil Job do nothing, for jobs which don't repeat.
+type Job struct {
+	name  string
+	every time.Duration
+
+	mu      sync.Mutex
+	running bool
+	started time.Time
+	lastRun time.Time
+}
+
+// JobStatus is a job's state when it was checked.
+type JobStatus struct {
+	Name    string    `json:"name"`
+	Every   string    `json:"every"`
+	Running bool      `json:"running"`
+	LastRun time.Time `json:"last_run,omitzero"`
+	// Stale is set when a running job hasn't finished a run in several intervals.
+	Stale bool `json:"stale"`
+}
+
+// NewJob registers a job expected to run every interval, replacing any of the same name.
+func NewJob(name string, every time.Duration) *Job {
+	j := &Job{name: name, every: every}
+
+	jobsMut.Lock()
+	defer jobsMut.Unlock()
+
+	jobs[name] = j
+	return j
+}
+
+// Start marks the job running.
+func (j *Job) Start() {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.running, j.started = true, time.Now()
+}
+
+// Ran records that the job has just finished a run.
+func (j *Job) Ran() {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.lastRun = time.Now()
+}
+
+// Stop marks the job stopped.
+func (j *Job) Stop() {
+	if j == nil {
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.running = false
+}
+
+// Status returns the job's state.
+func (j *Job) Status() JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	since := j.lastRun
+	if since.Before(j.started) {
+		since = j.started
+	}
+
+	return JobStatus{
+		Name:    j.name,
+		Every:   j.every.String(),
+		Running: j.running,
+		LastRun: j.lastRun,
+		Stale:   j.running && time.Since(since) > j.every*staleAfter,
+	}
+}
+
+// Jobs returns the state of every registered job, by name.
+func Jobs() []JobStatus {
+	jobsMut.Lock()
+	all := slices.SortedFunc(maps.Values(jobs), func(a, b *Job) int {
+		return strings.Compare(a.name, b.name)
+	})
+	jobsMut.Unlock()
+
+	res := make([]JobStatus, len(all))
+	for i, j := range all {
+		res[i] = j.Status()
+	}
+
+	return res
+}
@@ -23,23 +23,16 @@ func TryLockFor(key any, dur time.Duration) bool {
 	return true
 }
 
-var breaker = true
-
-func InitLockerTidy(every time.Duration) {
-	breaker = true
-
-	go func() {
-		for breaker {
-			time.Sleep(every)
-			for key, lock := range locks {
-				if lock.Before(time.Now()) {
-					delete(locks, key)
-				}
-			}
-		}
-	}()
-}
+// TidyLocks forgets locks which have expired.
+func TidyLocks() {
+	mut.Lock()
+	defer mut.Unlock()
 
-func HaultLockerTidy() {
-	breaker = false
+	now := time.Now()
+
+	for key, lock := range locks {
+		if lock.Before(now) {
+			delete(locks, key)
+		}
+	}
 }